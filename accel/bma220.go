@@ -8,6 +8,7 @@ import (
 )
 
 const (
+	regChipID        = 0x00
 	regRange         = 0x22
 	regLatch         = 0x1C
 	regSlopeSettings = 0x12
@@ -18,6 +19,9 @@ const (
 
 const addr = 0x0A
 
+// ChipID is the content of the BMA220 chip ID register.
+const ChipID = 0xDD
+
 // BMA220 represents Bosh BMA220 accelerometer
 type BMA220 struct {
 	transport sensors.I2CBus
@@ -69,13 +73,20 @@ func (b *BMA220) InitMotionDetection(ctx context.Context) error {
 	return nil
 }
 
-func (b *BMA220) CheckMotionInterrupt(ctx context.Context) (int, error) {
-	err := b.transport.WriteToAddr(ctx, addr, []byte{regInterrupts})
+// ReadChipID returns the content of the chip ID register, which is ChipID
+// for a BMA220.
+func (b *BMA220) ReadChipID(ctx context.Context) (byte, error) {
+	buf := []byte{0x00}
+	err := sensors.WriteRead(ctx, b.transport, addr, []byte{regChipID}, buf)
 	if err != nil {
-		return 0, fmt.Errorf("could not set registry pointer: %w", err)
+		return 0, fmt.Errorf("could not read chip id: %w", err)
 	}
+	return buf[0], nil
+}
+
+func (b *BMA220) CheckMotionInterrupt(ctx context.Context) (int, error) {
 	buf := []byte{0x00}
-	err = b.transport.ReadFromAddr(ctx, addr, buf)
+	err := sensors.WriteRead(ctx, b.transport, addr, []byte{regInterrupts}, buf)
 	if err != nil {
 		return 0, fmt.Errorf("could not read registry content: %w", err)
 	}
@@ -0,0 +1,86 @@
+package adapter
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strconv"
+
+	"github.com/mklimuk/sensors"
+)
+
+func init() {
+	sensors.RegisterBus("mcp2221", openMCP2221URL)
+	sensors.RegisterBus("cp2112", openCP2112URL)
+}
+
+// openMCP2221URL opens mcp2221://?serial=X&path=P&index=N&product=00dd&speed=100k.
+func openMCP2221URL(ctx context.Context, u *url.URL) (sensors.I2CBus, error) {
+	err := sensors.CheckBusQuery(u, "serial", "path", "index", "product", "speed")
+	if err != nil {
+		return nil, err
+	}
+	q := u.Query()
+	var opts []MCP2221Option
+	if serial := q.Get("serial"); serial != "" {
+		opts = append(opts, WithSerial(serial))
+	}
+	if path := q.Get("path"); path != "" {
+		opts = append(opts, WithPath(path))
+	}
+	if index := q.Get("index"); index != "" {
+		i, err := strconv.Atoi(index)
+		if err != nil {
+			return nil, fmt.Errorf("invalid index %q", index)
+		}
+		opts = append(opts, WithDeviceIndex(i))
+	}
+	if product := q.Get("product"); product != "" {
+		id, err := strconv.ParseUint(product, 16, 16)
+		if err != nil {
+			return nil, fmt.Errorf("invalid product %q, expected hex like 00dd", product)
+		}
+		opts = append(opts, WithProductID(uint16(id)))
+	}
+	if speed := q.Get("speed"); speed != "" {
+		hz, err := sensors.ParseSpeed(speed)
+		if err != nil {
+			return nil, err
+		}
+		_, err = i2cSpeedDivider(hz)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, WithI2CSpeed(hz))
+	}
+	d := NewMCP2221(opts...)
+	err = d.Init()
+	if err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+// openCP2112URL opens cp2112://?serial=X&path=P&speed=100k.
+func openCP2112URL(ctx context.Context, u *url.URL) (sensors.I2CBus, error) {
+	err := sensors.CheckBusQuery(u, "serial", "path", "speed")
+	if err != nil {
+		return nil, err
+	}
+	q := u.Query()
+	var opts []CP2112Option
+	if serial := q.Get("serial"); serial != "" {
+		opts = append(opts, WithCP2112Serial(serial))
+	}
+	if path := q.Get("path"); path != "" {
+		opts = append(opts, WithCP2112Path(path))
+	}
+	if speed := q.Get("speed"); speed != "" {
+		hz, err := sensors.ParseSpeed(speed)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, WithCP2112Speed(hz))
+	}
+	return NewCP2112(opts...), nil
+}
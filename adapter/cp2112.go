@@ -0,0 +1,530 @@
+package adapter
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/karalabe/hid"
+	"github.com/mklimuk/sensors"
+	"github.com/mklimuk/sensors/cmd/sensors/console"
+	"github.com/mklimuk/sensors/snsctx"
+)
+
+var _ sensors.I2CBus = &CP2112{}
+var _ sensors.I2CTransactor = &CP2112{}
+var _ sensors.AddressLocker = &CP2112{}
+
+// Silicon Labs CP2112 default USB ids
+const (
+	CP2112VendorID  = 0x10C4
+	CP2112ProductID = 0xEA90
+)
+
+// CP2112 report ids. Configuration and GPIO use feature reports, transfers
+// use interrupt reports.
+const (
+	cp2112ReportReset            = 0x01
+	cp2112ReportGPIOConfig       = 0x02
+	cp2112ReportGetGPIO          = 0x03
+	cp2112ReportSetGPIO          = 0x04
+	cp2112ReportVersion          = 0x05
+	cp2112ReportSMBusConfig      = 0x06
+	cp2112ReportReadRequest      = 0x10
+	cp2112ReportWriteReadRequest = 0x11
+	cp2112ReportReadForceSend    = 0x12
+	cp2112ReportReadResponse     = 0x13
+	cp2112ReportWrite            = 0x14
+	cp2112ReportStatusRequest    = 0x15
+	cp2112ReportStatusResponse   = 0x16
+	cp2112ReportCancel           = 0x17
+)
+
+// Transfer status (status 0 of the Transfer Status Response)
+const (
+	cp2112StatusIdle     = 0x00
+	cp2112StatusBusy     = 0x01
+	cp2112StatusComplete = 0x02
+	cp2112StatusError    = 0x03
+)
+
+// Transfer error details (status 1 when status 0 is cp2112StatusError)
+const (
+	cp2112ErrorAddressNACK     = 0x00
+	cp2112ErrorBusNotFree      = 0x01
+	cp2112ErrorArbitration     = 0x02
+	cp2112ErrorReadIncomplete  = 0x03
+	cp2112ErrorWriteIncomplete = 0x04
+	cp2112ErrorRetried         = 0x05
+)
+
+// Transfer limits
+const (
+	cp2112MaxWrite        = 61
+	cp2112MaxRead         = 512
+	cp2112MaxWriteRead    = 16
+	cp2112ReportSize      = 64
+	cp2112ResponseRetries = 8
+)
+
+var ErrCP2112TransferFailed = errors.New("cp2112 transfer failed")
+
+type CP2112Options struct {
+	VendorID    uint16
+	ProductID   uint16
+	DeviceIndex int
+	Serial      string
+	Path        string
+	// I2CSpeed is the SMBus clock in Hz applied when connecting; the chip
+	// setting is kept when 0
+	I2CSpeed int
+	// Transport enumerates and opens the bridges; hidraw on Linux and USB HID
+	// elsewhere when nil
+	Transport HIDTransport
+}
+
+type CP2112Option func(*CP2112Options)
+
+// WithCP2112Serial selects the bridge with the given USB serial number.
+func WithCP2112Serial(serial string) CP2112Option {
+	return func(o *CP2112Options) {
+		o.Serial = serial
+	}
+}
+
+// WithCP2112Path selects the bridge at the given HID path.
+func WithCP2112Path(path string) CP2112Option {
+	return func(o *CP2112Options) {
+		o.Path = path
+	}
+}
+
+// WithCP2112Speed sets the SMBus clock in Hz.
+func WithCP2112Speed(hz int) CP2112Option {
+	return func(o *CP2112Options) {
+		o.I2CSpeed = hz
+	}
+}
+
+// WithCP2112Transport replaces the HID transport, typically in tests.
+func WithCP2112Transport(transport HIDTransport) CP2112Option {
+	return func(o *CP2112Options) {
+		o.Transport = transport
+	}
+}
+
+func (o CP2112Options) transport() HIDTransport {
+	if o.Transport == nil {
+		return defaultCP2112Transport
+	}
+	return o.Transport
+}
+
+func (o CP2112Options) selectDevice() (hid.DeviceInfo, error) {
+	dev, matching, ok := selectHIDDevice(o.transport().Enumerate(o.VendorID, o.ProductID), o.Path, o.Serial, o.DeviceIndex)
+	if !ok {
+		return hid.DeviceInfo{}, fmt.Errorf("%w: cp2112 vendor: %#x product: %#x (%d matching)", ErrDeviceNotFound, o.VendorID, o.ProductID, matching)
+	}
+	return dev, nil
+}
+
+// CP2112 is a Silicon Labs CP2112 HID USB to SMBus/I2C bridge. Like MCP2221
+// it opens the HID device for every call unless a sticky session is started
+// with Open.
+type CP2112 struct {
+	mx          sync.Mutex
+	addrLocksMx sync.Mutex
+	addrLocks   map[byte]*sync.Mutex
+	options     CP2112Options
+	device      HIDDevice
+	keepOpen    bool
+	request     []byte
+	response    []byte
+}
+
+func NewCP2112(opts ...CP2112Option) *CP2112 {
+	options := CP2112Options{
+		VendorID:  CP2112VendorID,
+		ProductID: CP2112ProductID,
+	}
+	for _, opt := range opts {
+		opt(&options)
+	}
+	return &CP2112{
+		addrLocks: make(map[byte]*sync.Mutex),
+		options:   options,
+		request:   make([]byte, cp2112ReportSize),
+		response:  make([]byte, cp2112ReportSize),
+	}
+}
+
+func (d *CP2112) addrMutex(addr byte) *sync.Mutex {
+	d.addrLocksMx.Lock()
+	defer d.addrLocksMx.Unlock()
+	mu, ok := d.addrLocks[addr]
+	if !ok {
+		mu = &sync.Mutex{}
+		d.addrLocks[addr] = mu
+	}
+	return mu
+}
+
+// LockAddr acquires an exclusive per-address lock.
+func (d *CP2112) LockAddr(addr byte) {
+	d.addrMutex(addr).Lock()
+}
+
+// UnlockAddr releases the per-address lock acquired by LockAddr.
+func (d *CP2112) UnlockAddr(addr byte) {
+	d.addrMutex(addr).Unlock()
+}
+
+// Open starts a sticky session reusing one HID handle until Close.
+func (d *CP2112) Open(ctx context.Context) error {
+	d.mx.Lock()
+	defer d.mx.Unlock()
+	d.keepOpen = true
+	return d.connect()
+}
+
+// Close ends the sticky session and releases the HID handle.
+func (d *CP2112) Close() error {
+	d.mx.Lock()
+	defer d.mx.Unlock()
+	d.keepOpen = false
+	return d.invalidate()
+}
+
+func (d *CP2112) connect() error {
+	if d.device != nil {
+		return nil
+	}
+	info, err := d.options.selectDevice()
+	if err != nil {
+		return err
+	}
+	device, err := d.options.transport().Open(info)
+	if err != nil {
+		return fmt.Errorf("could not open hid device %s: %w", info.Path, err)
+	}
+	d.device = device
+	if d.options.I2CSpeed != 0 {
+		err = d.applySpeed()
+		if err != nil {
+			_ = d.invalidate()
+			return fmt.Errorf("could not apply i2c speed: %w", err)
+		}
+	}
+	return nil
+}
+
+// applySpeed writes the configured clock into the SMBus configuration unless
+// the chip already uses it. Callers must hold d.mx and be connected.
+func (d *CP2112) applySpeed() error {
+	cfg, err := d.doGetSMBusConfig()
+	if err != nil {
+		return err
+	}
+	if cfg.ClockSpeed == d.options.I2CSpeed {
+		return nil
+	}
+	cfg.ClockSpeed = d.options.I2CSpeed
+	return d.doSetSMBusConfig(cfg)
+}
+
+func (d *CP2112) disconnect() error {
+	if d.keepOpen {
+		return nil
+	}
+	return d.invalidate()
+}
+
+func (d *CP2112) invalidate() error {
+	if d.device == nil {
+		return nil
+	}
+	err := d.device.Close()
+	d.device = nil
+	if err != nil {
+		return fmt.Errorf("could not close hid device: %w", err)
+	}
+	return nil
+}
+
+// session connects for the duration of a public call and returns the
+// function ending it.
+func (d *CP2112) session() (func(), error) {
+	err := d.connect()
+	if err != nil {
+		return nil, fmt.Errorf("could not connect to cp2112: %w", err)
+	}
+	return func() {
+		err := d.disconnect()
+		if err != nil {
+			slog.Error("could not disconnect from cp2112", "err", err)
+		}
+	}, nil
+}
+
+func (d *CP2112) WriteToAddr(ctx context.Context, address byte, buffer []byte) error {
+	if len(buffer) == 0 {
+		return fmt.Errorf("i2c write to %x: cp2112 zero-length writes %w", address, sensors.ErrUnsupported)
+	}
+	if len(buffer) > cp2112MaxWrite {
+		return fmt.Errorf("i2c write to %x of %d bytes: cp2112 writes 1 to %d bytes: %w", address, len(buffer), cp2112MaxWrite, sensors.ErrInvalidArgument)
+	}
+	d.mx.Lock()
+	defer d.mx.Unlock()
+	end, err := d.session()
+	if err != nil {
+		return err
+	}
+	defer end()
+	d.resetBuffers()
+	d.request[0] = cp2112ReportWrite
+	d.request[1] = address << 1
+	d.request[2] = byte(len(buffer))
+	copy(d.request[3:], buffer)
+	err = d.send(ctx)
+	if err != nil {
+		return fmt.Errorf("i2c write to %x request failed: %w", address, err)
+	}
+	_, err = d.waitTransfer(ctx, address)
+	return err
+}
+
+func (d *CP2112) ReadFromAddr(ctx context.Context, address byte, buffer []byte) error {
+	if len(buffer) == 0 || len(buffer) > cp2112MaxRead {
+		return fmt.Errorf("i2c read from %x of %d bytes: cp2112 reads 1 to %d bytes", address, len(buffer), cp2112MaxRead)
+	}
+	d.mx.Lock()
+	defer d.mx.Unlock()
+	end, err := d.session()
+	if err != nil {
+		return err
+	}
+	defer end()
+	d.resetBuffers()
+	d.request[0] = cp2112ReportReadRequest
+	d.request[1] = address << 1
+	binary.BigEndian.PutUint16(d.request[2:4], uint16(len(buffer)))
+	err = d.send(ctx)
+	if err != nil {
+		return fmt.Errorf("i2c read from %x request failed: %w", address, err)
+	}
+	return d.collect(ctx, address, buffer)
+}
+
+// Tx writes w and reads len(r) bytes back after a repeated start using the
+// Data Write Read Request, which carries up to 16 bytes of w. Longer writes
+// fall back to a separate write and read.
+func (d *CP2112) Tx(ctx context.Context, address byte, w, r []byte) error {
+	switch {
+	case len(r) == 0:
+		return d.WriteToAddr(ctx, address, w)
+	case len(w) == 0:
+		return d.ReadFromAddr(ctx, address, r)
+	case len(w) > cp2112MaxWriteRead:
+		err := d.WriteToAddr(ctx, address, w)
+		if err != nil {
+			return err
+		}
+		return d.ReadFromAddr(ctx, address, r)
+	case len(r) > cp2112MaxRead:
+		return fmt.Errorf("i2c read from %x of %d bytes: cp2112 reads 1 to %d bytes", address, len(r), cp2112MaxRead)
+	}
+	d.mx.Lock()
+	defer d.mx.Unlock()
+	end, err := d.session()
+	if err != nil {
+		return err
+	}
+	defer end()
+	d.resetBuffers()
+	d.request[0] = cp2112ReportWriteReadRequest
+	d.request[1] = address << 1
+	binary.BigEndian.PutUint16(d.request[2:4], uint16(len(r)))
+	d.request[4] = byte(len(w))
+	copy(d.request[5:], w)
+	err = d.send(ctx)
+	if err != nil {
+		return fmt.Errorf("i2c transaction with %x request failed: %w", address, err)
+	}
+	return d.collect(ctx, address, r)
+}
+
+// Release cancels the pending transfer.
+func (d *CP2112) Release(ctx context.Context) error {
+	d.mx.Lock()
+	defer d.mx.Unlock()
+	end, err := d.session()
+	if err != nil {
+		return err
+	}
+	defer end()
+	d.resetBuffers()
+	d.request[0] = cp2112ReportCancel
+	d.request[1] = 0x01
+	err = d.send(ctx)
+	if err != nil {
+		return fmt.Errorf("cancel transfer request failed: %w", err)
+	}
+	return nil
+}
+
+// collect waits for a read transfer to complete and then fetches the data
+// with Data Read Force Send requests. Callers must hold d.mx and be
+// connected.
+func (d *CP2112) collect(ctx context.Context, address byte, buffer []byte) error {
+	n, err := d.waitTransfer(ctx, address)
+	if err != nil {
+		return err
+	}
+	if n < len(buffer) {
+		return fmt.Errorf("i2c read from %x returned %d of %d bytes: %w", address, n, len(buffer), ErrCP2112TransferFailed)
+	}
+	received := 0
+	deadline := time.Now().Add(maxDelay)
+	for received < len(buffer) {
+		d.resetBuffers()
+		d.request[0] = cp2112ReportReadForceSend
+		binary.BigEndian.PutUint16(d.request[1:3], uint16(len(buffer)-received))
+		err = d.send(ctx)
+		if err != nil {
+			return fmt.Errorf("i2c read from %x force send failed: %w", address, err)
+		}
+		// a force send is answered with as many reports as needed to carry
+		// the requested data, or a single empty one when none is buffered
+		for received < len(buffer) {
+			size, err := d.receiveData(ctx, address, buffer[received:])
+			if err != nil {
+				return err
+			}
+			if size == 0 {
+				break
+			}
+			received += size
+			deadline = time.Now().Add(maxDelay)
+		}
+		if received == len(buffer) {
+			return nil
+		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf("i2c read from %x stalled after %d of %d bytes: %w", address, received, len(buffer), ErrI2CEngineTimeout)
+		}
+		err = pause(ctx, pollDelay)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// receiveData copies the data of a Data Read Response into dst and returns
+// its size. Callers must hold d.mx and be connected.
+func (d *CP2112) receiveData(ctx context.Context, address byte, dst []byte) (int, error) {
+	err := d.receive(ctx, cp2112ReportReadResponse)
+	if err != nil {
+		return 0, fmt.Errorf("i2c read from %x response receive failed: %w", address, err)
+	}
+	if d.response[1] == cp2112StatusError {
+		return 0, fmt.Errorf("i2c read from %x: %w", address, ErrCP2112TransferFailed)
+	}
+	size := int(d.response[2])
+	if size > len(dst) || size > cp2112ReportSize-3 {
+		return 0, fmt.Errorf("invalid data size byte; expected at most %d, got %d", len(dst), size)
+	}
+	copy(dst, d.response[3:3+size])
+	return size, nil
+}
+
+// waitTransfer polls the transfer status until the transfer completes and
+// returns the number of bytes read. Callers must hold d.mx and be connected.
+func (d *CP2112) waitTransfer(ctx context.Context, address byte) (int, error) {
+	deadline := time.Now().Add(i2cDelay)
+	for {
+		d.resetBuffers()
+		d.request[0] = cp2112ReportStatusRequest
+		d.request[1] = 0x01
+		err := d.send(ctx)
+		if err != nil {
+			return 0, fmt.Errorf("could not send transfer status request: %w", err)
+		}
+		err = d.receive(ctx, cp2112ReportStatusResponse)
+		if err != nil {
+			return 0, fmt.Errorf("could not receive transfer status: %w", err)
+		}
+		read := int(binary.BigEndian.Uint16(d.response[5:7]))
+		switch d.response[1] {
+		case cp2112StatusComplete:
+			return read, nil
+		case cp2112StatusError:
+			return 0, transferError(address, d.response[2])
+		}
+		if time.Now().After(deadline) {
+			return 0, fmt.Errorf("i2c transfer with %x not completed: %w", address, ErrI2CEngineTimeout)
+		}
+		err = pause(ctx, pollDelay)
+		if err != nil {
+			return 0, err
+		}
+	}
+}
+
+// transferError maps the error details of a transfer status to an error.
+func transferError(address byte, detail byte) error {
+	switch detail {
+	case cp2112ErrorAddressNACK:
+		return fmt.Errorf("i2c transfer: %w", &sensors.NACKError{Address: address})
+	case cp2112ErrorBusNotFree, cp2112ErrorArbitration:
+		return fmt.Errorf("i2c transfer with %x (error %#x): %w", address, detail, sensors.ErrBusBusy)
+	case cp2112ErrorReadIncomplete:
+		return fmt.Errorf("i2c read from %x incomplete: %w", address, ErrCP2112TransferFailed)
+	case cp2112ErrorWriteIncomplete:
+		return fmt.Errorf("i2c write to %x incomplete: %w", address, ErrCP2112TransferFailed)
+	default:
+		return fmt.Errorf("i2c transfer with %x (error %#x): %w", address, detail, ErrCP2112TransferFailed)
+	}
+}
+
+func (d *CP2112) resetBuffers() {
+	resetBuffer(d.request)
+	resetBuffer(d.response)
+}
+
+func (d *CP2112) send(ctx context.Context) error {
+	if snsctx.IsVerbose(ctx) {
+		console.Printf("sending report to cp2112:\n%s\n", hex.Dump(d.request))
+	}
+	_, err := d.device.Write(d.request)
+	if err != nil {
+		_ = d.invalidate()
+		return fmt.Errorf("could not write request: %w: %w", sensors.ErrDisconnected, err)
+	}
+	return nil
+}
+
+// receive reads input reports until one with the given id arrives. Reports
+// left over from an earlier transfer are skipped.
+func (d *CP2112) receive(ctx context.Context, id byte) error {
+	for range cp2112ResponseRetries {
+		n, err := d.device.Read(d.response)
+		if err != nil {
+			_ = d.invalidate()
+			return fmt.Errorf("could not read response: %w: %w", sensors.ErrDisconnected, err)
+		}
+		if snsctx.IsVerbose(ctx) {
+			console.Printf("read report from cp2112:\n%s\n", hex.Dump(d.response[:n]))
+		}
+		if n > 0 && d.response[0] == id {
+			return nil
+		}
+		slog.Debug("skipping unexpected cp2112 report", "id", d.response[0], "expected", id)
+	}
+	return fmt.Errorf("no report %#x received", id)
+}
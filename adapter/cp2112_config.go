@@ -0,0 +1,289 @@
+package adapter
+
+import (
+	"encoding/binary"
+	"fmt"
+	"time"
+
+	"github.com/mklimuk/sensors"
+)
+
+var ErrFeatureReportsUnsupported = fmt.Errorf("HID transport feature reports %w", sensors.ErrUnsupported)
+
+// HIDFeatureDevice is implemented by HID devices able to exchange feature
+// reports, which the CP2112 uses for its configuration and GPIO. Reports
+// start with their id; GetFeatureReport fills report after the id.
+type HIDFeatureDevice interface {
+	SendFeatureReport(report []byte) (int, error)
+	GetFeatureReport(report []byte) (int, error)
+}
+
+// Feature report sizes, report id included
+const (
+	cp2112GPIOConfigSize  = 5
+	cp2112GetGPIOSize     = 2
+	cp2112VersionSize     = 3
+	cp2112SMBusConfigSize = 14
+)
+
+// CP2112SMBusConfig is the SMBus configuration of the bridge.
+type CP2112SMBusConfig struct {
+	// ClockSpeed is the SMBus clock in Hz
+	ClockSpeed int `yaml:"clock_speed"`
+	// DeviceAddress is the address of the bridge itself, acknowledged but
+	// not answered
+	DeviceAddress byte `yaml:"device_address"`
+	// AutoSendRead makes the bridge send read data as it arrives instead of
+	// waiting for a Data Read Force Send request
+	AutoSendRead bool `yaml:"auto_send_read"`
+	// WriteTimeout and ReadTimeout limit the transfers, 0 disables them
+	WriteTimeout time.Duration `yaml:"write_timeout"`
+	ReadTimeout  time.Duration `yaml:"read_timeout"`
+	// SCLLowTimeout resets the SMBus engine when SCL is held low over 25ms
+	SCLLowTimeout bool `yaml:"scl_low_timeout"`
+	// RetryTime is the number of address retries, 0 for no limit
+	RetryTime int `yaml:"retry_time"`
+}
+
+func decodeSMBusConfig(src []byte) CP2112SMBusConfig {
+	return CP2112SMBusConfig{
+		ClockSpeed:    int(binary.BigEndian.Uint32(src[0:4])),
+		DeviceAddress: src[4] >> 1,
+		AutoSendRead:  src[5] != 0,
+		WriteTimeout:  time.Duration(binary.BigEndian.Uint16(src[6:8])) * time.Millisecond,
+		ReadTimeout:   time.Duration(binary.BigEndian.Uint16(src[8:10])) * time.Millisecond,
+		SCLLowTimeout: src[10] != 0,
+		RetryTime:     int(binary.BigEndian.Uint16(src[11:13])),
+	}
+}
+
+func (c CP2112SMBusConfig) encode(dst []byte) {
+	binary.BigEndian.PutUint32(dst[0:4], uint32(c.ClockSpeed))
+	dst[4] = c.DeviceAddress << 1
+	dst[5] = boolByte(c.AutoSendRead)
+	binary.BigEndian.PutUint16(dst[6:8], uint16(c.WriteTimeout/time.Millisecond))
+	binary.BigEndian.PutUint16(dst[8:10], uint16(c.ReadTimeout/time.Millisecond))
+	dst[10] = boolByte(c.SCLLowTimeout)
+	binary.BigEndian.PutUint16(dst[11:13], uint16(c.RetryTime))
+}
+
+func boolByte(v bool) byte {
+	if v {
+		return 1
+	}
+	return 0
+}
+
+// CP2112GPIOConfig is the configuration of the GPIO.0 to GPIO.7 pins, one
+// bit per pin.
+type CP2112GPIOConfig struct {
+	// Direction has the bits of output pins set
+	Direction byte `yaml:"direction"`
+	// PushPull has the bits of push-pull outputs set, the other outputs are
+	// open-drain
+	PushPull byte `yaml:"push_pull"`
+	// Special enables the alternate functions: bit 0 the clock output on
+	// GPIO.7, bit 1 the TX LED on GPIO.0 and bit 2 the RX LED on GPIO.1
+	Special byte `yaml:"special"`
+	// ClockDivider sets the GPIO.7 clock to 48MHz / (2 * divider), 24MHz
+	// when 0
+	ClockDivider byte `yaml:"clock_divider"`
+}
+
+// CP2112Version identifies the chip.
+type CP2112Version struct {
+	PartNumber byte `yaml:"part_number"`
+	Version    byte `yaml:"version"`
+}
+
+// SMBusConfig returns the current SMBus configuration.
+func (d *CP2112) SMBusConfig() (CP2112SMBusConfig, error) {
+	d.mx.Lock()
+	defer d.mx.Unlock()
+	end, err := d.session()
+	if err != nil {
+		return CP2112SMBusConfig{}, err
+	}
+	defer end()
+	return d.doGetSMBusConfig()
+}
+
+// SetSMBusConfig changes the SMBus configuration. It is volatile and reverts
+// to the defaults when the bridge is reset.
+func (d *CP2112) SetSMBusConfig(cfg CP2112SMBusConfig) error {
+	if cfg.ClockSpeed <= 0 {
+		return fmt.Errorf("invalid clock speed %d", cfg.ClockSpeed)
+	}
+	d.mx.Lock()
+	defer d.mx.Unlock()
+	end, err := d.session()
+	if err != nil {
+		return err
+	}
+	defer end()
+	return d.doSetSMBusConfig(cfg)
+}
+
+func (d *CP2112) doGetSMBusConfig() (CP2112SMBusConfig, error) {
+	report, err := d.getFeature(cp2112ReportSMBusConfig, cp2112SMBusConfigSize)
+	if err != nil {
+		return CP2112SMBusConfig{}, fmt.Errorf("could not read SMBus configuration: %w", err)
+	}
+	return decodeSMBusConfig(report[1:]), nil
+}
+
+func (d *CP2112) doSetSMBusConfig(cfg CP2112SMBusConfig) error {
+	report := make([]byte, cp2112SMBusConfigSize)
+	report[0] = cp2112ReportSMBusConfig
+	cfg.encode(report[1:])
+	err := d.sendFeature(report)
+	if err != nil {
+		return fmt.Errorf("could not write SMBus configuration: %w", err)
+	}
+	return nil
+}
+
+// GPIOConfig returns the GPIO configuration.
+func (d *CP2112) GPIOConfig() (CP2112GPIOConfig, error) {
+	d.mx.Lock()
+	defer d.mx.Unlock()
+	end, err := d.session()
+	if err != nil {
+		return CP2112GPIOConfig{}, err
+	}
+	defer end()
+	report, err := d.getFeature(cp2112ReportGPIOConfig, cp2112GPIOConfigSize)
+	if err != nil {
+		return CP2112GPIOConfig{}, fmt.Errorf("could not read GPIO configuration: %w", err)
+	}
+	return CP2112GPIOConfig{
+		Direction:    report[1],
+		PushPull:     report[2],
+		Special:      report[3],
+		ClockDivider: report[4],
+	}, nil
+}
+
+// SetGPIOConfig changes the GPIO configuration.
+func (d *CP2112) SetGPIOConfig(cfg CP2112GPIOConfig) error {
+	d.mx.Lock()
+	defer d.mx.Unlock()
+	end, err := d.session()
+	if err != nil {
+		return err
+	}
+	defer end()
+	err = d.sendFeature([]byte{cp2112ReportGPIOConfig, cfg.Direction, cfg.PushPull, cfg.Special, cfg.ClockDivider})
+	if err != nil {
+		return fmt.Errorf("could not write GPIO configuration: %w", err)
+	}
+	return nil
+}
+
+// ReadGPIO returns the levels of GPIO.0 to GPIO.7, one bit per pin.
+func (d *CP2112) ReadGPIO() (byte, error) {
+	d.mx.Lock()
+	defer d.mx.Unlock()
+	end, err := d.session()
+	if err != nil {
+		return 0, err
+	}
+	defer end()
+	report, err := d.getFeature(cp2112ReportGetGPIO, cp2112GetGPIOSize)
+	if err != nil {
+		return 0, fmt.Errorf("could not read GPIO values: %w", err)
+	}
+	return report[1], nil
+}
+
+// WriteGPIO drives the output pins selected by mask to the levels of value.
+func (d *CP2112) WriteGPIO(value byte, mask byte) error {
+	d.mx.Lock()
+	defer d.mx.Unlock()
+	end, err := d.session()
+	if err != nil {
+		return err
+	}
+	defer end()
+	err = d.sendFeature([]byte{cp2112ReportSetGPIO, value, mask})
+	if err != nil {
+		return fmt.Errorf("could not write GPIO values: %w", err)
+	}
+	return nil
+}
+
+// Version returns the part number and the firmware version of the chip.
+func (d *CP2112) Version() (CP2112Version, error) {
+	d.mx.Lock()
+	defer d.mx.Unlock()
+	end, err := d.session()
+	if err != nil {
+		return CP2112Version{}, err
+	}
+	defer end()
+	report, err := d.getFeature(cp2112ReportVersion, cp2112VersionSize)
+	if err != nil {
+		return CP2112Version{}, fmt.Errorf("could not read version: %w", err)
+	}
+	return CP2112Version{PartNumber: report[1], Version: report[2]}, nil
+}
+
+// Reset restarts the bridge, which then enumerates again with the default
+// configuration.
+func (d *CP2112) Reset() error {
+	d.mx.Lock()
+	defer d.mx.Unlock()
+	end, err := d.session()
+	if err != nil {
+		return err
+	}
+	defer end()
+	err = d.sendFeature([]byte{cp2112ReportReset, 0x01})
+	if err != nil {
+		return fmt.Errorf("could not reset cp2112: %w", err)
+	}
+	return d.invalidate()
+}
+
+func (d *CP2112) features() (HIDFeatureDevice, error) {
+	dev, ok := d.device.(HIDFeatureDevice)
+	if !ok {
+		return nil, ErrFeatureReportsUnsupported
+	}
+	return dev, nil
+}
+
+// getFeature reads the feature report id of the given size, id included.
+// Callers must hold d.mx and be connected.
+func (d *CP2112) getFeature(id byte, size int) ([]byte, error) {
+	dev, err := d.features()
+	if err != nil {
+		return nil, err
+	}
+	report := make([]byte, size)
+	report[0] = id
+	n, err := dev.GetFeatureReport(report)
+	if err != nil {
+		_ = d.invalidate()
+		return nil, fmt.Errorf("%w: %w", sensors.ErrDisconnected, err)
+	}
+	if n < size {
+		return nil, fmt.Errorf("short feature report %#x: %d", id, n)
+	}
+	return report, nil
+}
+
+// sendFeature sends a feature report starting with its id. Callers must
+// hold d.mx and be connected.
+func (d *CP2112) sendFeature(report []byte) error {
+	dev, err := d.features()
+	if err != nil {
+		return err
+	}
+	_, err = dev.SendFeatureReport(report)
+	if err != nil {
+		_ = d.invalidate()
+		return fmt.Errorf("%w: %w", sensors.ErrDisconnected, err)
+	}
+	return nil
+}
@@ -0,0 +1,262 @@
+package adapter
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"testing"
+
+	"github.com/karalabe/hid"
+	"github.com/mklimuk/sensors"
+	"github.com/mklimuk/sensors/sim"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeCP2112 answers the CP2112 reports, forwarding transfers to a sim.Bus.
+type fakeCP2112 struct {
+	bus     *sim.Bus
+	opens   int
+	cancels int
+	// busyPolls is the number of status requests answered with busy
+	busyPolls int
+	// failure, when set, is the error detail of the next transfer
+	failure  *byte
+	status   []byte
+	readData []byte
+	queue    [][]byte
+	smbus    [13]byte
+	gpio     [4]byte
+	latch    byte
+}
+
+func newFakeCP2112(bus *sim.Bus) *fakeCP2112 {
+	f := &fakeCP2112{bus: bus}
+	CP2112SMBusConfig{ClockSpeed: 100000, RetryTime: 0}.encode(f.smbus[:])
+	return f
+}
+
+func (f *fakeCP2112) Enumerate(vendorID uint16, productID uint16) []hid.DeviceInfo {
+	if vendorID != CP2112VendorID || productID != CP2112ProductID {
+		return nil
+	}
+	return []hid.DeviceInfo{{Path: "fake-cp2112", VendorID: vendorID, ProductID: productID, Serial: "0001"}}
+}
+
+func (f *fakeCP2112) Open(info hid.DeviceInfo) (HIDDevice, error) {
+	f.opens++
+	return f, nil
+}
+
+func (f *fakeCP2112) Close() error {
+	return nil
+}
+
+func (f *fakeCP2112) complete(err error) {
+	f.status = []byte{cp2112StatusComplete, 0x05}
+	if f.failure != nil {
+		f.status = []byte{cp2112StatusError, *f.failure}
+		f.failure = nil
+		f.readData = nil
+		return
+	}
+	if err != nil {
+		f.status = []byte{cp2112StatusError, cp2112ErrorAddressNACK}
+		f.readData = nil
+	}
+}
+
+func (f *fakeCP2112) Write(report []byte) (int, error) {
+	ctx := context.Background()
+	address := report[1] >> 1
+	switch report[0] {
+	case cp2112ReportWrite:
+		f.readData = nil
+		f.complete(f.bus.WriteToAddr(ctx, address, report[3:3+report[2]]))
+	case cp2112ReportReadRequest:
+		f.readData = make([]byte, binary.BigEndian.Uint16(report[2:4]))
+		f.complete(f.bus.ReadFromAddr(ctx, address, f.readData))
+	case cp2112ReportWriteReadRequest:
+		f.readData = make([]byte, binary.BigEndian.Uint16(report[2:4]))
+		f.complete(sensors.WriteRead(ctx, f.bus, address, report[5:5+report[4]], f.readData))
+	case cp2112ReportStatusRequest:
+		res := make([]byte, cp2112ReportSize)
+		res[0] = cp2112ReportStatusResponse
+		if f.busyPolls > 0 {
+			f.busyPolls--
+			res[1] = cp2112StatusBusy
+		} else {
+			copy(res[1:3], f.status)
+			binary.BigEndian.PutUint16(res[5:7], uint16(len(f.readData)))
+		}
+		f.queue = append(f.queue, res)
+	case cp2112ReportReadForceSend:
+		remaining := min(int(binary.BigEndian.Uint16(report[1:3])), len(f.readData))
+		if remaining == 0 {
+			f.queue = append(f.queue, []byte{cp2112ReportReadResponse, cp2112StatusIdle, 0})
+		}
+		for remaining > 0 {
+			n := min(remaining, cp2112ReportSize-3)
+			res := make([]byte, cp2112ReportSize)
+			res[0] = cp2112ReportReadResponse
+			res[1] = cp2112StatusComplete
+			res[2] = byte(n)
+			copy(res[3:], f.readData[:n])
+			f.readData = f.readData[n:]
+			f.queue = append(f.queue, res)
+			remaining -= n
+		}
+	case cp2112ReportCancel:
+		f.cancels++
+	}
+	return len(report), nil
+}
+
+func (f *fakeCP2112) Read(report []byte) (int, error) {
+	if len(f.queue) == 0 {
+		return 0, errors.New("fake cp2112: no report")
+	}
+	n := copy(report, f.queue[0])
+	f.queue = f.queue[1:]
+	return n, nil
+}
+
+func (f *fakeCP2112) SendFeatureReport(report []byte) (int, error) {
+	switch report[0] {
+	case cp2112ReportSMBusConfig:
+		copy(f.smbus[:], report[1:])
+	case cp2112ReportGPIOConfig:
+		copy(f.gpio[:], report[1:])
+	case cp2112ReportSetGPIO:
+		f.latch = f.latch&^report[2] | report[1]&report[2]
+	}
+	return len(report), nil
+}
+
+func (f *fakeCP2112) GetFeatureReport(report []byte) (int, error) {
+	switch report[0] {
+	case cp2112ReportSMBusConfig:
+		copy(report[1:], f.smbus[:])
+	case cp2112ReportGPIOConfig:
+		copy(report[1:], f.gpio[:])
+	case cp2112ReportGetGPIO:
+		report[1] = f.latch
+	case cp2112ReportVersion:
+		report[1], report[2] = 0x0C, 0x03
+	}
+	return len(report), nil
+}
+
+func newFakeCP2112Adapter(t *testing.T, opts ...CP2112Option) (*CP2112, *fakeCP2112, *memoryDevice) {
+	t.Helper()
+	bus := sim.NewBus()
+	dev := &memoryDevice{}
+	bus.Attach(testAddress, dev)
+	fake := newFakeCP2112(bus)
+	return NewCP2112(append([]CP2112Option{WithCP2112Transport(fake)}, opts...)...), fake, dev
+}
+
+func TestCP2112_WriteRead(t *testing.T) {
+	ctx := context.Background()
+	d, fake, dev := newFakeCP2112Adapter(t)
+	dev.data = []byte{0xDE, 0xAD, 0xBE, 0xEF}
+
+	fake.busyPolls = 2
+	require.NoError(t, d.WriteToAddr(ctx, testAddress, []byte{0x01, 0x02}))
+	r := make([]byte, 4)
+	require.NoError(t, d.ReadFromAddr(ctx, testAddress, r))
+	assert.Equal(t, dev.data, r)
+
+	r = make([]byte, 2)
+	require.NoError(t, sensors.WriteRead(ctx, d, testAddress, []byte{0x03}, r))
+	assert.Equal(t, []byte{0xDE, 0xAD}, r)
+
+	// writes over 16 bytes are not sent with a write read request
+	require.NoError(t, d.Tx(ctx, testAddress, sequence(20), r))
+	assert.Equal(t, []byte{0xDE, 0xAD}, r)
+	assert.Equal(t, [][]byte{{0x01, 0x02}, {0x03}, sequence(20)}, dev.written)
+	assert.Empty(t, fake.queue)
+}
+
+func TestCP2112_LongRead(t *testing.T) {
+	ctx := context.Background()
+	d, _, dev := newFakeCP2112Adapter(t)
+	dev.data = sequence(cp2112MaxRead)
+
+	r := make([]byte, cp2112MaxRead)
+	require.NoError(t, d.ReadFromAddr(ctx, testAddress, r))
+	assert.Equal(t, dev.data, r)
+
+	assert.Error(t, d.ReadFromAddr(ctx, testAddress, make([]byte, cp2112MaxRead+1)))
+	assert.Error(t, d.WriteToAddr(ctx, testAddress, sequence(cp2112MaxWrite+1)))
+}
+
+func TestCP2112_TransferErrors(t *testing.T) {
+	ctx := context.Background()
+	d, fake, _ := newFakeCP2112Adapter(t)
+
+	assert.ErrorIs(t, d.ReadFromAddr(ctx, 0x10, make([]byte, 2)), ErrI2CNACK)
+	assert.ErrorIs(t, d.WriteToAddr(ctx, 0x10, []byte{0x01}), ErrI2CNACK)
+
+	detail := byte(cp2112ErrorBusNotFree)
+	fake.failure = &detail
+	assert.ErrorIs(t, d.WriteToAddr(ctx, testAddress, []byte{0x01}), sensors.ErrBusBusy)
+
+	require.NoError(t, d.Release(ctx))
+	assert.Equal(t, 1, fake.cancels)
+}
+
+func TestCP2112_Configuration(t *testing.T) {
+	ctx := context.Background()
+	d, fake, _ := newFakeCP2112Adapter(t, WithCP2112Speed(400000))
+
+	require.NoError(t, d.Open(ctx))
+	defer d.Close()
+	cfg, err := d.SMBusConfig()
+	require.NoError(t, err)
+	assert.Equal(t, 400000, cfg.ClockSpeed)
+
+	cfg.ReadTimeout = 25_000_000
+	cfg.AutoSendRead = true
+	require.NoError(t, d.SetSMBusConfig(cfg))
+	assert.Equal(t, cfg, decodeSMBusConfig(fake.smbus[:]))
+
+	require.NoError(t, d.SetGPIOConfig(CP2112GPIOConfig{Direction: 0x03, PushPull: 0x01}))
+	gpio, err := d.GPIOConfig()
+	require.NoError(t, err)
+	assert.Equal(t, CP2112GPIOConfig{Direction: 0x03, PushPull: 0x01}, gpio)
+
+	require.NoError(t, d.WriteGPIO(0x01, 0x03))
+	require.NoError(t, d.WriteGPIO(0x02, 0x02))
+	value, err := d.ReadGPIO()
+	require.NoError(t, err)
+	assert.Equal(t, byte(0x03), value)
+
+	version, err := d.Version()
+	require.NoError(t, err)
+	assert.Equal(t, CP2112Version{PartNumber: 0x0C, Version: 0x03}, version)
+	assert.Equal(t, 1, fake.opens)
+}
+
+func TestCP2112_FeatureReportsUnsupported(t *testing.T) {
+	d := NewCP2112(WithCP2112Transport(plainTransport{}))
+	_, err := d.Version()
+	assert.ErrorIs(t, err, ErrFeatureReportsUnsupported)
+}
+
+// plainTransport opens devices without feature report support.
+type plainTransport struct{}
+
+func (plainTransport) Enumerate(vendorID uint16, productID uint16) []hid.DeviceInfo {
+	return []hid.DeviceInfo{{Path: "plain", VendorID: vendorID, ProductID: productID}}
+}
+
+func (plainTransport) Open(info hid.DeviceInfo) (HIDDevice, error) {
+	return plainDevice{}, nil
+}
+
+type plainDevice struct{}
+
+func (plainDevice) Write(report []byte) (int, error) { return len(report), nil }
+func (plainDevice) Read(report []byte) (int, error)  { return 0, nil }
+func (plainDevice) Close() error                     { return nil }
@@ -0,0 +1,152 @@
+//go:build linux
+
+package adapter
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+	"unsafe"
+
+	"github.com/karalabe/hid"
+)
+
+// hidraw ioctl requests: _IOC(_IOC_WRITE|_IOC_READ, 'H', nr, len)
+const (
+	hidiocSetFeature = 0x06
+	hidiocGetFeature = 0x07
+)
+
+const hidrawReadTimeout = time.Second
+
+var defaultCP2112Transport HIDTransport = hidrawTransport{}
+
+// hidrawTransport talks to HID devices through the Linux hidraw nodes, which
+// unlike karalabe/hid give access to feature reports.
+type hidrawTransport struct{}
+
+func (hidrawTransport) Enumerate(vendorID uint16, productID uint16) []hid.DeviceInfo {
+	nodes, err := filepath.Glob("/sys/class/hidraw/hidraw*")
+	if err != nil {
+		return nil
+	}
+	sort.Strings(nodes)
+	var res []hid.DeviceInfo
+	for _, node := range nodes {
+		info, ok := readHIDRawUevent(filepath.Join(node, "device", "uevent"))
+		if !ok || info.VendorID != vendorID || info.ProductID != productID {
+			continue
+		}
+		info.Path = filepath.Join("/dev", filepath.Base(node))
+		res = append(res, info)
+	}
+	return res
+}
+
+// readHIDRawUevent parses the HID_ID, HID_NAME and HID_UNIQ entries of the
+// uevent file of a hidraw node.
+func readHIDRawUevent(path string) (hid.DeviceInfo, bool) {
+	f, err := os.Open(path)
+	if err != nil {
+		return hid.DeviceInfo{}, false
+	}
+	defer f.Close()
+	var info hid.DeviceInfo
+	found := false
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		key, value, ok := strings.Cut(scanner.Text(), "=")
+		if !ok {
+			continue
+		}
+		switch key {
+		case "HID_ID":
+			// bus:vendor:product, e.g. 0003:000010C4:0000EA90
+			parts := strings.Split(value, ":")
+			if len(parts) != 3 {
+				return hid.DeviceInfo{}, false
+			}
+			vendor, err := strconv.ParseUint(parts[1], 16, 32)
+			if err != nil {
+				return hid.DeviceInfo{}, false
+			}
+			product, err := strconv.ParseUint(parts[2], 16, 32)
+			if err != nil {
+				return hid.DeviceInfo{}, false
+			}
+			info.VendorID = uint16(vendor)
+			info.ProductID = uint16(product)
+			found = true
+		case "HID_NAME":
+			info.Product = value
+		case "HID_UNIQ":
+			info.Serial = value
+		}
+	}
+	return info, found
+}
+
+func (hidrawTransport) Open(info hid.DeviceInfo) (HIDDevice, error) {
+	f, err := os.OpenFile(info.Path, os.O_RDWR, 0)
+	if err != nil {
+		return nil, err
+	}
+	return &hidrawDevice{file: f}, nil
+}
+
+type hidrawDevice struct {
+	file *os.File
+}
+
+func (d *hidrawDevice) Write(report []byte) (int, error) {
+	return d.file.Write(report)
+}
+
+func (d *hidrawDevice) Read(report []byte) (int, error) {
+	err := d.file.SetReadDeadline(time.Now().Add(hidrawReadTimeout))
+	if err != nil && err != os.ErrNoDeadline {
+		return 0, err
+	}
+	return d.file.Read(report)
+}
+
+func (d *hidrawDevice) Close() error {
+	return d.file.Close()
+}
+
+func (d *hidrawDevice) SendFeatureReport(report []byte) (int, error) {
+	return d.featureIoctl(hidiocSetFeature, report)
+}
+
+func (d *hidrawDevice) GetFeatureReport(report []byte) (int, error) {
+	return d.featureIoctl(hidiocGetFeature, report)
+}
+
+func (d *hidrawDevice) featureIoctl(nr uintptr, report []byte) (int, error) {
+	if len(report) == 0 {
+		return 0, fmt.Errorf("empty feature report")
+	}
+	conn, err := d.file.SyscallConn()
+	if err != nil {
+		return 0, err
+	}
+	request := uintptr(3)<<30 | uintptr(len(report))<<16 | uintptr('H')<<8 | nr
+	var n uintptr
+	var errno syscall.Errno
+	err = conn.Control(func(fd uintptr) {
+		n, _, errno = syscall.Syscall(syscall.SYS_IOCTL, fd, request, uintptr(unsafe.Pointer(&report[0])))
+	})
+	if err != nil {
+		return 0, err
+	}
+	if errno != 0 {
+		return 0, errno
+	}
+	return int(n), nil
+}
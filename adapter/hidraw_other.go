@@ -0,0 +1,7 @@
+//go:build !linux
+
+package adapter
+
+// karalabe/hid has no feature reports, so the CP2112 configuration and GPIO
+// are only available with a custom transport outside of Linux.
+var defaultCP2112Transport HIDTransport = usbTransport{}
@@ -0,0 +1,753 @@
+package adapter
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/karalabe/hid"
+	"github.com/mklimuk/sensors"
+	"github.com/mklimuk/sensors/cmd/sensors/console"
+	"github.com/mklimuk/sensors/snsctx"
+)
+
+var _ sensors.SPIBus = &MCP2210{}
+
+// Microchip MCP2210 default USB ids
+const (
+	MCP2210VendorID  = 0x04D8
+	MCP2210ProductID = 0x00DE
+)
+
+// MCP2210 commands
+const (
+	mcp2210CmdCancel           = 0x11
+	mcp2210CmdGetChipSettings  = 0x20
+	mcp2210CmdSetChipSettings  = 0x21
+	mcp2210CmdSetGPIOValue     = 0x30
+	mcp2210CmdGetGPIOValue     = 0x31
+	mcp2210CmdSetGPIODirection = 0x32
+	mcp2210CmdGetGPIODirection = 0x33
+	mcp2210CmdSetSPISettings   = 0x40
+	mcp2210CmdGetSPISettings   = 0x41
+	mcp2210CmdTransfer         = 0x42
+)
+
+// Command status codes (byte 1 of the responses)
+const (
+	mcp2210StatusOK                 = 0x00
+	mcp2210StatusBusNotAvailable    = 0xF7
+	mcp2210StatusTransferInProgress = 0xF8
+	mcp2210StatusAccessDenied       = 0xFB
+)
+
+// SPI engine status (byte 3 of the Transfer SPI Data response)
+const (
+	mcp2210EngineFinished = 0x10
+	mcp2210EngineStarted  = 0x20
+	mcp2210EngineData     = 0x30
+)
+
+// Layout of the chip settings and SPI transfer settings, shared by the get
+// responses and the set requests.
+const (
+	mcp2210SettingsGP0          = 4
+	mcp2210SettingsBitRate      = 4
+	mcp2210SettingsIdleCS       = 8
+	mcp2210SettingsActiveCS     = 10
+	mcp2210SettingsCSToData     = 12
+	mcp2210SettingsDataToCS     = 14
+	mcp2210SettingsByteDelay    = 16
+	mcp2210SettingsTransferSize = 18
+	mcp2210SettingsMode         = 20
+	mcp2210ChipSettingsSize     = 19
+)
+
+const (
+	MCP2210Pins = 9
+	// mcp2210MaxChunk is the SPI data carried by a Transfer SPI Data report
+	mcp2210MaxChunk = 60
+	// mcp2210MaxTransfer is the largest transaction the chip accepts
+	mcp2210MaxTransfer = 0xFFFF
+	// mcp2210DelayUnit is the unit of the chip select and byte delays
+	mcp2210DelayUnit = 100 * time.Microsecond
+	// mcp2210TransferTimeout bounds a transfer without progress
+	mcp2210TransferTimeout = time.Second
+)
+
+var ErrSPIBusNotAvailable = fmt.Errorf("spi bus is used by another master: %w", sensors.ErrBusBusy)
+var ErrSPITransferInProgress = fmt.Errorf("spi transfer in progress: %w", sensors.ErrBusBusy)
+var ErrAccessDenied = errors.New("access denied")
+
+// SPIMode is the SPI clock polarity (bit 1) and phase (bit 0).
+type SPIMode byte
+
+const (
+	SPIMode0 SPIMode = iota
+	SPIMode1
+	SPIMode2
+	SPIMode3
+)
+
+// MCP2210Pin identifies one of the GP0..GP8 pins.
+type MCP2210Pin int
+
+func (p MCP2210Pin) String() string {
+	return fmt.Sprintf("GP%d", int(p))
+}
+
+func (p MCP2210Pin) valid() bool {
+	return p >= 0 && p < MCP2210Pins
+}
+
+// MCP2210PinDesignation is the function of a GP pin.
+type MCP2210PinDesignation byte
+
+const (
+	MCP2210PinGPIO       MCP2210PinDesignation = 0x00
+	MCP2210PinChipSelect MCP2210PinDesignation = 0x01
+	// MCP2210PinDedicated selects the pin dedicated function (GP6 external
+	// interrupt, GP7 SPI bus release acknowledge, GP8 SPI bus release
+	// request and the LED functions of the other pins)
+	MCP2210PinDedicated MCP2210PinDesignation = 0x02
+)
+
+func (d MCP2210PinDesignation) String() string {
+	switch d {
+	case MCP2210PinGPIO:
+		return "gpio"
+	case MCP2210PinChipSelect:
+		return "chip select"
+	case MCP2210PinDedicated:
+		return "dedicated"
+	}
+	return fmt.Sprintf("unknown (%#x)", byte(d))
+}
+
+func (d MCP2210PinDesignation) MarshalYAML() (interface{}, error) {
+	return d.String(), nil
+}
+
+// MCP2210SPISettings are the SPI transfer settings of the bridge. Chip
+// select masks have one bit per GP pin; only pins designated as chip select
+// follow them.
+type MCP2210SPISettings struct {
+	// BitRate is the SPI clock in Hz
+	BitRate int     `yaml:"bit_rate"`
+	Mode    SPIMode `yaml:"mode"`
+	// IdleChipSelect and ActiveChipSelect are the chip select levels between
+	// and during transfers
+	IdleChipSelect   uint16 `yaml:"idle_chip_select"`
+	ActiveChipSelect uint16 `yaml:"active_chip_select"`
+	// ChipSelectToData, DataToChipSelect and ByteDelay are rounded down to
+	// 100µs steps
+	ChipSelectToData time.Duration `yaml:"chip_select_to_data"`
+	DataToChipSelect time.Duration `yaml:"data_to_chip_select"`
+	ByteDelay        time.Duration `yaml:"byte_delay"`
+	// TransferSize is the number of bytes of a transaction, set by Transfer
+	TransferSize int `yaml:"transfer_size"`
+}
+
+func decodeSPISettings(src []byte) MCP2210SPISettings {
+	return MCP2210SPISettings{
+		BitRate:          int(binary.LittleEndian.Uint32(src[mcp2210SettingsBitRate:])),
+		IdleChipSelect:   binary.LittleEndian.Uint16(src[mcp2210SettingsIdleCS:]),
+		ActiveChipSelect: binary.LittleEndian.Uint16(src[mcp2210SettingsActiveCS:]),
+		ChipSelectToData: time.Duration(binary.LittleEndian.Uint16(src[mcp2210SettingsCSToData:])) * mcp2210DelayUnit,
+		DataToChipSelect: time.Duration(binary.LittleEndian.Uint16(src[mcp2210SettingsDataToCS:])) * mcp2210DelayUnit,
+		ByteDelay:        time.Duration(binary.LittleEndian.Uint16(src[mcp2210SettingsByteDelay:])) * mcp2210DelayUnit,
+		TransferSize:     int(binary.LittleEndian.Uint16(src[mcp2210SettingsTransferSize:])),
+		Mode:             SPIMode(src[mcp2210SettingsMode] & 0x03),
+	}
+}
+
+func (s MCP2210SPISettings) encode(dst []byte) {
+	binary.LittleEndian.PutUint32(dst[mcp2210SettingsBitRate:], uint32(s.BitRate))
+	binary.LittleEndian.PutUint16(dst[mcp2210SettingsIdleCS:], s.IdleChipSelect)
+	binary.LittleEndian.PutUint16(dst[mcp2210SettingsActiveCS:], s.ActiveChipSelect)
+	binary.LittleEndian.PutUint16(dst[mcp2210SettingsCSToData:], uint16(s.ChipSelectToData/mcp2210DelayUnit))
+	binary.LittleEndian.PutUint16(dst[mcp2210SettingsDataToCS:], uint16(s.DataToChipSelect/mcp2210DelayUnit))
+	binary.LittleEndian.PutUint16(dst[mcp2210SettingsByteDelay:], uint16(s.ByteDelay/mcp2210DelayUnit))
+	binary.LittleEndian.PutUint16(dst[mcp2210SettingsTransferSize:], uint16(s.TransferSize))
+	dst[mcp2210SettingsMode] = byte(s.Mode)
+}
+
+type MCP2210Options struct {
+	VendorID    uint16
+	ProductID   uint16
+	DeviceIndex int
+	Serial      string
+	Path        string
+	// ChipSelect is the pin selecting the device addressed by Transfer; it
+	// is designated as chip select when connecting. Transfers use the chip
+	// settings unchanged when it is negative.
+	ChipSelect MCP2210Pin
+	// BitRate and Mode are applied to every transfer when BitRate is set
+	BitRate int
+	Mode    SPIMode
+	// Transport enumerates and opens the bridges, USB HID when nil
+	Transport HIDTransport
+}
+
+type MCP2210Option func(*MCP2210Options)
+
+// WithMCP2210Serial selects the bridge with the given USB serial number.
+func WithMCP2210Serial(serial string) MCP2210Option {
+	return func(o *MCP2210Options) {
+		o.Serial = serial
+	}
+}
+
+// WithMCP2210Path selects the bridge at the given HID path.
+func WithMCP2210Path(path string) MCP2210Option {
+	return func(o *MCP2210Options) {
+		o.Path = path
+	}
+}
+
+// WithMCP2210Transport replaces the HID transport, typically in tests.
+func WithMCP2210Transport(transport HIDTransport) MCP2210Option {
+	return func(o *MCP2210Options) {
+		o.Transport = transport
+	}
+}
+
+// WithChipSelect selects the pin driving the chip select of the device.
+func WithChipSelect(pin MCP2210Pin) MCP2210Option {
+	return func(o *MCP2210Options) {
+		o.ChipSelect = pin
+	}
+}
+
+// WithSPIMode sets the SPI mode of the transfers.
+func WithSPIMode(mode SPIMode) MCP2210Option {
+	return func(o *MCP2210Options) {
+		o.Mode = mode
+	}
+}
+
+// WithSPIBitRate sets the SPI clock of the transfers in Hz.
+func WithSPIBitRate(hz int) MCP2210Option {
+	return func(o *MCP2210Options) {
+		o.BitRate = hz
+	}
+}
+
+func (o MCP2210Options) transport() HIDTransport {
+	if o.Transport == nil {
+		return usbTransport{}
+	}
+	return o.Transport
+}
+
+func (o MCP2210Options) selectDevice() (hid.DeviceInfo, error) {
+	dev, matching, ok := selectHIDDevice(o.transport().Enumerate(o.VendorID, o.ProductID), o.Path, o.Serial, o.DeviceIndex)
+	if !ok {
+		return hid.DeviceInfo{}, fmt.Errorf("%w: mcp2210 vendor: %#x product: %#x (%d matching)", ErrDeviceNotFound, o.VendorID, o.ProductID, matching)
+	}
+	return dev, nil
+}
+
+// MCP2210 is a Microchip MCP2210 HID USB to SPI bridge. Like MCP2221 it
+// opens the HID device for every call unless a sticky session is started
+// with Open.
+type MCP2210 struct {
+	mx       sync.Mutex
+	options  MCP2210Options
+	device   HIDDevice
+	keepOpen bool
+	// configured is set once the chip select pin designation was checked,
+	// applied holds the last SPI settings written; both are forgotten when
+	// the device is lost
+	configured bool
+	applied    *MCP2210SPISettings
+	request    []byte
+	response   []byte
+}
+
+func NewMCP2210(opts ...MCP2210Option) *MCP2210 {
+	options := MCP2210Options{
+		VendorID:   MCP2210VendorID,
+		ProductID:  MCP2210ProductID,
+		ChipSelect: -1,
+	}
+	for _, opt := range opts {
+		opt(&options)
+	}
+	return &MCP2210{
+		options:  options,
+		request:  make([]byte, 64),
+		response: make([]byte, 64),
+	}
+}
+
+// Open starts a sticky session reusing one HID handle until Close.
+func (d *MCP2210) Open(ctx context.Context) error {
+	d.mx.Lock()
+	defer d.mx.Unlock()
+	d.keepOpen = true
+	return d.connect(ctx)
+}
+
+// Close ends the sticky session and releases the HID handle.
+func (d *MCP2210) Close() error {
+	d.mx.Lock()
+	defer d.mx.Unlock()
+	d.keepOpen = false
+	return d.closeDevice()
+}
+
+func (d *MCP2210) connect(ctx context.Context) error {
+	if d.device != nil {
+		return nil
+	}
+	info, err := d.options.selectDevice()
+	if err != nil {
+		return err
+	}
+	device, err := d.options.transport().Open(info)
+	if err != nil {
+		return fmt.Errorf("could not open hid device %s: %w", info.Path, err)
+	}
+	d.device = device
+	if d.configured || d.options.ChipSelect < 0 {
+		return nil
+	}
+	if !d.options.ChipSelect.valid() {
+		_ = d.closeDevice()
+		return fmt.Errorf("invalid chip select pin %d: %w", d.options.ChipSelect, sensors.ErrInvalidArgument)
+	}
+	err = d.doSetPinDesignation(ctx, d.options.ChipSelect, MCP2210PinChipSelect)
+	if err != nil {
+		_ = d.invalidate()
+		return fmt.Errorf("could not designate %s as chip select: %w", d.options.ChipSelect, err)
+	}
+	d.configured = true
+	return nil
+}
+
+// session connects for the duration of a public call and returns the
+// function ending it.
+func (d *MCP2210) session(ctx context.Context) (func(), error) {
+	err := d.connect(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("could not connect to mcp2210: %w", err)
+	}
+	return func() {
+		if d.keepOpen {
+			return
+		}
+		err := d.closeDevice()
+		if err != nil {
+			slog.Error("could not disconnect from mcp2210", "err", err)
+		}
+	}, nil
+}
+
+func (d *MCP2210) closeDevice() error {
+	if d.device == nil {
+		return nil
+	}
+	err := d.device.Close()
+	d.device = nil
+	if err != nil {
+		return fmt.Errorf("could not close hid device: %w", err)
+	}
+	return nil
+}
+
+// invalidate drops the handle after a communication error. The chip may
+// have been reset or replaced, so the cached settings are forgotten too.
+func (d *MCP2210) invalidate() error {
+	d.configured = false
+	d.applied = nil
+	return d.closeDevice()
+}
+
+// Transfer clocks out w and reads the same number of bytes into r, which may
+// be nil, with the chip select of the options asserted.
+func (d *MCP2210) Transfer(ctx context.Context, w, r []byte) error {
+	if len(w) == 0 || len(w) > mcp2210MaxTransfer {
+		return fmt.Errorf("spi transfer of %d bytes: mcp2210 transfers 1 to %d bytes: %w", len(w), mcp2210MaxTransfer, sensors.ErrInvalidArgument)
+	}
+	if r != nil && len(r) != len(w) {
+		return fmt.Errorf("spi transfer buffers length mismatch: %d != %d: %w", len(w), len(r), sensors.ErrInvalidArgument)
+	}
+	d.mx.Lock()
+	defer d.mx.Unlock()
+	end, err := d.session(ctx)
+	if err != nil {
+		return err
+	}
+	defer end()
+	err = d.prepareTransfer(ctx, len(w))
+	if err != nil {
+		return fmt.Errorf("could not apply spi settings: %w", err)
+	}
+	return d.doTransfer(ctx, w, r)
+}
+
+// prepareTransfer writes the SPI settings of the options for a transaction
+// of size bytes unless the chip already uses them. Callers must hold d.mx
+// and be connected.
+func (d *MCP2210) prepareTransfer(ctx context.Context, size int) error {
+	settings := d.applied
+	if settings == nil {
+		current, err := d.doGetSPISettings(ctx)
+		if err != nil {
+			return err
+		}
+		settings = &current
+	}
+	next := *settings
+	next.TransferSize = size
+	if d.options.BitRate != 0 {
+		next.BitRate = d.options.BitRate
+		next.Mode = d.options.Mode
+	}
+	if d.options.ChipSelect >= 0 {
+		mask := uint16(1) << d.options.ChipSelect
+		next.IdleChipSelect = 1<<MCP2210Pins - 1
+		next.ActiveChipSelect = next.IdleChipSelect &^ mask
+	}
+	if d.applied != nil && *d.applied == next {
+		return nil
+	}
+	err := d.doSetSPISettings(ctx, next)
+	if err != nil {
+		return err
+	}
+	d.applied = &next
+	return nil
+}
+
+// doTransfer sends w in Transfer SPI Data reports and collects the bytes
+// received, which the chip returns in the responses of the following
+// reports. Callers must hold d.mx and be connected.
+func (d *MCP2210) doTransfer(ctx context.Context, w, r []byte) error {
+	sent, received := 0, 0
+	deadline := time.Now().Add(mcp2210TransferTimeout)
+	for {
+		chunk := w[sent:min(sent+mcp2210MaxChunk, len(w))]
+		d.resetBuffers()
+		d.request[0] = mcp2210CmdTransfer
+		d.request[1] = byte(len(chunk))
+		copy(d.request[4:], chunk)
+		err := d.exchange(ctx)
+		if err != nil {
+			return fmt.Errorf("spi transfer command failed: %w", err)
+		}
+		switch d.response[1] {
+		case mcp2210StatusOK:
+			sent += len(chunk)
+		case mcp2210StatusTransferInProgress:
+			// the chunk was not accepted, the previous one is still being
+			// clocked out
+		case mcp2210StatusBusNotAvailable:
+			return ErrSPIBusNotAvailable
+		default:
+			return fmt.Errorf("%w: status %#x", ErrCommandFailed, d.response[1])
+		}
+		n := int(d.response[2])
+		if n > mcp2210MaxChunk || received+n > len(w) {
+			return fmt.Errorf("invalid received size byte; expected at most %d, got %d", len(w)-received, n)
+		}
+		if r != nil {
+			copy(r[received:], d.response[4:4+n])
+		}
+		received += n
+		if n > 0 || d.response[1] == mcp2210StatusOK {
+			deadline = time.Now().Add(mcp2210TransferTimeout)
+		}
+		if sent == len(w) && d.response[3] == mcp2210EngineFinished {
+			if received != len(w) {
+				return fmt.Errorf("spi transfer finished after receiving %d of %d bytes", received, len(w))
+			}
+			return nil
+		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf("spi transfer stalled after sending %d and receiving %d of %d bytes: %w", sent, received, len(w), ErrSPITransferInProgress)
+		}
+		if d.response[1] == mcp2210StatusTransferInProgress || (sent == len(w) && n == 0) {
+			err = pause(ctx, pollDelay)
+			if err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// Cancel aborts the current SPI transfer and releases the bus.
+func (d *MCP2210) Cancel(ctx context.Context) error {
+	d.mx.Lock()
+	defer d.mx.Unlock()
+	end, err := d.session(ctx)
+	if err != nil {
+		return err
+	}
+	defer end()
+	d.resetBuffers()
+	d.request[0] = mcp2210CmdCancel
+	err = d.exchange(ctx)
+	if err != nil {
+		return fmt.Errorf("cancel spi transfer command failed: %w", err)
+	}
+	return nil
+}
+
+// SPISettings returns the current SPI transfer settings.
+func (d *MCP2210) SPISettings(ctx context.Context) (MCP2210SPISettings, error) {
+	d.mx.Lock()
+	defer d.mx.Unlock()
+	end, err := d.session(ctx)
+	if err != nil {
+		return MCP2210SPISettings{}, err
+	}
+	defer end()
+	return d.doGetSPISettings(ctx)
+}
+
+// SetSPISettings changes the SPI transfer settings. Transfer overrides the
+// transfer size and, when set in the options, the bit rate, mode and chip
+// select masks.
+func (d *MCP2210) SetSPISettings(ctx context.Context, settings MCP2210SPISettings) error {
+	if settings.BitRate <= 0 {
+		return fmt.Errorf("invalid bit rate %d: %w", settings.BitRate, sensors.ErrInvalidArgument)
+	}
+	d.mx.Lock()
+	defer d.mx.Unlock()
+	end, err := d.session(ctx)
+	if err != nil {
+		return err
+	}
+	defer end()
+	err = d.doSetSPISettings(ctx, settings)
+	if err != nil {
+		return err
+	}
+	d.applied = &settings
+	return nil
+}
+
+// SetTransferMode changes the SPI mode and clock in Hz of the following
+// transfers, like WithSPIMode and WithSPIBitRate do.
+func (d *MCP2210) SetTransferMode(mode SPIMode, hz int) error {
+	if mode > SPIMode3 {
+		return fmt.Errorf("invalid spi mode %d: %w", mode, sensors.ErrInvalidArgument)
+	}
+	if hz <= 0 {
+		return fmt.Errorf("invalid bit rate %d: %w", hz, sensors.ErrInvalidArgument)
+	}
+	d.mx.Lock()
+	defer d.mx.Unlock()
+	d.options.Mode = mode
+	d.options.BitRate = hz
+	return nil
+}
+
+func (d *MCP2210) doGetSPISettings(ctx context.Context) (MCP2210SPISettings, error) {
+	d.resetBuffers()
+	d.request[0] = mcp2210CmdGetSPISettings
+	err := d.command(ctx)
+	if err != nil {
+		return MCP2210SPISettings{}, fmt.Errorf("get spi settings command failed: %w", err)
+	}
+	return decodeSPISettings(d.response), nil
+}
+
+func (d *MCP2210) doSetSPISettings(ctx context.Context, settings MCP2210SPISettings) error {
+	d.resetBuffers()
+	d.request[0] = mcp2210CmdSetSPISettings
+	settings.encode(d.request)
+	err := d.command(ctx)
+	if err != nil {
+		return fmt.Errorf("set spi settings command failed: %w", err)
+	}
+	return nil
+}
+
+// PinDesignations returns the current function of the GP0..GP8 pins.
+func (d *MCP2210) PinDesignations(ctx context.Context) ([MCP2210Pins]MCP2210PinDesignation, error) {
+	var res [MCP2210Pins]MCP2210PinDesignation
+	d.mx.Lock()
+	defer d.mx.Unlock()
+	end, err := d.session(ctx)
+	if err != nil {
+		return res, err
+	}
+	defer end()
+	err = d.doGetChipSettings(ctx)
+	if err != nil {
+		return res, err
+	}
+	for i := range res {
+		res[i] = MCP2210PinDesignation(d.response[mcp2210SettingsGP0+i])
+	}
+	return res, nil
+}
+
+// SetPinDesignation changes the function of a pin. The change is volatile
+// and lost when the bridge is reset.
+func (d *MCP2210) SetPinDesignation(ctx context.Context, pin MCP2210Pin, designation MCP2210PinDesignation) error {
+	if !pin.valid() {
+		return fmt.Errorf("invalid pin %d: %w", pin, sensors.ErrInvalidArgument)
+	}
+	d.mx.Lock()
+	defer d.mx.Unlock()
+	end, err := d.session(ctx)
+	if err != nil {
+		return err
+	}
+	defer end()
+	return d.doSetPinDesignation(ctx, pin, designation)
+}
+
+// doSetPinDesignation updates the designation of pin in the current chip
+// settings unless it is already set. Callers must hold d.mx and be
+// connected.
+func (d *MCP2210) doSetPinDesignation(ctx context.Context, pin MCP2210Pin, designation MCP2210PinDesignation) error {
+	err := d.doGetChipSettings(ctx)
+	if err != nil {
+		return err
+	}
+	if d.response[mcp2210SettingsGP0+int(pin)] == byte(designation) {
+		return nil
+	}
+	var settings [mcp2210ChipSettingsSize]byte
+	copy(settings[:], d.response)
+	settings[mcp2210SettingsGP0+int(pin)] = byte(designation)
+	d.resetBuffers()
+	copy(d.request, settings[:])
+	d.request[0] = mcp2210CmdSetChipSettings
+	d.request[1], d.request[2], d.request[3] = 0, 0, 0
+	err = d.command(ctx)
+	if err != nil {
+		return fmt.Errorf("set chip settings command failed: %w", err)
+	}
+	return nil
+}
+
+func (d *MCP2210) doGetChipSettings(ctx context.Context) error {
+	d.resetBuffers()
+	d.request[0] = mcp2210CmdGetChipSettings
+	err := d.command(ctx)
+	if err != nil {
+		return fmt.Errorf("get chip settings command failed: %w", err)
+	}
+	return nil
+}
+
+// ReadGPIO returns the levels of GP0..GP8, one bit per pin.
+func (d *MCP2210) ReadGPIO(ctx context.Context) (uint16, error) {
+	return d.getPins(ctx, mcp2210CmdGetGPIOValue)
+}
+
+// WriteGPIO drives the GP pins designated as GPIO outputs to the levels of
+// value, one bit per pin.
+func (d *MCP2210) WriteGPIO(ctx context.Context, value uint16) error {
+	return d.setPins(ctx, mcp2210CmdSetGPIOValue, value)
+}
+
+// GPIODirection returns the direction of GP0..GP8, bits set for inputs.
+func (d *MCP2210) GPIODirection(ctx context.Context) (uint16, error) {
+	return d.getPins(ctx, mcp2210CmdGetGPIODirection)
+}
+
+// SetGPIODirection changes the direction of the GP pins designated as GPIO,
+// bits set for inputs.
+func (d *MCP2210) SetGPIODirection(ctx context.Context, inputs uint16) error {
+	return d.setPins(ctx, mcp2210CmdSetGPIODirection, inputs)
+}
+
+func (d *MCP2210) getPins(ctx context.Context, cmd byte) (uint16, error) {
+	d.mx.Lock()
+	defer d.mx.Unlock()
+	end, err := d.session(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer end()
+	d.resetBuffers()
+	d.request[0] = cmd
+	err = d.command(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("gpio command %#x failed: %w", cmd, err)
+	}
+	return binary.LittleEndian.Uint16(d.response[4:6]), nil
+}
+
+func (d *MCP2210) setPins(ctx context.Context, cmd byte, value uint16) error {
+	d.mx.Lock()
+	defer d.mx.Unlock()
+	end, err := d.session(ctx)
+	if err != nil {
+		return err
+	}
+	defer end()
+	d.resetBuffers()
+	d.request[0] = cmd
+	binary.LittleEndian.PutUint16(d.request[4:6], value&(1<<MCP2210Pins-1))
+	err = d.command(ctx)
+	if err != nil {
+		return fmt.Errorf("gpio command %#x failed: %w", cmd, err)
+	}
+	return nil
+}
+
+// command exchanges the request and checks the response status. Callers
+// must hold d.mx and be connected.
+func (d *MCP2210) command(ctx context.Context) error {
+	err := d.exchange(ctx)
+	if err != nil {
+		return err
+	}
+	switch d.response[1] {
+	case mcp2210StatusOK:
+		return nil
+	case mcp2210StatusTransferInProgress:
+		return ErrSPITransferInProgress
+	case mcp2210StatusAccessDenied:
+		return ErrAccessDenied
+	}
+	return fmt.Errorf("%w: status %#x", ErrCommandFailed, d.response[1])
+}
+
+func (d *MCP2210) resetBuffers() {
+	resetBuffer(d.request)
+	resetBuffer(d.response)
+}
+
+// exchange sends the request and reads the response, which echoes the
+// command code. Callers must hold d.mx and be connected.
+func (d *MCP2210) exchange(ctx context.Context) error {
+	verbose := snsctx.IsVerbose(ctx)
+	if verbose {
+		console.Printf("sending message to mcp2210:\n%s\n", hex.Dump(d.request))
+	}
+	_, err := d.device.Write(d.request)
+	if err != nil {
+		_ = d.invalidate()
+		return fmt.Errorf("could not write request: %w: %w", sensors.ErrDisconnected, err)
+	}
+	n, err := d.device.Read(d.response)
+	if err != nil {
+		_ = d.invalidate()
+		return fmt.Errorf("could not read response: %w: %w", sensors.ErrDisconnected, err)
+	}
+	if verbose {
+		console.Printf("read message from mcp2210:\n%s\n", hex.Dump(d.response))
+	}
+	if n < 4 {
+		_ = d.invalidate()
+		return fmt.Errorf("short read: %d bytes", n)
+	}
+	if d.response[0] != d.request[0] {
+		return fmt.Errorf("%w: response to %#x for command %#x", ErrCommandFailed, d.response[0], d.request[0])
+	}
+	return nil
+}
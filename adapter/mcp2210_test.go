@@ -0,0 +1,207 @@
+package adapter
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"testing"
+
+	"github.com/karalabe/hid"
+	"github.com/mklimuk/sensors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeMCP2210 answers the MCP2210 commands, passing each SPI transaction to
+// device once all of its bytes were sent.
+type fakeMCP2210 struct {
+	device      func(w []byte) []byte
+	opens       int
+	chipUpdates int
+	// busy is the number of transfer reports answered with a transfer in
+	// progress, busOwned makes them fail with the bus not available
+	busy      int
+	busOwned  bool
+	chip      [mcp2210ChipSettingsSize]byte
+	spi       [64]byte
+	gpio      uint16
+	direction uint16
+	tx        []byte
+	rx        []byte
+	response  []byte
+}
+
+func newFakeMCP2210(device func(w []byte) []byte) *fakeMCP2210 {
+	f := &fakeMCP2210{device: device, direction: 1<<MCP2210Pins - 1}
+	MCP2210SPISettings{BitRate: 1_000_000, IdleChipSelect: 0x1FF, ActiveChipSelect: 0x1FF, TransferSize: 4}.encode(f.spi[:])
+	return f
+}
+
+func (f *fakeMCP2210) Enumerate(vendorID uint16, productID uint16) []hid.DeviceInfo {
+	if vendorID != MCP2210VendorID || productID != MCP2210ProductID {
+		return nil
+	}
+	return []hid.DeviceInfo{{Path: "fake-mcp2210", VendorID: vendorID, ProductID: productID}}
+}
+
+func (f *fakeMCP2210) Open(info hid.DeviceInfo) (HIDDevice, error) {
+	f.opens++
+	return f, nil
+}
+
+func (f *fakeMCP2210) Close() error {
+	return nil
+}
+
+func (f *fakeMCP2210) Write(report []byte) (int, error) {
+	res := make([]byte, 64)
+	res[0] = report[0]
+	switch report[0] {
+	case mcp2210CmdGetChipSettings:
+		copy(res[4:], f.chip[4:])
+	case mcp2210CmdSetChipSettings:
+		copy(f.chip[4:], report[4:mcp2210ChipSettingsSize])
+		f.chipUpdates++
+	case mcp2210CmdGetSPISettings:
+		copy(res[4:], f.spi[4:])
+	case mcp2210CmdSetSPISettings:
+		copy(f.spi[4:], report[4:])
+	case mcp2210CmdSetGPIOValue:
+		f.gpio = binary.LittleEndian.Uint16(report[4:])
+	case mcp2210CmdGetGPIOValue:
+		binary.LittleEndian.PutUint16(res[4:], f.gpio)
+	case mcp2210CmdSetGPIODirection:
+		f.direction = binary.LittleEndian.Uint16(report[4:])
+	case mcp2210CmdGetGPIODirection:
+		binary.LittleEndian.PutUint16(res[4:], f.direction)
+	case mcp2210CmdTransfer:
+		f.transfer(report, res)
+	}
+	f.response = res
+	return len(report), nil
+}
+
+func (f *fakeMCP2210) transfer(report []byte, res []byte) {
+	if f.busOwned {
+		res[1] = mcp2210StatusBusNotAvailable
+		return
+	}
+	if f.busy > 0 {
+		f.busy--
+		res[1] = mcp2210StatusTransferInProgress
+		return
+	}
+	f.tx = append(f.tx, report[4:4+report[1]]...)
+	if len(f.tx) == int(decodeSPISettings(f.spi[:]).TransferSize) {
+		f.rx = append(f.rx, f.device(f.tx)...)
+		f.tx = nil
+	}
+	n := min(len(f.rx), mcp2210MaxChunk)
+	res[2] = byte(n)
+	copy(res[4:], f.rx[:n])
+	f.rx = f.rx[n:]
+	switch {
+	case n > 0 && len(f.rx) == 0 && len(f.tx) == 0:
+		res[3] = mcp2210EngineFinished
+	case n > 0:
+		res[3] = mcp2210EngineData
+	default:
+		res[3] = mcp2210EngineStarted
+	}
+}
+
+func (f *fakeMCP2210) Read(report []byte) (int, error) {
+	if f.response == nil {
+		return 0, errors.New("fake mcp2210: no response")
+	}
+	n := copy(report, f.response)
+	f.response = nil
+	return n, nil
+}
+
+// increment answers every byte with its value plus one.
+func increment(w []byte) []byte {
+	res := bytes.Clone(w)
+	for i := range res {
+		res[i]++
+	}
+	return res
+}
+
+func TestMCP2210_Transfer(t *testing.T) {
+	ctx := context.Background()
+	fake := newFakeMCP2210(increment)
+	d := NewMCP2210(WithMCP2210Transport(fake), WithChipSelect(2), WithSPIMode(SPIMode3), WithSPIBitRate(4_000_000))
+	require.NoError(t, d.Open(ctx))
+	defer d.Close()
+
+	w := sequence(150)
+	r := make([]byte, len(w))
+	fake.busy = 2
+	require.NoError(t, d.Transfer(ctx, w, r))
+	assert.Equal(t, increment(w), r)
+
+	settings := decodeSPISettings(fake.spi[:])
+	assert.Equal(t, MCP2210SPISettings{
+		BitRate:          4_000_000,
+		Mode:             SPIMode3,
+		IdleChipSelect:   0x1FF,
+		ActiveChipSelect: 0x1FB,
+		TransferSize:     150,
+	}, settings)
+	assert.Equal(t, byte(MCP2210PinChipSelect), fake.chip[mcp2210SettingsGP0+2])
+
+	// received bytes may be discarded
+	require.NoError(t, d.Transfer(ctx, []byte{0x06}, nil))
+	assert.Equal(t, 1, decodeSPISettings(fake.spi[:]).TransferSize)
+	assert.Equal(t, 1, fake.chipUpdates)
+	assert.Equal(t, 1, fake.opens)
+
+	assert.ErrorIs(t, d.Transfer(ctx, []byte{0x01, 0x02}, make([]byte, 1)), sensors.ErrInvalidArgument)
+	assert.ErrorIs(t, d.Transfer(ctx, nil, nil), sensors.ErrInvalidArgument)
+
+	require.NoError(t, d.SetTransferMode(SPIMode1, 500_000))
+	require.NoError(t, d.Transfer(ctx, []byte{0x06}, nil))
+	settings = decodeSPISettings(fake.spi[:])
+	assert.Equal(t, SPIMode1, settings.Mode)
+	assert.Equal(t, 500_000, settings.BitRate)
+	assert.ErrorIs(t, d.SetTransferMode(SPIMode(4), 500_000), sensors.ErrInvalidArgument)
+	assert.ErrorIs(t, d.SetTransferMode(SPIMode0, 0), sensors.ErrInvalidArgument)
+	assert.ErrorIs(t, d.SetSPISettings(ctx, MCP2210SPISettings{}), sensors.ErrInvalidArgument)
+
+	d = NewMCP2210(WithMCP2210Transport(fake), WithChipSelect(MCP2210Pins))
+	assert.ErrorIs(t, d.Transfer(ctx, []byte{0x01}, nil), sensors.ErrInvalidArgument)
+}
+
+func TestMCP2210_BusNotAvailable(t *testing.T) {
+	ctx := context.Background()
+	fake := newFakeMCP2210(increment)
+	fake.busOwned = true
+	d := NewMCP2210(WithMCP2210Transport(fake))
+	assert.ErrorIs(t, d.Transfer(ctx, []byte{0x01}, nil), ErrSPIBusNotAvailable)
+}
+
+func TestMCP2210_GPIO(t *testing.T) {
+	ctx := context.Background()
+	fake := newFakeMCP2210(increment)
+	d := NewMCP2210(WithMCP2210Transport(fake))
+
+	require.NoError(t, d.SetPinDesignation(ctx, 4, MCP2210PinGPIO))
+	require.NoError(t, d.SetPinDesignation(ctx, 5, MCP2210PinDedicated))
+	designations, err := d.PinDesignations(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, MCP2210PinGPIO, designations[4])
+	assert.Equal(t, MCP2210PinDedicated, designations[5])
+	assert.ErrorIs(t, d.SetPinDesignation(ctx, MCP2210Pins, MCP2210PinGPIO), sensors.ErrInvalidArgument)
+
+	require.NoError(t, d.SetGPIODirection(ctx, 0x1EF))
+	direction, err := d.GPIODirection(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, uint16(0x1EF), direction)
+
+	require.NoError(t, d.WriteGPIO(ctx, 0xFFFF))
+	value, err := d.ReadGPIO(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, uint16(0x1FF), value)
+}
@@ -12,8 +12,6 @@ import (
 
 	"github.com/mklimuk/sensors"
 
-	"github.com/karalabe/hid"
-
 	"github.com/mklimuk/sensors/cmd/sensors/console"
 	"github.com/mklimuk/sensors/snsctx"
 )
@@ -169,6 +167,10 @@ hid                   159744  3 usbhid,hid_generic,hid_mcp2221
 const VendorID = 0x04D8
 const ProductID = 0x00DD
 
+var _ sensors.I2CBus = &MCP2221{}
+var _ sensors.I2CTransactor = &MCP2221{}
+var _ sensors.AddressLocker = &MCP2221{}
+
 type GPIODesignation byte
 
 const (
@@ -199,15 +201,22 @@ const (
 	GPIO3DAC2 GPIODesignation = 0b00000011
 )
 
-var ErrCommandUnsupported = errors.New("unsupported command")
+var ErrCommandUnsupported = fmt.Errorf("command %w", sensors.ErrUnsupported)
 var ErrCommandFailed = errors.New("command failed")
-var ErrNotConnected = errors.New("not connected")
+var ErrNotConnected = fmt.Errorf("not connected: %w", sensors.ErrDisconnected)
 var ErrNoReconnectChannel = errors.New("reconnect channel not initialized")
-var ErrI2CStatusTimeout = errors.New("i2c status check timeout")
+var ErrI2CStatusTimeout = fmt.Errorf("i2c status check %w", sensors.ErrTimeout)
 var ErrI2CAddressMismatch = errors.New("i2c address mismatch")
+var ErrI2CEngineTimeout = fmt.Errorf("i2c engine %w", sensors.ErrTimeout)
+
+// ErrI2CNACK is returned, as a *sensors.NACKError, when the addressed device
+// does not acknowledge a transfer.
+//
+// Deprecated: Use sensors.ErrNACK.
+var ErrI2CNACK = sensors.ErrNACK
 
 const (
-	StatusNew = iota
+	StatusNew ConnectionState = iota
 	StatusInitialized
 	StatusConnecting
 	StatusConnected
@@ -217,12 +226,18 @@ var (
 	chipDelay = 5 * time.Millisecond
 	i2cDelay  = 50 * time.Millisecond
 	maxDelay  = 75 * time.Millisecond
+	pollDelay = time.Millisecond
 )
 
 type MCP2221Options struct {
 	VendorID    uint16
 	ProductID   uint16
 	DeviceIndex int
+	Serial      string
+	Path        string
+	I2CSpeed    int
+	// Transport enumerates and opens the bridges; USB HID when nil
+	Transport HIDTransport
 }
 
 type MCP2221Option func(*MCP2221Options)
@@ -241,14 +256,32 @@ type MCP2221 struct {
 	response     []byte
 	responseWait time.Duration
 	options      MCP2221Options
-	device       *hid.Device
+	device       HIDDevice
 	// keepOpen, when true, keeps the HID handle alive across calls so tight
 	// polling loops (e.g. button scanning) don't pay USB enumeration / open
 	// cost on every iteration. Toggled via Open / Close.
 	keepOpen bool
+	// speedApplied is set once the configured I2C speed has been sent to
+	// the chip and cleared when the chip may have lost it
+	speedApplied bool
+	// restoreSRAM is set when the chip was lost and shadow has to be sent
+	// again on the next connect
+	restoreSRAM bool
+	shadow      sramShadow
+	stateMx     sync.Mutex
+	state       ConnectionState
+	// reconnect is signalled when the chip is lost; it is nil unless
+	// Monitor runs
+	reconnect   chan struct{}
+	subscribers map[chan ConnectionState]struct{}
+	// password is the flash access password last accepted by the chip; it
+	// is sent along with chip settings writes so that they keep it
+	password []byte
 }
 
 type MCP2221Status struct {
+	I2CState               I2CEngineState
+	I2CAddressNACK         bool
 	I2CDataBufferCounter   int
 	I2CSpeedDivider        int
 	I2CTimeout             int
@@ -257,6 +290,12 @@ type MCP2221Status struct {
 	LastI2CRequestedSize   uint16
 	LastI2CTransferredSize uint16
 	ReadPending            int
+	SCL                    byte
+	SDA                    byte
+	InterruptDetected      bool
+	HardwareRevision       string
+	FirmwareRevision       string
+	ADC                    [3]uint16
 }
 
 type GPIOMode byte
@@ -317,6 +356,7 @@ func NewMCP2221(opts ...MCP2221Option) *MCP2221 {
 		response:     make([]byte, 64),
 		responseWait: 50 * time.Millisecond,
 		options:      options,
+		subscribers:  make(map[chan ConnectionState]struct{}),
 	}
 }
 
@@ -324,6 +364,9 @@ func (d *MCP2221) Init() error {
 	d.mx.Lock()
 	defer d.mx.Unlock()
 	// karalabe/hid doesn't need explicit initialization
+	if d.State() == StatusNew {
+		d.setState(StatusInitialized)
+	}
 	return nil
 }
 
@@ -357,15 +400,27 @@ func (d *MCP2221) connect() error {
 	if d.device != nil {
 		return nil
 	}
-	devices := hid.Enumerate(d.options.VendorID, d.options.ProductID)
-	if len(devices) == 0 {
-		return fmt.Errorf("could not find hid device vendor: %#x product: %#x", d.options.VendorID, d.options.ProductID)
+	info, err := d.options.selectDevice(d.options.enumerate())
+	if err != nil {
+		d.lost()
+		return err
 	}
-	device, err := devices[d.options.DeviceIndex].Open()
+	device, err := d.options.transport().Open(info)
 	if err != nil {
 		return fmt.Errorf("could not open hid device vendor: %#x product: %#x: %w", d.options.VendorID, d.options.ProductID, err)
 	}
 	d.device = device
+	err = d.applyI2CSpeed()
+	if err != nil {
+		d.lost()
+		return fmt.Errorf("could not apply i2c speed: %w", err)
+	}
+	err = d.applySRAMShadow()
+	if err != nil {
+		d.lost()
+		return err
+	}
+	d.setState(StatusConnected)
 	return nil
 }
 
@@ -416,17 +471,39 @@ func (d *MCP2221) Close() error {
 	return d.invalidate()
 }
 
+// i2cChunkSize is the I2C payload carried by a single HID report; longer
+// transfers are split over several reports.
+const i2cChunkSize = 60
+
+// i2cMaxTransfer is the longest transfer the I2C engine accepts.
+const i2cMaxTransfer = 0xFFFF
+
+// I2C engine states used by the transfer logic, see I2CEngineState for the
+// complete list
+const (
+	i2cStateIdle          = 0x00
+	i2cStateAddrNACK      = 0x25
+	i2cStatePartialData   = 0x41
+	i2cStateWritingNoStop = 0x45
+)
+
+// i2cReadNotReady is reported as the data size of a Get I2C Data response
+// when no data could be collected from the engine.
+const i2cReadNotReady = 0x7F
+
+// I2C transfer commands
+const (
+	cmdI2CWrite            = 0x90
+	cmdI2CWriteRepeatStart = 0x92
+	cmdI2CWriteNoStop      = 0x94
+	cmdI2CRead             = 0x91
+	cmdI2CReadRepeatStart  = 0x93
+	cmdI2CGetData          = 0x40
+)
+
 func (d *MCP2221) WriteToAddr(ctx context.Context, address byte, buffer []byte) error {
 	d.mx.Lock()
 	defer d.mx.Unlock()
-	d.resetBuffers()
-	d.request[0] = 0x90
-	binary.LittleEndian.PutUint16(d.request[1:3], uint16(len(buffer)))
-	addr := address << 1
-	d.request[3] = addr
-	if len(buffer) > 0 {
-		copy(d.request[4:], buffer)
-	}
 	err := d.connect()
 	if err != nil {
 		return fmt.Errorf("could not connect to mcp2221: %w", err)
@@ -437,34 +514,12 @@ func (d *MCP2221) WriteToAddr(ctx context.Context, address byte, buffer []byte)
 			slog.Error("could not disconnect from mcp2221", "err", err)
 		}
 	}()
-	err = d.send(ctx)
-	if err != nil {
-		return fmt.Errorf("i2c write to %x request write failed: %w", address, err)
-	}
-	err = d.waitAndReceive(ctx, chipDelay)
-	if err != nil {
-		return fmt.Errorf("i2c write to %x response read failed: %w", address, err)
-	}
-	// read could not be performed
-	if d.response[1] == 0x01 {
-		slog.Debug("i2c bus busy, releasing bus", "state", d.response[2])
-		_, err = d.doReleaseBus(ctx)
-		if err != nil {
-			return fmt.Errorf("%w; could not release bus: %v", sensors.ErrBusBusy, err)
-		}
-	}
-	return nil
+	return d.doWrite(ctx, cmdI2CWrite, address, buffer)
 }
 
 func (d *MCP2221) ReadFromAddr(ctx context.Context, address byte, buffer []byte) error {
 	d.mx.Lock()
 	defer d.mx.Unlock()
-	d.resetBuffers()
-	// send i2c read request
-	d.request[0] = 0x91
-	binary.LittleEndian.PutUint16(d.request[1:3], uint16(len(buffer)))
-	addr := address<<1 + 1
-	d.request[3] = addr
 	err := d.connect()
 	if err != nil {
 		return fmt.Errorf("could not connect to mcp2221: %w", err)
@@ -475,50 +530,15 @@ func (d *MCP2221) ReadFromAddr(ctx context.Context, address byte, buffer []byte)
 			slog.Error("could not disconnect from mcp2221", "err", err)
 		}
 	}()
-	err = d.send(ctx)
-	// we iterated several times with no result
-	if err != nil {
-		return fmt.Errorf("i2c read from %x request failed: %w", address, err)
-	}
-	err = d.receive(ctx)
-	if err != nil {
-		return fmt.Errorf("i2c read from %x response receive failed: %w", address, err)
-	}
-	if d.response[1] == 0x01 {
-		slog.Debug("i2c bus busy, releasing bus", "state", d.response[2])
-		_, err = d.doReleaseBus(ctx)
-		if err != nil {
-			return fmt.Errorf("%w; could not release bus: %v", sensors.ErrBusBusy, err)
-		}
-		return sensors.ErrBusBusy
-	}
-	// read i2c data
-	d.request[0] = 0x40
-	resetBuffer(d.response)
-	err = d.send(ctx)
-	if err != nil {
-		return fmt.Errorf("error getting i2c read data from adapter: %w", err)
-	}
-	err = d.waitAndReceive(ctx, chipDelay)
-	if err != nil {
-		return fmt.Errorf("i2c read from %x response receive failed: %w", address, err)
-	}
-	if d.response[1] == 0x41 {
-		return fmt.Errorf("error reading the i2c slave data from the i2c engine")
-	}
-	if d.response[3] == 127 || int(d.response[3]) != len(buffer) {
-		return fmt.Errorf("invalid data size byte; expected %d, got %d", len(buffer), d.response[3])
-	}
-
-	copy(buffer, d.response[4:])
-	return nil
+	return d.doRead(ctx, cmdI2CRead, address, buffer)
 }
 
-func (d *MCP2221) ReadChipSettings(ctx context.Context) error {
+// Tx writes w to the device at address without issuing a STOP condition and
+// then reads len(r) bytes back after a repeated START, so no other master can
+// take the bus between the two phases.
+func (d *MCP2221) Tx(ctx context.Context, address byte, w, r []byte) error {
 	d.mx.Lock()
 	defer d.mx.Unlock()
-	d.resetBuffers()
-	d.request[0] = 0xB0
 	err := d.connect()
 	if err != nil {
 		return fmt.Errorf("could not connect to mcp2221: %w", err)
@@ -529,51 +549,144 @@ func (d *MCP2221) ReadChipSettings(ctx context.Context) error {
 			slog.Error("could not disconnect from mcp2221", "err", err)
 		}
 	}()
-	err = d.send(ctx)
-	if err != nil {
-		return fmt.Errorf("read chip parameters command request write failed: %w", err)
+	if len(r) == 0 {
+		return d.doWrite(ctx, cmdI2CWrite, address, w)
 	}
-	err = d.receive(ctx)
-	if err != nil {
-		return fmt.Errorf("read chip parameters command response read failed: %w", err)
+	if len(w) == 0 {
+		return d.doRead(ctx, cmdI2CRead, address, r)
 	}
-	// read could not be performed
-	if d.response[1] == 0x01 {
-		return ErrCommandFailed
+	err = d.doWrite(ctx, cmdI2CWriteNoStop, address, w)
+	if err != nil {
+		return err
 	}
-	dump("chip settings:", d.response[:14])
-	return nil
+	return d.doRead(ctx, cmdI2CReadRepeatStart, address, r)
 }
 
-func (d *MCP2221) ReadGPIOSettings(ctx context.Context) error {
-	d.mx.Lock()
-	defer d.mx.Unlock()
-	d.resetBuffers()
-	d.request[0] = 0xB0
-	d.request[1] = 0x01
-	err := d.connect()
-	if err != nil {
-		return fmt.Errorf("could not connect to mcp2221: %w", err)
-	}
-	defer func() {
-		err = d.disconnect()
+// doWrite sends buffer in as many reports as needed, each carrying up to
+// i2cChunkSize bytes along with the total transfer length. A busy engine on
+// the first report means the bus is stuck: it is released and ErrBusBusy is
+// returned, so that Tx never reads after a dropped write. Later reports are
+// retried until the engine has drained the previous one.
+func (d *MCP2221) doWrite(ctx context.Context, cmd byte, address byte, buffer []byte) error {
+	if len(buffer) > i2cMaxTransfer {
+		return fmt.Errorf("i2c write to %x of %d bytes exceeds %d bytes", address, len(buffer), i2cMaxTransfer)
+	}
+	sent := 0
+	deadline := time.Now().Add(maxDelay)
+	for {
+		chunk := min(len(buffer)-sent, i2cChunkSize)
+		d.resetBuffers()
+		d.request[0] = cmd
+		binary.LittleEndian.PutUint16(d.request[1:3], uint16(len(buffer)))
+		d.request[3] = address << 1
+		copy(d.request[4:], buffer[sent:sent+chunk])
+		err := d.send(ctx)
 		if err != nil {
-			slog.Error("could not disconnect from mcp2221", "err", err)
+			return fmt.Errorf("i2c write to %x request write failed: %w", address, err)
 		}
-	}()
-	err = d.send(ctx)
+		err = d.waitAndReceive(ctx, chipDelay)
+		if err != nil {
+			return fmt.Errorf("i2c write to %x response read failed: %w", address, err)
+		}
+		// write could not be performed
+		if d.response[1] == 0x01 {
+			if sent == 0 {
+				slog.Debug("i2c bus busy, releasing bus", "state", d.response[2])
+				_, err = d.doReleaseBus(ctx)
+				if err != nil {
+					return fmt.Errorf("%w; could not release bus: %v", sensors.ErrBusBusy, err)
+				}
+				return sensors.ErrBusBusy
+			}
+			if time.Now().After(deadline) {
+				return fmt.Errorf("i2c write to %x stalled after %d of %d bytes: %w", address, sent, len(buffer), ErrI2CEngineTimeout)
+			}
+			err = pause(ctx, pollDelay)
+			if err != nil {
+				return err
+			}
+			continue
+		}
+		sent += chunk
+		deadline = time.Now().Add(maxDelay)
+		if sent >= len(buffer) {
+			break
+		}
+	}
+	// the engine acknowledges the reports before the transfer is done, so
+	// its state is polled to report a NACK even for single report writes
+	return d.waitForI2CTransfer(ctx, cmd, address)
+}
+
+// doRead requests the transfer of len(buffer) bytes and collects the data
+// with as many Get I2C Data reports as needed, each carrying up to
+// i2cChunkSize bytes.
+func (d *MCP2221) doRead(ctx context.Context, cmd byte, address byte, buffer []byte) error {
+	if len(buffer) > i2cMaxTransfer {
+		return fmt.Errorf("i2c read from %x of %d bytes exceeds %d bytes", address, len(buffer), i2cMaxTransfer)
+	}
+	d.resetBuffers()
+	// send i2c read request
+	d.request[0] = cmd
+	binary.LittleEndian.PutUint16(d.request[1:3], uint16(len(buffer)))
+	addr := address<<1 + 1
+	d.request[3] = addr
+	err := d.send(ctx)
+	// we iterated several times with no result
 	if err != nil {
-		return fmt.Errorf("read gpio parameters command request write failed: %w", err)
+		return fmt.Errorf("i2c read from %x request failed: %w", address, err)
 	}
 	err = d.receive(ctx)
 	if err != nil {
-		return fmt.Errorf("read gpio parameters command response read failed: %w", err)
+		return fmt.Errorf("i2c read from %x response receive failed: %w", address, err)
 	}
-	// read could not be performed
 	if d.response[1] == 0x01 {
-		return ErrCommandFailed
+		slog.Debug("i2c bus busy, releasing bus", "state", d.response[2])
+		_, err = d.doReleaseBus(ctx)
+		if err != nil {
+			return fmt.Errorf("%w; could not release bus: %v", sensors.ErrBusBusy, err)
+		}
+		return sensors.ErrBusBusy
+	}
+	// read i2c data
+	received := 0
+	deadline := time.Now().Add(maxDelay)
+	for received < len(buffer) {
+		d.resetBuffers()
+		d.request[0] = cmdI2CGetData
+		err = d.send(ctx)
+		if err != nil {
+			return fmt.Errorf("error getting i2c read data from adapter: %w", err)
+		}
+		err = d.waitAndReceive(ctx, chipDelay)
+		if err != nil {
+			return fmt.Errorf("i2c read from %x response receive failed: %w", address, err)
+		}
+		if d.response[2] == i2cStateAddrNACK {
+			return fmt.Errorf("i2c read: %w", &sensors.NACKError{Address: address})
+		}
+		size := int(d.response[3])
+		// the engine has not collected the next chunk yet
+		if d.response[1] == i2cStatePartialData || size == i2cReadNotReady || size == 0 {
+			if time.Now().After(deadline) {
+				return fmt.Errorf("i2c read from %x stalled after %d of %d bytes: %w", address, received, len(buffer), ErrI2CEngineTimeout)
+			}
+			err = pause(ctx, pollDelay)
+			if err != nil {
+				return err
+			}
+			continue
+		}
+		if d.response[1] != 0x00 {
+			return fmt.Errorf("error reading the i2c slave data from the i2c engine")
+		}
+		if size > i2cChunkSize || size > len(buffer)-received {
+			return fmt.Errorf("invalid data size byte; expected at most %d, got %d", min(len(buffer)-received, i2cChunkSize), size)
+		}
+		copy(buffer[received:], d.response[4:4+size])
+		received += size
+		deadline = time.Now().Add(maxDelay)
 	}
-	dump("gpio settings:", d.response[:14])
 	return nil
 }
 
@@ -584,69 +697,17 @@ func dump(description string, value []byte) {
 	}
 }
 
-func (d *MCP2221) UpdateVendorAndProductID(ctx context.Context, vendor, product []byte, dryrun ...bool) error {
-	for len(vendor) < 2 {
-		vendor = append(vendor, 0x00)
-	}
-	for len(product) < 2 {
-		product = append(product, 0x00)
-	}
-	d.mx.Lock()
-	defer d.mx.Unlock()
-	d.resetBuffers()
-	d.request[0] = 0xB1 // command
-	d.request[1] = 0x00 // subcommand
-	//d.request[2] = 0xFC // set usb CDC serial number
-	d.request[2] = 0x7C // set usb CDC serial number
-	d.request[3] = 0x12 // clock divider
-	d.request[4] = 0x88 // reference voltage DAC
-	d.request[5] = 0x6F // reference voltage ADC
-	d.request[6] = vendor[1]
-	d.request[7] = vendor[0]
-	d.request[8] = product[1]
-	d.request[9] = product[0]
-	d.request[10] = 0x80 // usb power attributes
-	d.request[11] = 0x32 // usb requested mA value
-
-	if len(dryrun) > 0 && dryrun[0] {
-		dump("sent chip settings:", d.request[:12])
-		return nil
-	}
-	err := d.connect()
-	if err != nil {
-		return fmt.Errorf("could not connect to mcp2221: %w", err)
-	}
-	defer func() {
-		err = d.disconnect()
-		if err != nil {
-			slog.Error("could not disconnect from mcp2221", "err", err)
-		}
-	}()
-	err = d.send(ctx)
-	if err != nil {
-		return fmt.Errorf("write chip parameters command request write failed: %w", err)
-	}
-	err = d.receive(ctx)
-	if err != nil {
-		return fmt.Errorf("write chip parameters command response read failed: %w", err)
-	}
-	// read could not be performed
-	if d.response[1] == 0x01 {
-		return ErrCommandFailed
-	}
-	return nil
-}
-
-func (d *MCP2221) SetGPIOParameters(ctx context.Context, params MCP2221GPIOParameters) error {
+// WriteFlashGPIOParameters stores params as the power-up GP configuration in
+// flash. It does not change the current configuration; use
+// SetGPIOParameters for that. Flash has limited write endurance, so this
+// should only be used to deliberately change the chip defaults.
+func (d *MCP2221) WriteFlashGPIOParameters(ctx context.Context, params MCP2221GPIOParameters) error {
 	d.mx.Lock()
 	defer d.mx.Unlock()
 	d.resetBuffers()
-	d.request[0] = 0xB1
-	d.request[1] = 0x01
-	d.request[2] = byte(params.GPIO0Designation) | byte(params.GPIO0Mode)
-	d.request[3] = byte(params.GPIO1Designation) | byte(params.GPIO1Mode)
-	d.request[4] = byte(params.GPIO2Designation) | byte(params.GPIO2Mode)
-	d.request[5] = byte(params.GPIO3Designation) | byte(params.GPIO3Mode)
+	d.request[0] = cmdWriteFlash
+	d.request[1] = flashGPSettings
+	params.encode(d.request[2:6], nil)
 	err := d.connect()
 	if err != nil {
 		return fmt.Errorf("could not connect to mcp2221: %w", err)
@@ -659,11 +720,11 @@ func (d *MCP2221) SetGPIOParameters(ctx context.Context, params MCP2221GPIOParam
 	}()
 	err = d.send(ctx)
 	if err != nil {
-		return fmt.Errorf("set GP parameters command request write failed: %w", err)
+		return fmt.Errorf("write flash GP parameters command request write failed: %w", err)
 	}
 	err = d.receive(ctx)
 	if err != nil {
-		return fmt.Errorf("set GP parameters command response read failed: %w", err)
+		return fmt.Errorf("write flash GP parameters command response read failed: %w", err)
 	}
 	// read could not be performed
 	if d.response[1] == 0x01 {
@@ -684,7 +745,7 @@ func (d *MCP2221) ReadGPIO(ctx context.Context) (MCP2221GPIOValues, error) {
 	d.mx.Lock()
 	defer d.mx.Unlock()
 	d.resetBuffers()
-	d.request[0] = 0x51
+	d.request[0] = cmdGetGPIOValues
 	err := d.connect()
 	if err != nil {
 		return MCP2221GPIOValues{}, fmt.Errorf("could not connect to mcp2221: %w", err)
@@ -731,12 +792,14 @@ func (d *MCP2221) ReadGPIO(ctx context.Context) (MCP2221GPIOValues, error) {
 	return res, nil
 }
 
-func (d *MCP2221) GetGPIOParameters(ctx context.Context) (MCP2221GPIOParameters, error) {
+// ReadFlashGPIOParameters returns the power-up GP configuration stored in
+// flash.
+func (d *MCP2221) ReadFlashGPIOParameters(ctx context.Context) (MCP2221GPIOParameters, error) {
 	d.mx.Lock()
 	defer d.mx.Unlock()
 	d.resetBuffers()
-	d.request[0] = 0xB0
-	d.request[1] = 0x01
+	d.request[0] = cmdReadFlash
+	d.request[1] = flashGPSettings
 	err := d.connect()
 	if err != nil {
 		return MCP2221GPIOParameters{}, fmt.Errorf("could not connect to mcp2221: %w", err)
@@ -749,26 +812,17 @@ func (d *MCP2221) GetGPIOParameters(ctx context.Context) (MCP2221GPIOParameters,
 	}()
 	err = d.send(ctx)
 	if err != nil {
-		return MCP2221GPIOParameters{}, fmt.Errorf("get GP parameters command request write failed: %w", err)
+		return MCP2221GPIOParameters{}, fmt.Errorf("read flash GP parameters command request write failed: %w", err)
 	}
 	err = d.receive(ctx)
 	if err != nil {
-		return MCP2221GPIOParameters{}, fmt.Errorf("get GP parameters command response read failed: %w", err)
+		return MCP2221GPIOParameters{}, fmt.Errorf("read flash GP parameters command response read failed: %w", err)
 	}
 	// read could not be performed
 	if d.response[1] == 0x01 {
 		return MCP2221GPIOParameters{}, ErrCommandUnsupported
 	}
-	return MCP2221GPIOParameters{
-		GPIO0Mode:        GPIOMode(d.response[4] & gpioModeMask),
-		GPIO0Designation: GPIODesignation(d.response[4] & gpioOperationMask),
-		GPIO1Mode:        GPIOMode(d.response[5] & gpioModeMask),
-		GPIO1Designation: GPIODesignation(d.response[5] & gpioOperationMask),
-		GPIO2Mode:        GPIOMode(d.response[6] & gpioModeMask),
-		GPIO2Designation: GPIODesignation(d.response[6] & gpioOperationMask),
-		GPIO3Mode:        GPIOMode(d.response[7] & gpioModeMask),
-		GPIO3Designation: GPIODesignation(d.response[7] & gpioOperationMask),
-	}, nil
+	return decodeGPIOParameters(d.response[4:8]), nil
 }
 
 func (d *MCP2221) Status(ctx context.Context) (*MCP2221Status, error) {
@@ -804,6 +858,19 @@ func (d *MCP2221) doGetStatus(ctx context.Context) (*MCP2221Status, error) {
 func (d *MCP2221) Reset(ctx context.Context) error {
 	d.mx.Lock()
 	defer d.mx.Unlock()
+	err := d.doReset(ctx)
+	if err != nil {
+		return err
+	}
+	// the chip restarts with the default SRAM settings
+	d.shadow = sramShadow{}
+	return nil
+}
+
+// doReset resets the chip, which then enumerates again. The SRAM settings
+// recorded in d.shadow are restored on the next connect. Callers must hold
+// d.mx.
+func (d *MCP2221) doReset(ctx context.Context) error {
 	d.resetBuffers()
 	d.request[0] = 0x70
 	d.request[1] = 0xAB
@@ -823,11 +890,13 @@ func (d *MCP2221) Reset(ctx context.Context) error {
 	if err != nil {
 		return fmt.Errorf("reset request failed: %w", err)
 	}
+	d.lost()
 	return nil
 }
 
 func bufferToStatus(buffer []byte) *MCP2221Status {
 	/*
+		8: I2C engine state machine
 		9: Lower byte (16-bit value) of the requested I2C transfer length
 		10: Higher byte (16-bit value) of the requested I2C transfer length
 		11:	Lower byte (16-bit value) of the already transferred (through I2C) number of bytes
@@ -837,17 +906,36 @@ func bufferToStatus(buffer []byte) *MCP2221Status {
 		15: Current I2C timeout value
 		16:	Lower byte (16-bit value) of the I2C address being used
 		17:	Higher byte (16-bit value) of the I2C address being used
+		20: bit 6 set when the I2C address was not acknowledged
+		22: SCL line value
+		23: SDA line value
+		24: Interrupt edge detector state
+		25: I2C read pending
+		46-47: Hardware revision (major, minor)
+		48-49: Firmware revision (major, minor)
+		50-55: ADC channels 1-3 (16-bit little endian values)
 	*/
 	status := &MCP2221Status{
+		I2CState:             I2CEngineState(buffer[statusI2CState]),
+		I2CAddressNACK:       buffer[statusI2CACK]&statusNACKMask != 0,
 		I2CDataBufferCounter: int(buffer[13]),
 		I2CSpeedDivider:      int(buffer[14]),
 		I2CTimeout:           int(buffer[15]),
 		ReadPending:          int(buffer[25]),
 		CurrentAddress:       hex.EncodeToString(buffer[16:18]),
+		SCL:                  buffer[statusSCL],
+		SDA:                  buffer[statusSDA],
+		InterruptDetected:    buffer[statusInterrupt] != 0,
+		HardwareRevision:     revision(buffer[statusHardwareRevision : statusHardwareRevision+2]),
+		FirmwareRevision:     revision(buffer[statusFirmwareRevision : statusFirmwareRevision+2]),
 	}
 	status.LastI2CRequestedSize = binary.LittleEndian.Uint16(buffer[9:11])
 	status.LastI2CTransferredSize = binary.LittleEndian.Uint16(buffer[11:13])
 	status.I2CAddress = buffer[16]
+	for i := range status.ADC {
+		offset := statusADC + 2*i
+		status.ADC[i] = binary.LittleEndian.Uint16(buffer[offset:offset+2]) & ADCMaxValue
+	}
 	return status
 }
 
@@ -893,30 +981,85 @@ func (d *MCP2221) doReleaseBus(ctx context.Context) (*MCP2221Status, error) {
 	return bufferToStatus(d.response), nil
 }
 
-func (d *MCP2221) waitForI2CTransfer(ctx context.Context, address byte) error {
-	timeout := time.NewTimer(maxDelay)
-	defer timeout.Stop()
-	tick := time.NewTicker(chipDelay)
-	defer tick.Stop()
+// waitForI2CTransfer polls the engine state until a multi-report write has
+// been clocked out on the bus. A write without stop leaves the engine waiting
+// for the repeated start, which also counts as done. Callers must hold d.mx
+// and be connected.
+func (d *MCP2221) waitForI2CTransfer(ctx context.Context, cmd byte, address byte) error {
+	deadline := time.Now().Add(maxDelay)
+	var transferred uint16
 	for {
-		select {
-		case <-tick.C:
-			status, err := d.doGetStatus(ctx)
-			if err != nil {
-				slog.Error("could not get status", "err", err)
-			}
-			if status.I2CAddress != 0x00 && status.I2CAddress != address {
-				return fmt.Errorf("%w; expected %#x, got %#x", ErrI2CAddressMismatch, address, status.I2CAddress)
-			}
-			if status.LastI2CRequestedSize == status.LastI2CTransferredSize {
+		d.resetBuffers()
+		d.request[0] = 0x10
+		err := d.send(ctx)
+		if err != nil {
+			return fmt.Errorf("could not send status request: %w", err)
+		}
+		err = d.receive(ctx)
+		if err != nil {
+			return fmt.Errorf("could not receive status: %w", err)
+		}
+		state := d.response[statusI2CState]
+		if d.response[statusI2CACK]&statusNACKMask != 0 || state == i2cStateAddrNACK {
+			return fmt.Errorf("i2c write: %w", &sensors.NACKError{Address: address})
+		}
+		switch state {
+		case i2cStateIdle:
+			return nil
+		case i2cStateWritingNoStop:
+			if cmd == cmdI2CWriteNoStop {
 				return nil
 			}
-		case <-timeout.C:
-			return ErrI2CStatusTimeout
-		case <-ctx.Done():
-			return ctx.Err()
+		default:
+			if I2CEngineState(state).Timeout() {
+				return fmt.Errorf("i2c write to %x failed in state %s: %w", address, I2CEngineState(state), ErrI2CEngineTimeout)
+			}
 		}
+		status := bufferToStatus(d.response)
+		// the deadline only applies while the engine makes no progress as
+		// long transfers take seconds at low bus speeds
+		if status.LastI2CTransferredSize != transferred {
+			transferred = status.LastI2CTransferredSize
+			deadline = time.Now().Add(maxDelay)
+		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf("i2c write to %x stalled in state %s: %w", address, I2CEngineState(state), ErrI2CStatusTimeout)
+		}
+		err = pause(ctx, pollDelay)
+		if err != nil {
+			return err
+		}
+	}
+}
+
+func pause(ctx context.Context, delay time.Duration) error {
+	select {
+	case <-time.After(delay):
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// exchange sends the prepared request and reads the response into
+// d.response, holding the HID handle only for the duration of the call unless
+// a sticky session is active. Callers must hold d.mx.
+func (d *MCP2221) exchange(ctx context.Context) error {
+	err := d.connect()
+	if err != nil {
+		return fmt.Errorf("could not connect to mcp2221: %w", err)
+	}
+	defer func() {
+		err := d.disconnect()
+		if err != nil {
+			slog.Error("could not disconnect from mcp2221", "err", err)
+		}
+	}()
+	err = d.send(ctx)
+	if err != nil {
+		return err
 	}
+	return d.receive(ctx)
 }
 
 func (d *MCP2221) waitAndReceive(ctx context.Context, delay time.Duration) error {
@@ -940,12 +1083,12 @@ func (d *MCP2221) send(ctx context.Context) error {
 
 	n, err := d.device.Write(d.request)
 	if err != nil {
-		_ = d.invalidate()
-		return fmt.Errorf("could not write request: %w", err)
+		d.lost()
+		return fmt.Errorf("could not write request: %w: %w", sensors.ErrDisconnected, err)
 	}
 	if n != 64 {
-		_ = d.invalidate()
-		return fmt.Errorf("short write: %d", n)
+		d.lost()
+		return fmt.Errorf("short write: %d: %w", n, sensors.ErrDisconnected)
 	}
 	return nil
 }
@@ -954,12 +1097,12 @@ func (d *MCP2221) send(ctx context.Context) error {
 func (d *MCP2221) receive(ctx context.Context) error {
 	n, err := d.device.Read(d.response)
 	if err != nil {
-		_ = d.invalidate()
-		return fmt.Errorf("could not read response: %w", err)
+		d.lost()
+		return fmt.Errorf("could not read response: %w: %w", sensors.ErrDisconnected, err)
 	}
 	if n != 64 {
-		_ = d.invalidate()
-		return fmt.Errorf("short read: %d", n)
+		d.lost()
+		return fmt.Errorf("short read: %d: %w", n, sensors.ErrDisconnected)
 	}
 	verbose := snsctx.IsVerbose(ctx)
 	if verbose {
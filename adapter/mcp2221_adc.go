@@ -0,0 +1,73 @@
+package adapter
+
+import (
+	"context"
+	"fmt"
+)
+
+// ADCChannel identifies one of the three 10-bit ADC inputs. ADC1 is routed to
+// GP1, ADC2 to GP2 and ADC3 to GP3 when the pin is designated as ADC input.
+type ADCChannel int
+
+const (
+	ADC1 ADCChannel = iota + 1
+	ADC2
+	ADC3
+)
+
+// ADCMaxValue is the full scale value of the 10-bit converter.
+const ADCMaxValue = 0x3FF
+
+// ADCReading holds one sample of all three channels along with the reference
+// in use when it was taken.
+type ADCReading struct {
+	Reference VoltageReference `yaml:"reference"`
+	Values    [3]uint16        `yaml:"values"`
+}
+
+// Value returns the raw 10-bit value of the given channel.
+func (r ADCReading) Value(ch ADCChannel) uint16 {
+	if ch < ADC1 || ch > ADC3 {
+		return 0
+	}
+	return r.Values[ch-1]
+}
+
+// Volts converts the value of the given channel to volts. vdd is the supply
+// voltage of the chip and is used only when the reference is VDD.
+func (r ADCReading) Volts(ch ADCChannel, vdd float64) float64 {
+	return float64(r.Value(ch)) / ADCMaxValue * r.Reference.Volts(vdd)
+}
+
+// ReadADC samples all ADC channels. The values are meaningful only for pins
+// designated as ADC inputs.
+func (d *MCP2221) ReadADC(ctx context.Context) (ADCReading, error) {
+	d.mx.Lock()
+	defer d.mx.Unlock()
+	var res ADCReading
+	err := d.doGetSRAM(ctx)
+	if err != nil {
+		return res, fmt.Errorf("could not read ADC reference: %w", err)
+	}
+	res.Reference = voltageReferenceFromSRAM(d.response[sramGetInterrupt], 2)
+	status, err := d.doGetStatus(ctx)
+	if err != nil {
+		return res, fmt.Errorf("could not read ADC values: %w", err)
+	}
+	res.Values = status.ADC
+	return res, nil
+}
+
+// SetADCReference selects the ADC voltage reference in SRAM. The setting is
+// volatile and reverts to the flash default on reset.
+func (d *MCP2221) SetADCReference(ctx context.Context, ref VoltageReference) error {
+	d.mx.Lock()
+	defer d.mx.Unlock()
+	err := d.doSetSRAM(ctx, func(request []byte) {
+		request[sramSetADCRef] = sramAlter | byte(ref)
+	})
+	if err != nil {
+		return fmt.Errorf("could not set ADC reference: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,50 @@
+package adapter
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVoltageReferenceFromSRAM(t *testing.T) {
+	tests := []struct {
+		name     string
+		value    byte
+		shift    uint
+		expected VoltageReference
+	}{
+		{"adc vdd", 0b00000000, 2, VoltageReferenceVDD},
+		{"adc 1.024", 0b00001100, 2, VoltageReference1V024},
+		{"adc 2.048", 0b00010100, 2, VoltageReference2V048},
+		{"adc 4.096 with interrupt bits", 0b01111100, 2, VoltageReference4V096},
+		{"adc vrm level without vrm source", 0b00011000, 2, VoltageReferenceVDD},
+		{"dac 2.048", 0b10100000, 5, VoltageReference2V048},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, voltageReferenceFromSRAM(tt.value, tt.shift))
+		})
+	}
+}
+
+func TestParseVoltageReference(t *testing.T) {
+	ref, err := ParseVoltageReference("2.048")
+	assert.NoError(t, err)
+	assert.Equal(t, VoltageReference2V048, ref)
+	_, err = ParseVoltageReference("3.3")
+	assert.Error(t, err)
+}
+
+func TestADCReading_Volts(t *testing.T) {
+	reading := ADCReading{
+		Reference: VoltageReference4V096,
+		Values:    [3]uint16{0, ADCMaxValue, 512},
+	}
+	assert.Equal(t, 0.0, reading.Volts(ADC1, 5))
+	assert.InDelta(t, 4.096, reading.Volts(ADC2, 5), 1e-9)
+	assert.InDelta(t, 2.05, reading.Volts(ADC3, 5), 0.01)
+
+	reading.Reference = VoltageReferenceVDD
+	assert.InDelta(t, 3.3, reading.Volts(ADC2, 3.3), 1e-9)
+	assert.Equal(t, uint16(0), reading.Value(ADCChannel(4)))
+}
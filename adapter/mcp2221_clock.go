@@ -0,0 +1,161 @@
+package adapter
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// Clock output setting of the SRAM settings: bits 4:3 select the duty cycle
+// and bits 2:0 the divider. In the Set SRAM Settings request bit 7 loads the
+// new value.
+const (
+	clockDutyShift   = 3
+	clockDutyMask    = 0b00011000
+	clockDividerMask = 0b00000111
+)
+
+// ClockFrequency is the reference clock output frequency, encoded as the
+// divider of the 48 MHz internal clock.
+type ClockFrequency byte
+
+const (
+	Clock24MHz   ClockFrequency = 0b001
+	Clock12MHz   ClockFrequency = 0b010
+	Clock6MHz    ClockFrequency = 0b011
+	Clock3MHz    ClockFrequency = 0b100
+	Clock1M5Hz   ClockFrequency = 0b101
+	Clock750kHz  ClockFrequency = 0b110
+	Clock375kHz  ClockFrequency = 0b111
+	clockInvalid ClockFrequency = 0b000
+)
+
+var clockFrequencyNames = map[ClockFrequency]string{
+	Clock24MHz:  "24MHz",
+	Clock12MHz:  "12MHz",
+	Clock6MHz:   "6MHz",
+	Clock3MHz:   "3MHz",
+	Clock1M5Hz:  "1.5MHz",
+	Clock750kHz: "750kHz",
+	Clock375kHz: "375kHz",
+}
+
+// ParseClockFrequency accepts one of the supported frequencies, e.g. "12MHz",
+// "1.5MHz" or "375kHz" (case insensitive, the Hz suffix is optional).
+func ParseClockFrequency(value string) (ClockFrequency, error) {
+	clean := strings.TrimSuffix(strings.ToLower(strings.TrimSpace(value)), "hz")
+	for f, name := range clockFrequencyNames {
+		if clean == strings.TrimSuffix(strings.ToLower(name), "hz") {
+			return f, nil
+		}
+	}
+	return clockInvalid, fmt.Errorf("invalid clock frequency %q, expected 24MHz, 12MHz, 6MHz, 3MHz, 1.5MHz, 750kHz or 375kHz", value)
+}
+
+func (f ClockFrequency) String() string {
+	name, ok := clockFrequencyNames[f]
+	if !ok {
+		return "invalid"
+	}
+	return name
+}
+
+func (f ClockFrequency) MarshalYAML() (interface{}, error) {
+	return f.String(), nil
+}
+
+// Hz returns the frequency in Hz.
+func (f ClockFrequency) Hz() int {
+	if f == clockInvalid || f > Clock375kHz {
+		return 0
+	}
+	return 48_000_000 >> f
+}
+
+// ClockDuty is the duty cycle of the reference clock output.
+type ClockDuty byte
+
+const (
+	ClockDuty0  ClockDuty = 0b00
+	ClockDuty25 ClockDuty = 0b01
+	ClockDuty50 ClockDuty = 0b10
+	ClockDuty75 ClockDuty = 0b11
+)
+
+// ParseClockDuty accepts 0, 25, 50 or 75, optionally followed by "%".
+func ParseClockDuty(value string) (ClockDuty, error) {
+	switch strings.TrimSuffix(strings.TrimSpace(value), "%") {
+	case "0":
+		return ClockDuty0, nil
+	case "25":
+		return ClockDuty25, nil
+	case "50":
+		return ClockDuty50, nil
+	case "75":
+		return ClockDuty75, nil
+	}
+	return 0, fmt.Errorf("invalid clock duty cycle %q, expected 0, 25, 50 or 75", value)
+}
+
+func (d ClockDuty) String() string {
+	return fmt.Sprintf("%d%%", int(d&0b11)*25)
+}
+
+func (d ClockDuty) MarshalYAML() (interface{}, error) {
+	return d.String(), nil
+}
+
+// ClockOutput is the reference clock output configuration.
+type ClockOutput struct {
+	Frequency ClockFrequency `yaml:"frequency"`
+	Duty      ClockDuty      `yaml:"duty"`
+}
+
+func decodeClockOutput(value byte) ClockOutput {
+	return ClockOutput{
+		Frequency: ClockFrequency(value & clockDividerMask),
+		Duty:      ClockDuty(value & clockDutyMask >> clockDutyShift),
+	}
+}
+
+func (c ClockOutput) encode() byte {
+	return byte(c.Duty)<<clockDutyShift&clockDutyMask | byte(c.Frequency)&clockDividerMask
+}
+
+// ClockOutput returns the current reference clock output configuration.
+func (d *MCP2221) ClockOutput(ctx context.Context) (ClockOutput, error) {
+	d.mx.Lock()
+	defer d.mx.Unlock()
+	err := d.doGetSRAM(ctx)
+	if err != nil {
+		return ClockOutput{}, fmt.Errorf("could not read clock output: %w", err)
+	}
+	return decodeClockOutput(d.response[sramGetClock]), nil
+}
+
+// SetClockOutput configures the reference clock output and designates GP1 as
+// the clock output pin. The other pins keep their configuration. Like other
+// SRAM settings this is lost on reset.
+func (d *MCP2221) SetClockOutput(ctx context.Context, clock ClockOutput) error {
+	if clock.Frequency.Hz() == 0 {
+		return fmt.Errorf("invalid clock frequency %d", clock.Frequency)
+	}
+	d.mx.Lock()
+	defer d.mx.Unlock()
+	err := d.doGetSRAM(ctx)
+	if err != nil {
+		return fmt.Errorf("could not read GP parameters: %w", err)
+	}
+	var gp [4]byte
+	copy(gp[:], d.response[sramGetGP0:sramGetGP0+4])
+	gp[GP1] = byte(GPIO1ClockOutput)
+	err = d.doSetSRAM(ctx, func(request []byte) {
+		request[sramSetClock] = sramAlter | clock.encode()
+		request[sramSetGPIO] = sramAlter
+		copy(request[sramSetGP0:], gp[:])
+	})
+	if err != nil {
+		return fmt.Errorf("could not set clock output: %w", err)
+	}
+	return nil
+}
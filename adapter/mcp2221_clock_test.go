@@ -0,0 +1,38 @@
+package adapter
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClockFrequency(t *testing.T) {
+	assert.Equal(t, 24_000_000, Clock24MHz.Hz())
+	assert.Equal(t, 1_500_000, Clock1M5Hz.Hz())
+	assert.Equal(t, 375_000, Clock375kHz.Hz())
+	assert.Equal(t, 0, clockInvalid.Hz())
+
+	for _, value := range []string{"1.5MHz", "1.5mhz", "1.5M", " 375k "} {
+		_, err := ParseClockFrequency(value)
+		assert.NoError(t, err, value)
+	}
+	f, err := ParseClockFrequency("750kHz")
+	require.NoError(t, err)
+	assert.Equal(t, Clock750kHz, f)
+	_, err = ParseClockFrequency("1MHz")
+	assert.Error(t, err)
+}
+
+func TestClockOutput_EncodeDecode(t *testing.T) {
+	c := ClockOutput{Frequency: Clock12MHz, Duty: ClockDuty50}
+	assert.Equal(t, byte(0x12), c.encode())
+	assert.Equal(t, c, decodeClockOutput(0x12))
+	assert.Equal(t, "50%", c.Duty.String())
+
+	d, err := ParseClockDuty("75%")
+	require.NoError(t, err)
+	assert.Equal(t, ClockDuty75, d)
+	_, err = ParseClockDuty("30")
+	assert.Error(t, err)
+}
@@ -0,0 +1,101 @@
+package adapter
+
+import (
+	"context"
+	"fmt"
+	"math"
+)
+
+// DACMaxValue is the highest code accepted by the 5-bit DAC.
+const DACMaxValue = 0x1F
+
+// DACSetting describes the DAC output routed to GP2 (DAC1) and GP3 (DAC2)
+// when the pins are designated as DAC outputs. Both pins share one DAC.
+type DACSetting struct {
+	Reference VoltageReference `yaml:"reference"`
+	Value     byte             `yaml:"value"`
+}
+
+// Volts returns the output voltage given the supply voltage vdd.
+func (s DACSetting) Volts(vdd float64) float64 {
+	return DACVolts(s.Value, s.Reference, vdd)
+}
+
+// DACVolts returns the output voltage produced by code with the given
+// reference.
+func DACVolts(code byte, ref VoltageReference, vdd float64) float64 {
+	return float64(code&DACMaxValue) / (DACMaxValue + 1) * ref.Volts(vdd)
+}
+
+// DACCode returns the code producing the output closest to volts with the
+// given reference. Voltages outside the DAC range are rejected.
+func DACCode(volts float64, ref VoltageReference, vdd float64) (byte, error) {
+	full := ref.Volts(vdd)
+	step := full / (DACMaxValue + 1)
+	if volts < 0 || volts > full-step/2 {
+		return 0, fmt.Errorf("%.3fV out of DAC range 0-%.3fV with %s reference", volts, full-step, ref)
+	}
+	return byte(math.Round(volts / step)), nil
+}
+
+// ReadDAC returns the current DAC reference and output code from SRAM.
+func (d *MCP2221) ReadDAC(ctx context.Context) (DACSetting, error) {
+	d.mx.Lock()
+	defer d.mx.Unlock()
+	err := d.doGetSRAM(ctx)
+	if err != nil {
+		return DACSetting{}, fmt.Errorf("could not read DAC settings: %w", err)
+	}
+	return DACSetting{
+		Reference: voltageReferenceFromSRAM(d.response[sramGetDAC], 5),
+		Value:     d.response[sramGetDAC] & DACMaxValue,
+	}, nil
+}
+
+// SetDAC sets the raw 5-bit DAC output code in SRAM.
+func (d *MCP2221) SetDAC(ctx context.Context, value byte) error {
+	if value > DACMaxValue {
+		return fmt.Errorf("invalid DAC value %d, expected 0-%d", value, DACMaxValue)
+	}
+	d.mx.Lock()
+	defer d.mx.Unlock()
+	err := d.doSetSRAM(ctx, func(request []byte) {
+		request[sramSetDACValue] = sramAlter | value
+	})
+	if err != nil {
+		return fmt.Errorf("could not set DAC value: %w", err)
+	}
+	return nil
+}
+
+// SetDACReference selects the DAC voltage reference in SRAM.
+func (d *MCP2221) SetDACReference(ctx context.Context, ref VoltageReference) error {
+	d.mx.Lock()
+	defer d.mx.Unlock()
+	err := d.doSetSRAM(ctx, func(request []byte) {
+		request[sramSetDACRef] = sramAlter | byte(ref)
+	})
+	if err != nil {
+		return fmt.Errorf("could not set DAC reference: %w", err)
+	}
+	return nil
+}
+
+// SetDACVolts selects ref and sets the code closest to volts in a single
+// SRAM update. It returns the voltage actually produced.
+func (d *MCP2221) SetDACVolts(ctx context.Context, volts float64, ref VoltageReference, vdd float64) (float64, error) {
+	code, err := DACCode(volts, ref, vdd)
+	if err != nil {
+		return 0, err
+	}
+	d.mx.Lock()
+	defer d.mx.Unlock()
+	err = d.doSetSRAM(ctx, func(request []byte) {
+		request[sramSetDACRef] = sramAlter | byte(ref)
+		request[sramSetDACValue] = sramAlter | code
+	})
+	if err != nil {
+		return 0, fmt.Errorf("could not set DAC output: %w", err)
+	}
+	return DACVolts(code, ref, vdd), nil
+}
@@ -0,0 +1,31 @@
+package adapter
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDACCode(t *testing.T) {
+	code, err := DACCode(1.024, VoltageReference2V048, 5)
+	assert.NoError(t, err)
+	assert.Equal(t, byte(16), code)
+
+	code, err = DACCode(0, VoltageReferenceVDD, 3.3)
+	assert.NoError(t, err)
+	assert.Equal(t, byte(0), code)
+
+	code, err = DACCode(3.19, VoltageReferenceVDD, 3.3)
+	assert.NoError(t, err)
+	assert.Equal(t, byte(DACMaxValue), code)
+
+	_, err = DACCode(2.048, VoltageReference2V048, 5)
+	assert.Error(t, err, "full reference is not reachable by a 5-bit DAC")
+	_, err = DACCode(-0.1, VoltageReference2V048, 5)
+	assert.Error(t, err)
+}
+
+func TestDACVolts(t *testing.T) {
+	assert.InDelta(t, 2.048, DACVolts(16, VoltageReference4V096, 5), 1e-9)
+	assert.InDelta(t, 3.3*31/32, DACSetting{Reference: VoltageReferenceVDD, Value: 31}.Volts(3.3), 1e-9)
+}
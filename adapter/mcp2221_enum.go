@@ -0,0 +1,113 @@
+package adapter
+
+import (
+	"fmt"
+
+	"github.com/karalabe/hid"
+	"github.com/mklimuk/sensors"
+)
+
+var ErrDeviceNotFound = fmt.Errorf("device not found: %w", sensors.ErrDisconnected)
+
+// WithSerial selects the bridge with the given USB serial number when several
+// are attached.
+func WithSerial(serial string) MCP2221Option {
+	return func(o *MCP2221Options) {
+		o.Serial = serial
+	}
+}
+
+// WithPath selects the bridge at the given platform specific HID path, as
+// reported by EnumerateMCP2221.
+func WithPath(path string) MCP2221Option {
+	return func(o *MCP2221Options) {
+		o.Path = path
+	}
+}
+
+// WithDeviceIndex selects the n-th bridge among those matching the other
+// options, in enumeration order.
+func WithDeviceIndex(index int) MCP2221Option {
+	return func(o *MCP2221Options) {
+		o.DeviceIndex = index
+	}
+}
+
+// MCP2221Info describes an attached bridge.
+type MCP2221Info struct {
+	Path         string `json:"path" yaml:"path"`
+	Serial       string `json:"serial" yaml:"serial"`
+	Manufacturer string `json:"manufacturer" yaml:"manufacturer"`
+	Product      string `json:"product" yaml:"product"`
+	VendorID     uint16 `json:"vendor_id" yaml:"vendor_id"`
+	ProductID    uint16 `json:"product_id" yaml:"product_id"`
+}
+
+// EnumerateMCP2221 lists the attached bridges matching the vendor and product
+// ids of the options (the defaults unless overridden).
+func EnumerateMCP2221(opts ...MCP2221Option) []MCP2221Info {
+	options := MCP2221Options{
+		VendorID:  VendorID,
+		ProductID: ProductID,
+	}
+	for _, opt := range opts {
+		opt(&options)
+	}
+	devices := options.enumerate()
+	res := make([]MCP2221Info, 0, len(devices))
+	for _, dev := range devices {
+		res = append(res, MCP2221Info{
+			Path:         dev.Path,
+			Serial:       dev.Serial,
+			Manufacturer: dev.Manufacturer,
+			Product:      dev.Product,
+			VendorID:     dev.VendorID,
+			ProductID:    dev.ProductID,
+		})
+	}
+	return res
+}
+
+// selectDevice picks the device matching the path and serial options, if
+// set, and then the one at DeviceIndex among the remaining ones.
+func (o MCP2221Options) selectDevice(devices []hid.DeviceInfo) (hid.DeviceInfo, error) {
+	dev, matching, ok := selectHIDDevice(devices, o.Path, o.Serial, o.DeviceIndex)
+	if !ok {
+		return hid.DeviceInfo{}, fmt.Errorf("%w: %s (%d matching)", ErrDeviceNotFound, o.describe(), matching)
+	}
+	return dev, nil
+}
+
+// selectHIDDevice picks the device with the given path and serial, when not
+// empty, and then the one at index among the remaining ones. It also returns
+// the number of devices matching path and serial.
+func selectHIDDevice(devices []hid.DeviceInfo, path string, serial string, index int) (hid.DeviceInfo, int, bool) {
+	matching := make([]hid.DeviceInfo, 0, len(devices))
+	for _, dev := range devices {
+		if path != "" && dev.Path != path {
+			continue
+		}
+		if serial != "" && dev.Serial != serial {
+			continue
+		}
+		matching = append(matching, dev)
+	}
+	if index < 0 || index >= len(matching) {
+		return hid.DeviceInfo{}, len(matching), false
+	}
+	return matching[index], len(matching), true
+}
+
+func (o MCP2221Options) describe() string {
+	desc := fmt.Sprintf("vendor: %#x product: %#x", o.VendorID, o.ProductID)
+	if o.Serial != "" {
+		desc += fmt.Sprintf(" serial: %q", o.Serial)
+	}
+	if o.Path != "" {
+		desc += fmt.Sprintf(" path: %q", o.Path)
+	}
+	if o.DeviceIndex != 0 {
+		desc += fmt.Sprintf(" index: %d", o.DeviceIndex)
+	}
+	return desc
+}
@@ -0,0 +1,44 @@
+package adapter
+
+import (
+	"testing"
+
+	"github.com/karalabe/hid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSelectDevice(t *testing.T) {
+	devices := []hid.DeviceInfo{
+		{Path: "1-1:1.2", Serial: "0001"},
+		{Path: "1-2:1.2", Serial: "0002"},
+		{Path: "1-3:1.2", Serial: "0003"},
+	}
+
+	dev, err := MCP2221Options{}.selectDevice(devices)
+	require.NoError(t, err)
+	assert.Equal(t, "0001", dev.Serial)
+
+	dev, err = MCP2221Options{DeviceIndex: 2}.selectDevice(devices)
+	require.NoError(t, err)
+	assert.Equal(t, "0003", dev.Serial)
+
+	dev, err = MCP2221Options{Serial: "0002"}.selectDevice(devices)
+	require.NoError(t, err)
+	assert.Equal(t, "1-2:1.2", dev.Path)
+
+	dev, err = MCP2221Options{Path: "1-3:1.2"}.selectDevice(devices)
+	require.NoError(t, err)
+	assert.Equal(t, "0003", dev.Serial)
+
+	_, err = MCP2221Options{DeviceIndex: 3}.selectDevice(devices)
+	assert.ErrorIs(t, err, ErrDeviceNotFound)
+	_, err = MCP2221Options{DeviceIndex: -1}.selectDevice(devices)
+	assert.ErrorIs(t, err, ErrDeviceNotFound)
+	_, err = MCP2221Options{Serial: "0002", DeviceIndex: 1}.selectDevice(devices)
+	assert.ErrorIs(t, err, ErrDeviceNotFound)
+	_, err = MCP2221Options{Serial: "0004"}.selectDevice(devices)
+	assert.ErrorIs(t, err, ErrDeviceNotFound)
+	_, err = MCP2221Options{}.selectDevice(nil)
+	assert.ErrorIs(t, err, ErrDeviceNotFound)
+}
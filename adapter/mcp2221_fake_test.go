@@ -0,0 +1,670 @@
+package adapter
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"sync"
+	"unicode/utf16"
+
+	"github.com/karalabe/hid"
+	"github.com/mklimuk/sensors"
+)
+
+var _ HIDTransport = &fakeMCP2221{}
+
+// fakeStateReadData is the engine state of the fake while read data waits to
+// be collected with Get I2C Data.
+const fakeStateReadData = 0x55
+
+// fakeDefaultDivider is the 100kHz divider the chip uses after power-up.
+const fakeDefaultDivider = 0x75
+
+var errFakeGone = errors.New("fake mcp2221: device disconnected")
+
+// fakeMCP2221 is an in-process MCP2221 for tests. It implements HIDTransport,
+// so that it can be used with WithTransport, and answers the commands sent
+// by the adapter: status, cancel and I2C speed (0x10), I2C transfers (0x90
+// to 0x94 and 0x40), GPIO values (0x50, 0x51), SRAM settings (0x60, 0x61),
+// flash data (0xB0, 0xB1), password (0xB2) and reset (0x70). I2C transfers
+// are forwarded to the attached bus, typically a sim.Bus.
+//
+// Faults can be injected with SetBusy, ShortReads and Unplug.
+type fakeMCP2221 struct {
+	mx        sync.Mutex
+	bus       sensors.I2CBus
+	info      hid.DeviceInfo
+	unplugged bool
+	opened    *fakeHIDHandle
+	opens     int
+	response  []byte
+	// injected faults
+	busy       int
+	shortReads int
+	sdaHold    int
+	// chip settings in the flash chip settings layout, as stored and as
+	// currently used
+	flash   [10]byte
+	flashGP [4]byte
+	sram    [10]byte
+	gp      [4]byte
+	inputs  [4]byte
+	adc     [3]uint16
+	strings map[byte][]byte
+	// password is the flash password, unlocked is set once it was sent
+	password  [passwordLength]byte
+	unlocked  bool
+	attempts  int
+	interrupt bool
+	divider   byte
+	resets    int
+	cancels   int
+	// I2C engine
+	state       byte
+	nack        bool
+	address     byte
+	requested   uint16
+	transferred uint16
+	writing     bool
+	pending     []byte
+	noStop      []byte
+	readData    []byte
+}
+
+// newFakeMCP2221 returns a powered up fake forwarding I2C transfers to bus.
+// Its flash holds the factory defaults: all pins are GPIO inputs.
+func newFakeMCP2221(bus sensors.I2CBus) *fakeMCP2221 {
+	f := &fakeMCP2221{
+		bus: bus,
+		info: hid.DeviceInfo{
+			Path:         "fake",
+			VendorID:     VendorID,
+			ProductID:    ProductID,
+			Serial:       "0001234567",
+			Manufacturer: "Microchip Technology Inc.",
+			Product:      "MCP2221 USB-I2C/UART Combo",
+		},
+		flash:   [10]byte{0x00, 0x12, 0x00, 0x00, 0, 0, 0, 0, 0x80, 50},
+		flashGP: [4]byte{0x08, 0x08, 0x08, 0x08},
+		strings: map[byte][]byte{
+			flashManufacturer: fakeUSBString("Microchip Technology Inc."),
+			flashProduct:      fakeUSBString("MCP2221 USB-I2C/UART Combo"),
+			flashSerial:       fakeUSBString("0001234567"),
+		},
+	}
+	binary.LittleEndian.PutUint16(f.flash[4:6], VendorID)
+	binary.LittleEndian.PutUint16(f.flash[6:8], ProductID)
+	f.powerUp()
+	return f
+}
+
+func fakeUSBString(value string) []byte {
+	units := utf16.Encode([]rune(value))
+	res := []byte{byte(len(units)*2 + 2), usbStringDescriptor}
+	for _, u := range units {
+		res = append(res, byte(u), byte(u>>8))
+	}
+	return res
+}
+
+// Info returns the HID device information reported by Enumerate.
+func (f *fakeMCP2221) Info() hid.DeviceInfo {
+	f.mx.Lock()
+	defer f.mx.Unlock()
+	return f.info
+}
+
+func (f *fakeMCP2221) Enumerate(vendorID uint16, productID uint16) []hid.DeviceInfo {
+	f.mx.Lock()
+	defer f.mx.Unlock()
+	if f.unplugged || (vendorID != 0 && vendorID != f.info.VendorID) || (productID != 0 && productID != f.info.ProductID) {
+		return nil
+	}
+	return []hid.DeviceInfo{f.info}
+}
+
+func (f *fakeMCP2221) Open(info hid.DeviceInfo) (HIDDevice, error) {
+	f.mx.Lock()
+	defer f.mx.Unlock()
+	if f.unplugged || info.Path != f.info.Path {
+		return nil, fmt.Errorf("fake mcp2221: no device at %q", info.Path)
+	}
+	f.opens++
+	f.opened = &fakeHIDHandle{fake: f}
+	return f.opened, nil
+}
+
+// Opens returns the number of times the device was opened.
+func (f *fakeMCP2221) Opens() int {
+	f.mx.Lock()
+	defer f.mx.Unlock()
+	return f.opens
+}
+
+// Cancels returns the number of I2C transfer cancellations received.
+func (f *fakeMCP2221) Cancels() int {
+	f.mx.Lock()
+	defer f.mx.Unlock()
+	return f.cancels
+}
+
+// Resets returns the number of reset commands received.
+func (f *fakeMCP2221) Resets() int {
+	f.mx.Lock()
+	defer f.mx.Unlock()
+	return f.resets
+}
+
+// Divider returns the I2C speed divider in use.
+func (f *fakeMCP2221) Divider() byte {
+	f.mx.Lock()
+	defer f.mx.Unlock()
+	return f.divider
+}
+
+// SetBusy makes the next n I2C transfer requests fail as if the engine was
+// busy.
+func (f *fakeMCP2221) SetBusy(n int) {
+	f.mx.Lock()
+	defer f.mx.Unlock()
+	f.busy = n
+}
+
+// HoldSDA makes a stuck slave hold SDA low until n transfer cancellations
+// or resets were received, or for good when n is negative. Transfers are
+// refused as busy meanwhile.
+func (f *fakeMCP2221) HoldSDA(n int) {
+	f.mx.Lock()
+	defer f.mx.Unlock()
+	f.sdaHold = n
+}
+
+// ShortReads makes the next n report reads return a truncated report.
+func (f *fakeMCP2221) ShortReads(n int) {
+	f.mx.Lock()
+	defer f.mx.Unlock()
+	f.shortReads = n
+}
+
+// Unplug disconnects the device: the open handle fails and the device is no
+// longer enumerated.
+func (f *fakeMCP2221) Unplug() {
+	f.mx.Lock()
+	defer f.mx.Unlock()
+	f.unplugged = true
+	f.disconnect()
+}
+
+// Plug connects the device again. Like the real chip it powers up with the
+// settings stored in flash.
+func (f *fakeMCP2221) Plug() {
+	f.mx.Lock()
+	defer f.mx.Unlock()
+	f.unplugged = false
+	f.powerUp()
+}
+
+// SetInput sets the level read from pin when it is a GPIO input.
+func (f *fakeMCP2221) SetInput(pin GPIOPin, level byte) {
+	f.mx.Lock()
+	defer f.mx.Unlock()
+	f.inputs[pin] = level & 0x01
+}
+
+// Output returns the level driven on pin and whether the pin is a GPIO
+// output.
+func (f *fakeMCP2221) Output(pin GPIOPin) (byte, bool) {
+	f.mx.Lock()
+	defer f.mx.Unlock()
+	setting := f.gp[pin]
+	return setting & gpioOutputValueMask >> 4, setting&gpioOperationMask == 0 && setting&gpioModeMask == 0
+}
+
+// SetADC sets the value reported for an ADC channel.
+func (f *fakeMCP2221) SetADC(ch ADCChannel, value uint16) {
+	f.mx.Lock()
+	defer f.mx.Unlock()
+	f.adc[ch-ADC1] = value & ADCMaxValue
+}
+
+// TriggerInterrupt latches the interrupt flag if the detector is enabled.
+func (f *fakeMCP2221) TriggerInterrupt() {
+	f.mx.Lock()
+	defer f.mx.Unlock()
+	if f.sram[3]&(sramGetInterruptPositive|sramGetInterruptNegative) != 0 {
+		f.interrupt = true
+	}
+}
+
+// powerUp loads the flash settings and resets the volatile state.
+func (f *fakeMCP2221) powerUp() {
+	f.sram = f.flash
+	f.gp = f.flashGP
+	f.divider = fakeDefaultDivider
+	f.unlocked = false
+	f.attempts = 0
+	f.interrupt = false
+	f.cancel()
+}
+
+// disconnect makes the open handle fail, as after a re-enumeration.
+func (f *fakeMCP2221) disconnect() {
+	if f.opened != nil {
+		f.opened.gone = true
+		f.opened = nil
+	}
+	f.response = nil
+}
+
+// cancel resets the I2C engine.
+func (f *fakeMCP2221) cancel() {
+	f.state = i2cStateIdle
+	f.nack = false
+	f.writing = false
+	f.pending = nil
+	f.noStop = nil
+	f.readData = nil
+}
+
+// releaseSDA counts an operation towards the release of SDA.
+func (f *fakeMCP2221) releaseSDA() {
+	if f.sdaHold > 0 {
+		f.sdaHold--
+	}
+}
+
+// handle executes a request and returns the response, or nil for commands
+// which do not answer.
+func (f *fakeMCP2221) handle(request []byte) []byte {
+	response := make([]byte, 64)
+	response[0] = request[0]
+	switch request[0] {
+	case 0x10:
+		f.status(request, response)
+	case cmdI2CWrite, cmdI2CWriteRepeatStart, cmdI2CWriteNoStop:
+		f.write(request, response)
+	case cmdI2CRead, cmdI2CReadRepeatStart:
+		f.read(request, response)
+	case cmdI2CGetData:
+		f.getData(response)
+	case cmdSetGPIOValues:
+		f.setGPIOValues(request, response)
+	case cmdGetGPIOValues:
+		f.getGPIOValues(response)
+	case cmdSetSRAMSettings:
+		f.setSRAM(request)
+	case cmdGetSRAMSettings:
+		copy(response[sramGetChip:], f.sram[:])
+		copy(response[sramGetGP0:], f.gp[:])
+	case cmdReadFlash:
+		f.readFlash(request, response)
+	case cmdWriteFlash:
+		f.writeFlash(request, response)
+	case cmdSendPassword:
+		f.sendPassword(request, response)
+	case 0x70:
+		if bytes.Equal(request[1:4], []byte{0xAB, 0xCD, 0xEF}) {
+			f.resets++
+			f.releaseSDA()
+			f.powerUp()
+			f.disconnect()
+		}
+		return nil
+	default:
+		response[1] = 0x01
+	}
+	return response
+}
+
+func (f *fakeMCP2221) status(request, response []byte) {
+	if request[2] == 0x10 {
+		f.cancels++
+		f.cancel()
+		f.releaseSDA()
+		response[2] = 0x10
+	}
+	if request[statusSetSpeedOffset] == statusSetSpeed {
+		response[statusSetSpeedOffset] = statusSetSpeed
+		if f.writing || f.readData != nil {
+			response[statusSetSpeedOffset] = statusSpeedNotSet
+		} else {
+			f.divider = request[statusSetSpeedOffset+1]
+		}
+	}
+	response[statusI2CState] = f.state
+	binary.LittleEndian.PutUint16(response[9:11], f.requested)
+	binary.LittleEndian.PutUint16(response[11:13], f.transferred)
+	response[14] = f.divider
+	response[16] = f.address << 1
+	if f.nack {
+		response[statusI2CACK] = statusNACKMask
+	}
+	response[statusSCL] = 1
+	if f.sdaHold == 0 {
+		response[statusSDA] = 1
+	}
+	if f.interrupt {
+		response[statusInterrupt] = 1
+	}
+	copy(response[statusHardwareRevision:], "A6")
+	copy(response[statusFirmwareRevision:], "12")
+	for i, v := range f.adc {
+		binary.LittleEndian.PutUint16(response[statusADC+2*i:], v)
+	}
+}
+
+// refuseBusy reports a busy engine when a fault was injected or SDA is held
+// low.
+func (f *fakeMCP2221) refuseBusy(response []byte) bool {
+	if f.busy == 0 && f.sdaHold == 0 {
+		return false
+	}
+	if f.busy > 0 {
+		f.busy--
+	}
+	response[1] = 0x01
+	response[2] = f.state
+	return true
+}
+
+func (f *fakeMCP2221) write(request, response []byte) {
+	if f.refuseBusy(response) {
+		return
+	}
+	length := binary.LittleEndian.Uint16(request[1:3])
+	if !f.writing {
+		f.cancel()
+		f.writing = true
+		f.address = request[3] >> 1
+		f.requested = length
+		f.transferred = 0
+	}
+	chunk := min(int(length)-len(f.pending), i2cChunkSize)
+	f.pending = append(f.pending, request[4:4+chunk]...)
+	f.transferred = uint16(len(f.pending))
+	if len(f.pending) < int(length) {
+		f.state = 0x40
+		return
+	}
+	data := f.pending
+	f.pending = nil
+	f.writing = false
+	if request[0] == cmdI2CWriteNoStop {
+		f.noStop = data
+		f.state = i2cStateWritingNoStop
+		return
+	}
+	err := f.bus.WriteToAddr(context.Background(), f.address, data)
+	f.done(err)
+}
+
+func (f *fakeMCP2221) read(request, response []byte) {
+	if f.refuseBusy(response) {
+		return
+	}
+	length := binary.LittleEndian.Uint16(request[1:3])
+	address := request[3] >> 1
+	w := f.noStop
+	f.cancel()
+	f.address = address
+	f.requested = length
+	f.transferred = 0
+	buffer := make([]byte, length)
+	ctx := context.Background()
+	var err error
+	switch tx, ok := f.bus.(sensors.I2CTransactor); {
+	case w != nil && ok && request[0] == cmdI2CReadRepeatStart:
+		err = tx.Tx(ctx, address, w, buffer)
+	case w != nil:
+		err = f.bus.WriteToAddr(ctx, address, w)
+		if err == nil {
+			err = f.bus.ReadFromAddr(ctx, address, buffer)
+		}
+	default:
+		err = f.bus.ReadFromAddr(ctx, address, buffer)
+	}
+	f.done(err)
+	if err == nil {
+		f.readData = buffer
+		f.state = fakeStateReadData
+	}
+}
+
+// done updates the engine after a transfer on the bus.
+func (f *fakeMCP2221) done(err error) {
+	f.state = i2cStateIdle
+	if err != nil {
+		f.nack = true
+		f.state = i2cStateAddrNACK
+	}
+}
+
+func (f *fakeMCP2221) getData(response []byte) {
+	if f.nack {
+		response[1] = i2cStatePartialData
+		response[2] = i2cStateAddrNACK
+		return
+	}
+	if f.readData == nil {
+		response[1] = i2cStatePartialData
+		response[3] = i2cReadNotReady
+		return
+	}
+	n := min(len(f.readData), i2cChunkSize)
+	response[2] = f.state
+	response[3] = byte(n)
+	copy(response[4:], f.readData[:n])
+	f.readData = f.readData[n:]
+	f.transferred += uint16(n)
+	if len(f.readData) == 0 {
+		f.readData = nil
+		f.state = i2cStateIdle
+	}
+}
+
+func (f *fakeMCP2221) setGPIOValues(request, response []byte) {
+	for pin := range f.gp {
+		gpio := f.gp[pin]&gpioOperationMask == 0
+		for _, field := range []struct {
+			offset int
+			mask   byte
+		}{{gpioSetValue, gpioOutputValueMask}, {gpioSetDirection, gpioModeMask}} {
+			i := field.offset + 4*pin
+			if request[i] != 0x01 {
+				continue
+			}
+			if !gpio {
+				response[i+1] = gpioNotDesignated
+				continue
+			}
+			response[i+1] = request[i+1]
+			f.gp[pin] &^= field.mask
+			if request[i+1] != 0 {
+				f.gp[pin] |= field.mask
+			}
+		}
+	}
+}
+
+func (f *fakeMCP2221) getGPIOValues(response []byte) {
+	for pin, setting := range f.gp {
+		if setting&gpioOperationMask != 0 {
+			response[2+2*pin] = gpioNotDesignated
+			response[3+2*pin] = gpioNotDesignated
+			continue
+		}
+		direction := setting & gpioModeMask >> 3
+		value := setting & gpioOutputValueMask >> 4
+		if direction == 1 {
+			value = f.inputs[pin]
+		}
+		response[2+2*pin] = value
+		response[3+2*pin] = direction
+	}
+}
+
+func (f *fakeMCP2221) setSRAM(request []byte) {
+	if v := request[sramSetClock]; v&sramAlter != 0 {
+		f.sram[1] = v & 0x1F
+	}
+	if v := request[sramSetDACRef]; v&sramAlter != 0 {
+		f.sram[2] = f.sram[2]&DACMaxValue | (v&0x07)<<5
+	}
+	if v := request[sramSetDACValue]; v&sramAlter != 0 {
+		f.sram[2] = f.sram[2]&^DACMaxValue | v&DACMaxValue
+	}
+	if v := request[sramSetADCRef]; v&sramAlter != 0 {
+		f.sram[3] = f.sram[3]&^0x1C | (v&0x07)<<2
+	}
+	if v := request[sramSetInterrupt]; v&sramAlter != 0 {
+		if v&sramInterruptAlterPositive != 0 {
+			f.sram[3] &^= sramGetInterruptPositive
+			if v&sramInterruptPositive != 0 {
+				f.sram[3] |= sramGetInterruptPositive
+			}
+		}
+		if v&sramInterruptAlterNegative != 0 {
+			f.sram[3] &^= sramGetInterruptNegative
+			if v&sramInterruptNegative != 0 {
+				f.sram[3] |= sramGetInterruptNegative
+			}
+		}
+		if v&sramInterruptClear != 0 {
+			f.interrupt = false
+		}
+	}
+	if request[sramSetGPIO]&sramAlter != 0 {
+		copy(f.gp[:], request[sramSetGP0:sramSetGP0+4])
+	} else {
+		// the chip reverts the pins to their power-up configuration
+		f.gp = f.flashGP
+	}
+}
+
+func (f *fakeMCP2221) readFlash(request, response []byte) {
+	switch sub := request[1]; sub {
+	case flashChipSettings:
+		response[2] = byte(len(f.flash))
+		copy(response[4:], f.flash[:])
+	case flashGPSettings:
+		response[2] = byte(len(f.flashGP))
+		copy(response[4:], f.flashGP[:])
+	case flashManufacturer, flashProduct, flashSerial:
+		copy(response[2:], f.strings[sub])
+	case flashFactorySerial:
+		response[2] = byte(len(f.info.Serial))
+		copy(response[4:], f.info.Serial)
+	default:
+		response[1] = 0x01
+	}
+}
+
+func (f *fakeMCP2221) writeFlash(request, response []byte) {
+	switch ChipSecurity(f.flash[0] & 0x03) {
+	case ChipUnsecured:
+	case ChipPasswordProtected:
+		if !f.unlocked {
+			response[1] = flashNotAllowed
+			return
+		}
+	default:
+		response[1] = flashNotAllowed
+		return
+	}
+	switch sub := request[1]; sub {
+	case flashChipSettings:
+		copy(f.flash[:], request[2:12])
+		if ChipSecurity(f.flash[0]&0x03) == ChipPasswordProtected {
+			copy(f.password[:], request[12:12+passwordLength])
+		}
+	case flashGPSettings:
+		copy(f.flashGP[:], request[2:6])
+	case flashManufacturer, flashProduct, flashSerial:
+		size := int(request[2])
+		if size < 2 || size > 2+2*USBStringMaxLength {
+			response[1] = 0x01
+			return
+		}
+		f.strings[sub] = bytes.Clone(request[2 : 2+size])
+	default:
+		response[1] = 0x01
+	}
+}
+
+func (f *fakeMCP2221) sendPassword(request, response []byte) {
+	if f.attempts >= 3 {
+		response[1] = 0x03
+		return
+	}
+	if !bytes.Equal(request[2:2+passwordLength], f.password[:]) {
+		f.attempts++
+		response[1] = 0x01
+		return
+	}
+	f.unlocked = true
+}
+
+// fakeHIDHandle is an opened fakeMCP2221.
+type fakeHIDHandle struct {
+	fake   *fakeMCP2221
+	closed bool
+	gone   bool
+}
+
+func (h *fakeHIDHandle) Write(report []byte) (int, error) {
+	f := h.fake
+	f.mx.Lock()
+	defer f.mx.Unlock()
+	if err := h.check(); err != nil {
+		return 0, err
+	}
+	request := make([]byte, 64)
+	copy(request, report)
+	f.response = f.handle(request)
+	return len(report), nil
+}
+
+func (h *fakeHIDHandle) Read(report []byte) (int, error) {
+	f := h.fake
+	f.mx.Lock()
+	defer f.mx.Unlock()
+	if err := h.check(); err != nil {
+		return 0, err
+	}
+	if f.response == nil {
+		return 0, errors.New("fake mcp2221: no pending response")
+	}
+	n := copy(report, f.response)
+	f.response = nil
+	if f.shortReads > 0 {
+		f.shortReads--
+		return n / 2, nil
+	}
+	return n, nil
+}
+
+func (h *fakeHIDHandle) Close() error {
+	f := h.fake
+	f.mx.Lock()
+	defer f.mx.Unlock()
+	if h.closed {
+		return errors.New("fake mcp2221: already closed")
+	}
+	h.closed = true
+	if f.opened == h {
+		f.opened = nil
+	}
+	return nil
+}
+
+func (h *fakeHIDHandle) check() error {
+	if h.closed {
+		return errors.New("fake mcp2221: handle closed")
+	}
+	if h.gone {
+		return errFakeGone
+	}
+	return nil
+}
@@ -0,0 +1,278 @@
+package adapter
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+// Flash data commands. Flash settings define the power-up configuration of
+// the chip and have limited write endurance.
+const (
+	cmdReadFlash  = 0xB0
+	cmdWriteFlash = 0xB1
+)
+
+// Flash data subcommands
+const (
+	flashChipSettings  = 0x00
+	flashGPSettings    = 0x01
+	flashManufacturer  = 0x02
+	flashProduct       = 0x03
+	flashSerial        = 0x04
+	flashFactorySerial = 0x05
+)
+
+// flashNotAllowed is the status of flash writes refused because of the chip
+// security settings.
+const flashNotAllowed = 0x03
+
+var ErrChipProtected = errors.New("chip settings are protected")
+
+// ChipSecurity is the access control applied to the flash settings.
+type ChipSecurity byte
+
+const (
+	ChipUnsecured         ChipSecurity = 0b00
+	ChipPasswordProtected ChipSecurity = 0b01
+	ChipPermanentlyLocked ChipSecurity = 0b10
+)
+
+func (s ChipSecurity) String() string {
+	switch s {
+	case ChipUnsecured:
+		return "unsecured"
+	case ChipPasswordProtected:
+		return "password protected"
+	default:
+		return "permanently locked"
+	}
+}
+
+func (s ChipSecurity) MarshalYAML() (interface{}, error) {
+	return s.String(), nil
+}
+
+// USBID is a USB vendor or product id.
+type USBID uint16
+
+func (id USBID) String() string {
+	return fmt.Sprintf("%#04x", uint16(id))
+}
+
+func (id USBID) MarshalYAML() (interface{}, error) {
+	return id.String(), nil
+}
+
+// ChipSettings are the power-up chip settings stored in flash.
+type ChipSettings struct {
+	CDCSerialEnumeration  bool             `yaml:"cdc_serial_enumeration"`
+	Security              ChipSecurity     `yaml:"security"`
+	ClockDivider          byte             `yaml:"clock_divider"`
+	DACReference          VoltageReference `yaml:"dac_reference"`
+	DACValue              byte             `yaml:"dac_value"`
+	ADCReference          VoltageReference `yaml:"adc_reference"`
+	InterruptPositiveEdge bool             `yaml:"interrupt_positive_edge"`
+	InterruptNegativeEdge bool             `yaml:"interrupt_negative_edge"`
+	VendorID              USBID            `yaml:"vendor_id"`
+	ProductID             USBID            `yaml:"product_id"`
+	PowerAttributes       byte             `yaml:"power_attributes"`
+	RequestedMilliAmps    int              `yaml:"requested_ma"`
+	// initial pin function values (bits 6:2 of the first settings byte),
+	// carried over unchanged on write
+	pinDefaults byte
+	// raw reference codes as read, which keep the Vrm level selected while
+	// the reference is VDD
+	dacRef, adcRef byte
+}
+
+// decodeChipSettings decodes the chip settings of a Read Flash Data
+// response, starting at byte 4.
+func decodeChipSettings(src []byte) ChipSettings {
+	return ChipSettings{
+		CDCSerialEnumeration:  src[0]&0x80 != 0,
+		Security:              ChipSecurity(src[0] & 0x03),
+		pinDefaults:           src[0] & 0x7C,
+		dacRef:                src[2] >> 5 & 0x07,
+		adcRef:                src[3] >> 2 & 0x07,
+		ClockDivider:          src[1] & 0x1F,
+		DACReference:          voltageReferenceFromSRAM(src[2], 5),
+		DACValue:              src[2] & DACMaxValue,
+		ADCReference:          voltageReferenceFromSRAM(src[3], 2),
+		InterruptPositiveEdge: src[3]&0x40 != 0,
+		InterruptNegativeEdge: src[3]&0x20 != 0,
+		VendorID:              USBID(binary.LittleEndian.Uint16(src[4:6])),
+		ProductID:             USBID(binary.LittleEndian.Uint16(src[6:8])),
+		PowerAttributes:       src[8],
+		RequestedMilliAmps:    int(src[9]) * 2,
+	}
+}
+
+// encode writes the settings in the Write Flash Data layout, starting at
+// byte 2 of the request.
+func (s ChipSettings) encode(dst []byte) {
+	dst[0] = s.pinDefaults | byte(s.Security)&0x03
+	if s.CDCSerialEnumeration {
+		dst[0] |= 0x80
+	}
+	dst[1] = s.ClockDivider & 0x1F
+	dst[2] = rawReference(s.DACReference, s.dacRef)<<5 | s.DACValue&DACMaxValue
+	dst[3] = rawReference(s.ADCReference, s.adcRef) << 2
+	if s.InterruptPositiveEdge {
+		dst[3] |= 0x40
+	}
+	if s.InterruptNegativeEdge {
+		dst[3] |= 0x20
+	}
+	binary.LittleEndian.PutUint16(dst[4:6], uint16(s.VendorID))
+	binary.LittleEndian.PutUint16(dst[6:8], uint16(s.ProductID))
+	dst[8] = s.PowerAttributes
+	dst[9] = byte(s.RequestedMilliAmps / 2)
+}
+
+// rawReference returns the code read from flash when ref has not been
+// changed, and the code of ref otherwise.
+func rawReference(ref VoltageReference, raw byte) byte {
+	if voltageReferenceFromSRAM(raw, 0) == ref {
+		return raw
+	}
+	return byte(ref)
+}
+
+// ReadChipSettings returns the power-up chip settings stored in flash.
+func (d *MCP2221) ReadChipSettings(ctx context.Context) (ChipSettings, error) {
+	d.mx.Lock()
+	defer d.mx.Unlock()
+	err := d.doReadFlash(ctx, flashChipSettings)
+	if err != nil {
+		return ChipSettings{}, fmt.Errorf("could not read chip settings: %w", err)
+	}
+	return decodeChipSettings(d.response[4:14]), nil
+}
+
+// WriteChipSettings reads the chip settings from flash, applies update and
+// writes them back, so that only the fields changed by update are altered.
+// The chip security cannot be changed this way, see SetPassword,
+// RemovePassword and LockPermanently. Settings of a password protected chip
+// can be written only after SendPassword.
+func (d *MCP2221) WriteChipSettings(ctx context.Context, update func(s *ChipSettings)) error {
+	d.mx.Lock()
+	defer d.mx.Unlock()
+	return d.doWriteChipSettings(ctx, update, nil, false)
+}
+
+// UpdateVendorAndProductID changes the USB vendor and product ids the chip
+// enumerates with after the next reset. vendor and product are big endian,
+// as decoded from their hex representation. With dryrun set the request is
+// printed instead of being sent.
+func (d *MCP2221) UpdateVendorAndProductID(ctx context.Context, vendor, product []byte, dryrun ...bool) error {
+	for len(vendor) < 2 {
+		vendor = append(vendor, 0x00)
+	}
+	for len(product) < 2 {
+		product = append(product, 0x00)
+	}
+	d.mx.Lock()
+	defer d.mx.Unlock()
+	return d.doWriteChipSettings(ctx, func(s *ChipSettings) {
+		s.VendorID = USBID(binary.BigEndian.Uint16(vendor))
+		s.ProductID = USBID(binary.BigEndian.Uint16(product))
+	}, nil, len(dryrun) > 0 && dryrun[0])
+}
+
+// securityChange describes a change of the chip security requested along
+// with a chip settings write.
+type securityChange struct {
+	security ChipSecurity
+	// password is the new password when security is ChipPasswordProtected
+	password []byte
+}
+
+// doWriteChipSettings performs the read-modify-write of the chip settings,
+// applying update (if not nil) and then change (if not nil). The write
+// carries a password whenever the resulting security is password protection:
+// the new one or else the one sent last, which then stays unchanged. Callers
+// must hold d.mx.
+func (d *MCP2221) doWriteChipSettings(ctx context.Context, update func(s *ChipSettings), change *securityChange, dryrun bool) error {
+	err := d.doReadFlash(ctx, flashChipSettings)
+	if err != nil {
+		return fmt.Errorf("could not read chip settings: %w", err)
+	}
+	current := decodeChipSettings(d.response[4:14])
+	password := d.password
+	switch {
+	case current.Security >= ChipPermanentlyLocked:
+		return fmt.Errorf("%w: %s", ErrChipProtected, current.Security)
+	case current.Security == ChipPasswordProtected && password == nil:
+		return fmt.Errorf("%w: %s, send the password first", ErrChipProtected, current.Security)
+	}
+	settings := current
+	if update != nil {
+		update(&settings)
+	}
+	if settings.Security != current.Security {
+		return fmt.Errorf("changing chip security from %s to %s is not supported", current.Security, settings.Security)
+	}
+	if change != nil {
+		settings.Security = change.security
+		if change.password != nil {
+			password = change.password
+		}
+	}
+	d.resetBuffers()
+	d.request[0] = cmdWriteFlash
+	d.request[1] = flashChipSettings
+	settings.encode(d.request[2:12])
+	if settings.Security == ChipPasswordProtected {
+		copy(d.request[12:12+passwordLength], password)
+	}
+	if dryrun {
+		dump("sent chip settings:", d.request[:12])
+		return nil
+	}
+	err = d.exchange(ctx)
+	if err != nil {
+		return fmt.Errorf("write chip settings failed: %w", err)
+	}
+	err = flashWriteStatus(d.response[1])
+	if err != nil {
+		return err
+	}
+	if change != nil {
+		d.password = nil
+		if change.security == ChipPasswordProtected {
+			d.password = password
+		}
+	}
+	return nil
+}
+
+// flashWriteStatus maps the status byte of a Write Flash Data response to an
+// error.
+func flashWriteStatus(status byte) error {
+	switch status {
+	case 0x00:
+		return nil
+	case flashNotAllowed:
+		return ErrChipProtected
+	default:
+		return ErrCommandFailed
+	}
+}
+
+// doReadFlash reads the flash section selected by sub into d.response.
+// Callers must hold d.mx.
+func (d *MCP2221) doReadFlash(ctx context.Context, sub byte) error {
+	d.resetBuffers()
+	d.request[0] = cmdReadFlash
+	d.request[1] = sub
+	err := d.exchange(ctx)
+	if err != nil {
+		return fmt.Errorf("read flash data failed: %w", err)
+	}
+	if d.response[1] != 0x00 {
+		return ErrCommandFailed
+	}
+	return nil
+}
@@ -0,0 +1,48 @@
+package adapter
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestChipSettings_DecodeEncode(t *testing.T) {
+	// factory defaults as read from flash
+	raw := []byte{0x10, 0x12, 0xC8, 0x7C, 0xD8, 0x04, 0xDD, 0x00, 0x80, 0x32}
+	s := decodeChipSettings(raw)
+	assert.False(t, s.CDCSerialEnumeration)
+	assert.Equal(t, ChipUnsecured, s.Security)
+	assert.Equal(t, byte(0x12), s.ClockDivider)
+	// Vrm selected but VDD as source
+	assert.Equal(t, VoltageReferenceVDD, s.DACReference)
+	assert.Equal(t, byte(0x08), s.DACValue)
+	assert.Equal(t, VoltageReference4V096, s.ADCReference)
+	assert.True(t, s.InterruptPositiveEdge)
+	assert.True(t, s.InterruptNegativeEdge)
+	assert.Equal(t, USBID(0x04D8), s.VendorID)
+	assert.Equal(t, USBID(0x00DD), s.ProductID)
+	assert.Equal(t, byte(0x80), s.PowerAttributes)
+	assert.Equal(t, 100, s.RequestedMilliAmps)
+
+	out := make([]byte, len(raw))
+	s.encode(out)
+	assert.Equal(t, raw, out)
+
+	s.ProductID = 0x00DE
+	s.CDCSerialEnumeration = true
+	s.encode(out)
+	assert.Equal(t, []byte{0x90, 0x12, 0xC8, 0x7C, 0xD8, 0x04, 0xDE, 0x00, 0x80, 0x32}, out)
+
+	s.DACReference = VoltageReference1V024
+	s.ADCReference = VoltageReferenceVDD
+	s.encode(out)
+	assert.Equal(t, []byte{0x90, 0x12, 0x68, 0x60, 0xD8, 0x04, 0xDE, 0x00, 0x80, 0x32}, out)
+}
+
+func TestChipSecurity_String(t *testing.T) {
+	assert.Equal(t, "unsecured", ChipUnsecured.String())
+	assert.Equal(t, "password protected", ChipPasswordProtected.String())
+	assert.Equal(t, "permanently locked", ChipPermanentlyLocked.String())
+	assert.Equal(t, "permanently locked", ChipSecurity(0b11).String())
+	assert.Equal(t, "0x04d8", USBID(0x04D8).String())
+}
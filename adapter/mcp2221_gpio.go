@@ -0,0 +1,207 @@
+package adapter
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// GPIO values commands
+const (
+	cmdSetGPIOValues = 0x50
+	cmdGetGPIOValues = 0x51
+)
+
+// Set GPIO Output Values request layout: each pin uses four bytes starting
+// at offset 2 + 4*pin, an alter flag and a value for the output level
+// followed by an alter flag and a value for the direction.
+const (
+	gpioSetValue     = 2
+	gpioSetDirection = 4
+)
+
+// gpioNotDesignated is returned in place of a pin value or direction when the
+// pin is not designated for GPIO operation.
+const gpioNotDesignated = 0xEE
+
+var ErrPinNotGPIO = errors.New("pin is not designated for GPIO operation")
+
+// GPIOPin identifies one of the GP0..GP3 pins.
+type GPIOPin int
+
+const (
+	GP0 GPIOPin = iota
+	GP1
+	GP2
+	GP3
+)
+
+// ParseGPIOPin accepts a pin number (0-3) optionally prefixed with "GP".
+func ParseGPIOPin(value string) (GPIOPin, error) {
+	clean := strings.TrimPrefix(strings.ToUpper(strings.TrimSpace(value)), "GP")
+	n, err := strconv.Atoi(clean)
+	if err != nil || n < int(GP0) || n > int(GP3) {
+		return 0, fmt.Errorf("invalid pin %q, expected 0-3 or GP0-GP3", value)
+	}
+	return GPIOPin(n), nil
+}
+
+func (p GPIOPin) String() string {
+	return fmt.Sprintf("GP%d", int(p))
+}
+
+func (p GPIOPin) valid() bool {
+	return p >= GP0 && p <= GP3
+}
+
+// Value returns the level read from the given pin.
+func (v MCP2221GPIOValues) Value(pin GPIOPin) byte {
+	switch pin {
+	case GP0:
+		return v.GPIO0Value
+	case GP1:
+		return v.GPIO1Value
+	case GP2:
+		return v.GPIO2Value
+	case GP3:
+		return v.GPIO3Value
+	}
+	return 0
+}
+
+// gpioOutputValueMask selects the output value bit of a GP setting byte.
+const gpioOutputValueMask = 0b00010000
+
+// encode writes the GP0..GP3 setting bytes of params into dst. The output
+// value bits are carried over from current when it is not nil, so that
+// reconfiguring a pin does not glitch the level driven by outputs.
+func (p MCP2221GPIOParameters) encode(dst []byte, current []byte) {
+	settings := [4]byte{
+		byte(p.GPIO0Designation) | byte(p.GPIO0Mode),
+		byte(p.GPIO1Designation) | byte(p.GPIO1Mode),
+		byte(p.GPIO2Designation) | byte(p.GPIO2Mode),
+		byte(p.GPIO3Designation) | byte(p.GPIO3Mode),
+	}
+	for i := range settings {
+		if current != nil {
+			settings[i] |= current[i] & gpioOutputValueMask
+		}
+		dst[i] = settings[i]
+	}
+}
+
+// decodeGPIOParameters decodes the GP0..GP3 setting bytes shared by the SRAM
+// and the flash layouts.
+func decodeGPIOParameters(src []byte) MCP2221GPIOParameters {
+	return MCP2221GPIOParameters{
+		GPIO0Mode:        GPIOMode(src[0] & gpioModeMask),
+		GPIO0Designation: GPIODesignation(src[0] & gpioOperationMask),
+		GPIO1Mode:        GPIOMode(src[1] & gpioModeMask),
+		GPIO1Designation: GPIODesignation(src[1] & gpioOperationMask),
+		GPIO2Mode:        GPIOMode(src[2] & gpioModeMask),
+		GPIO2Designation: GPIODesignation(src[2] & gpioOperationMask),
+		GPIO3Mode:        GPIOMode(src[3] & gpioModeMask),
+		GPIO3Designation: GPIODesignation(src[3] & gpioOperationMask),
+	}
+}
+
+// SetGPIOParameters changes the current GP configuration through the SRAM
+// settings. The change takes effect immediately and is lost on reset; use
+// WriteFlashGPIOParameters to change the power-up configuration.
+func (d *MCP2221) SetGPIOParameters(ctx context.Context, params MCP2221GPIOParameters) error {
+	d.mx.Lock()
+	defer d.mx.Unlock()
+	err := d.doGetSRAM(ctx)
+	if err != nil {
+		return fmt.Errorf("could not read GP parameters: %w", err)
+	}
+	var current [4]byte
+	copy(current[:], d.response[sramGetGP0:sramGetGP0+4])
+	err = d.doSetSRAM(ctx, func(request []byte) {
+		request[sramSetGPIO] = sramAlter
+		params.encode(request[sramSetGP0:sramSetGP0+4], current[:])
+	})
+	if err != nil {
+		return fmt.Errorf("could not set GP parameters: %w", err)
+	}
+	return nil
+}
+
+// GetGPIOParameters returns the current GP configuration from the SRAM
+// settings. Use ReadFlashGPIOParameters for the power-up configuration.
+func (d *MCP2221) GetGPIOParameters(ctx context.Context) (MCP2221GPIOParameters, error) {
+	d.mx.Lock()
+	defer d.mx.Unlock()
+	err := d.doGetSRAM(ctx)
+	if err != nil {
+		return MCP2221GPIOParameters{}, fmt.Errorf("could not read GP parameters: %w", err)
+	}
+	return decodeGPIOParameters(d.response[sramGetGP0 : sramGetGP0+4]), nil
+}
+
+// SetGPIO drives the output level of a pin designated for GPIO operation.
+// Any non-zero value sets the pin high. The level is only visible on pins
+// configured as outputs (see SetGPIODirection).
+func (d *MCP2221) SetGPIO(ctx context.Context, pin GPIOPin, value byte) error {
+	if !pin.valid() {
+		return fmt.Errorf("invalid pin %d", pin)
+	}
+	if value != 0 {
+		value = 1
+	}
+	d.mx.Lock()
+	defer d.mx.Unlock()
+	err := d.doSetGPIOValues(ctx, pin, gpioSetValue, value)
+	if err != nil {
+		return fmt.Errorf("could not set %s value: %w", pin, err)
+	}
+	return nil
+}
+
+// SetGPIODirection switches a pin designated for GPIO operation between
+// input and output. The change is volatile, like SetGPIOParameters.
+func (d *MCP2221) SetGPIODirection(ctx context.Context, pin GPIOPin, mode GPIOMode) error {
+	if !pin.valid() {
+		return fmt.Errorf("invalid pin %d", pin)
+	}
+	var direction byte
+	switch mode {
+	case GPIOModeIn:
+		direction = 1
+	case GPIOModeOut:
+	default:
+		return fmt.Errorf("invalid GPIO mode %s", mode)
+	}
+	d.mx.Lock()
+	defer d.mx.Unlock()
+	err := d.doSetGPIOValues(ctx, pin, gpioSetDirection, direction)
+	if err != nil {
+		return fmt.Errorf("could not set %s direction: %w", pin, err)
+	}
+	return nil
+}
+
+// doSetGPIOValues sends a Set GPIO Output Values request altering a single
+// field of pin, either gpioSetValue or gpioSetDirection. Callers must hold
+// d.mx.
+func (d *MCP2221) doSetGPIOValues(ctx context.Context, pin GPIOPin, offset int, value byte) error {
+	d.resetBuffers()
+	d.request[0] = cmdSetGPIOValues
+	field := offset + 4*int(pin)
+	d.request[field] = 0x01
+	d.request[field+1] = value
+	err := d.exchange(ctx)
+	if err != nil {
+		return fmt.Errorf("set GPIO values command failed: %w", err)
+	}
+	if d.response[1] != 0x00 {
+		return ErrCommandFailed
+	}
+	if d.response[field+1] == gpioNotDesignated {
+		return ErrPinNotGPIO
+	}
+	d.shadow.recordGPIO(pin, offset, value)
+	return nil
+}
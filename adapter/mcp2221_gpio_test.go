@@ -0,0 +1,42 @@
+package adapter
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGPIOParameters_EncodeDecode(t *testing.T) {
+	params := MCP2221GPIOParameters{
+		GPIO0Mode:        GPIOModeIn,
+		GPIO0Designation: GPIOOperation,
+		GPIO1Mode:        GPIOModeOut,
+		GPIO1Designation: GPIO1ADC1,
+		GPIO2Mode:        GPIOModeOut,
+		GPIO2Designation: GPIOOperation,
+		GPIO3Mode:        GPIOModeOut,
+		GPIO3Designation: GPIO3DAC2,
+	}
+	buf := make([]byte, 4)
+	params.encode(buf, nil)
+	assert.Equal(t, []byte{0x08, 0x02, 0x00, 0x03}, buf)
+	assert.Equal(t, params, decodeGPIOParameters(buf))
+
+	// output levels are preserved, everything else is replaced
+	params.encode(buf, []byte{0x1F, 0x00, 0x10, 0x17})
+	assert.Equal(t, []byte{0x18, 0x02, 0x10, 0x13}, buf)
+	assert.Equal(t, params, decodeGPIOParameters(buf))
+}
+
+func TestParseGPIOPin(t *testing.T) {
+	for value, expected := range map[string]GPIOPin{"0": GP0, "gp1": GP1, "GP2": GP2, " 3 ": GP3} {
+		pin, err := ParseGPIOPin(value)
+		assert.NoError(t, err, value)
+		assert.Equal(t, expected, pin, value)
+	}
+	for _, value := range []string{"4", "-1", "GP", "GPIO1", ""} {
+		_, err := ParseGPIOPin(value)
+		assert.Error(t, err, value)
+	}
+	assert.Equal(t, "GP3", GP3.String())
+}
@@ -0,0 +1,54 @@
+package adapter
+
+import (
+	"github.com/karalabe/hid"
+)
+
+// HIDDevice is an opened HID device exchanging 64-byte reports. It is
+// implemented by *hid.Device and by the handles of the test fakes.
+type HIDDevice interface {
+	Write(report []byte) (int, error)
+	Read(report []byte) (int, error)
+	Close() error
+}
+
+// HIDTransport enumerates and opens HID devices.
+type HIDTransport interface {
+	Enumerate(vendorID uint16, productID uint16) []hid.DeviceInfo
+	Open(info hid.DeviceInfo) (HIDDevice, error)
+}
+
+// WithTransport replaces the USB HID transport, typically with a fake in
+// tests.
+func WithTransport(transport HIDTransport) MCP2221Option {
+	return func(o *MCP2221Options) {
+		o.Transport = transport
+	}
+}
+
+// usbTransport is the default transport backed by karalabe/hid.
+type usbTransport struct{}
+
+func (usbTransport) Enumerate(vendorID uint16, productID uint16) []hid.DeviceInfo {
+	return hid.Enumerate(vendorID, productID)
+}
+
+func (usbTransport) Open(info hid.DeviceInfo) (HIDDevice, error) {
+	device, err := info.Open()
+	if err != nil {
+		return nil, err
+	}
+	return device, nil
+}
+
+func (o MCP2221Options) transport() HIDTransport {
+	if o.Transport == nil {
+		return usbTransport{}
+	}
+	return o.Transport
+}
+
+// enumerate lists the devices matching the vendor and product ids.
+func (o MCP2221Options) enumerate() []hid.DeviceInfo {
+	return o.transport().Enumerate(o.VendorID, o.ProductID)
+}
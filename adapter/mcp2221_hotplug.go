@@ -0,0 +1,255 @@
+package adapter
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+)
+
+// ConnectionState is the state of the connection with the bridge.
+type ConnectionState int
+
+func (s ConnectionState) String() string {
+	switch s {
+	case StatusNew:
+		return "new"
+	case StatusInitialized:
+		return "initialized"
+	case StatusConnecting:
+		return "connecting"
+	case StatusConnected:
+		return "connected"
+	}
+	return fmt.Sprintf("state %d", int(s))
+}
+
+// Reconnection backoff used by Monitor while the bridge is missing
+const (
+	minReconnectDelay = 100 * time.Millisecond
+	maxReconnectDelay = 5 * time.Second
+)
+
+var ErrAlreadyMonitoring = errors.New("connection already monitored")
+
+// State returns the current connection state.
+func (d *MCP2221) State() ConnectionState {
+	d.stateMx.Lock()
+	defer d.stateMx.Unlock()
+	return d.state
+}
+
+// Subscribe returns a channel receiving every connection state change while
+// Monitor runs, and a function cancelling the subscription. The channel is
+// closed when monitoring stops. Changes are dropped for subscribers which do
+// not keep up. ErrNoReconnectChannel is returned when Monitor is not running.
+func (d *MCP2221) Subscribe() (<-chan ConnectionState, func(), error) {
+	d.stateMx.Lock()
+	defer d.stateMx.Unlock()
+	if d.reconnect == nil {
+		return nil, nil, ErrNoReconnectChannel
+	}
+	ch := make(chan ConnectionState, 8)
+	d.subscribers[ch] = struct{}{}
+	return ch, func() {
+		d.stateMx.Lock()
+		defer d.stateMx.Unlock()
+		if _, ok := d.subscribers[ch]; ok {
+			delete(d.subscribers, ch)
+			close(ch)
+		}
+	}, nil
+}
+
+// Monitor watches the presence of the bridge in the background until ctx is
+// done. It checks the bridge is still enumerated every interval (1s when 0)
+// and, once it is lost either this way or through a transport error, tries
+// to reconnect with exponential backoff. After reconnecting, the settings
+// changed through SRAM (GPIO, DAC, ADC, clock, interrupt) and the I2C speed
+// are restored, so callers only see errors while the bridge is missing.
+func (d *MCP2221) Monitor(ctx context.Context, interval time.Duration) error {
+	if interval <= 0 {
+		interval = time.Second
+	}
+	d.stateMx.Lock()
+	if d.reconnect != nil {
+		d.stateMx.Unlock()
+		return ErrAlreadyMonitoring
+	}
+	d.reconnect = make(chan struct{}, 1)
+	d.stateMx.Unlock()
+	go d.monitor(ctx, interval)
+	return nil
+}
+
+func (d *MCP2221) monitor(ctx context.Context, interval time.Duration) {
+	defer func() {
+		d.stateMx.Lock()
+		defer d.stateMx.Unlock()
+		d.reconnect = nil
+		for ch := range d.subscribers {
+			delete(d.subscribers, ch)
+			close(ch)
+		}
+	}()
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	delay := minReconnectDelay
+	for {
+		if d.State() == StatusConnected {
+			delay = minReconnectDelay
+			select {
+			case <-ctx.Done():
+				return
+			case <-d.reconnect:
+			case <-ticker.C:
+				_, err := d.options.selectDevice(d.options.enumerate())
+				if err != nil {
+					slog.Debug("mcp2221 unplugged", "err", err)
+					d.mx.Lock()
+					d.lost()
+					d.mx.Unlock()
+				}
+			}
+			continue
+		}
+		d.mx.Lock()
+		err := d.connect()
+		if err == nil {
+			err = d.disconnect()
+		}
+		d.mx.Unlock()
+		if err == nil {
+			continue
+		}
+		slog.Debug("mcp2221 reconnect failed; will retry", "err", err, "delay", delay)
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(delay):
+		}
+		delay = min(2*delay, maxReconnectDelay)
+	}
+}
+
+// setState records the connection state and notifies the subscribers.
+func (d *MCP2221) setState(state ConnectionState) {
+	d.stateMx.Lock()
+	defer d.stateMx.Unlock()
+	if d.state == state {
+		return
+	}
+	d.state = state
+	for ch := range d.subscribers {
+		select {
+		case ch <- state:
+		default:
+			slog.Warn("mcp2221 state change dropped, subscriber too slow", "state", state)
+		}
+	}
+}
+
+// lost drops the handle after a transport error or an unplug. The SRAM
+// settings and the I2C speed are restored on the next connect. Callers must
+// hold d.mx.
+func (d *MCP2221) lost() {
+	_ = d.invalidate()
+	d.speedApplied = false
+	d.restoreSRAM = true
+	if d.State() != StatusConnected {
+		return
+	}
+	d.setState(StatusConnecting)
+	d.stateMx.Lock()
+	defer d.stateMx.Unlock()
+	if d.reconnect != nil {
+		select {
+		case d.reconnect <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// sramShadow keeps the SRAM settings changed by the adapter in the Set SRAM
+// Settings layout, with sramAlter set on the recorded sections, so that they
+// can be sent again to a chip that went through a power cycle.
+type sramShadow [sramSetGP0 + 4]byte
+
+// record merges the sections altered by a Set SRAM Settings request.
+func (s *sramShadow) record(request []byte) {
+	for _, i := range []int{sramSetClock, sramSetDACRef, sramSetDACValue, sramSetADCRef} {
+		if request[i]&sramAlter != 0 {
+			s[i] = request[i]
+		}
+	}
+	// clearing the flag alone does not change the detection conditions
+	if request[sramSetInterrupt]&(sramInterruptAlterPositive|sramInterruptAlterNegative) != 0 {
+		s[sramSetInterrupt] = request[sramSetInterrupt] &^ sramInterruptClear
+	}
+	if request[sramSetGPIO]&sramAlter != 0 {
+		s[sramSetGPIO] = sramAlter
+		copy(s[sramSetGP0:], request[sramSetGP0:sramSetGP0+4])
+	}
+}
+
+// recordGPIO applies a Set GPIO Output Values change to the recorded GP
+// settings, if any.
+func (s *sramShadow) recordGPIO(pin GPIOPin, field int, value byte) {
+	if s[sramSetGPIO] == 0 {
+		return
+	}
+	mask := byte(gpioOutputValueMask)
+	if field == gpioSetDirection {
+		mask = gpioModeMask
+	}
+	if value != 0 {
+		s[sramSetGP0+int(pin)] |= mask
+		return
+	}
+	s[sramSetGP0+int(pin)] &^= mask
+}
+
+func (s *sramShadow) empty() bool {
+	return *s == sramShadow{}
+}
+
+// applySRAMShadow sends the recorded SRAM settings again after the chip was
+// lost. Like applyI2CSpeed it runs while connecting and preserves the
+// request buffer. Callers must hold d.mx and be connected.
+func (d *MCP2221) applySRAMShadow() error {
+	if !d.restoreSRAM {
+		return nil
+	}
+	if !d.shadow.empty() {
+		err := d.sideExchange(func(request []byte) {
+			request[0] = cmdSetSRAMSettings
+			copy(request[1:], d.shadow[1:])
+		})
+		if err != nil {
+			return fmt.Errorf("restore SRAM settings failed: %w", err)
+		}
+		if d.response[1] != 0x00 {
+			return fmt.Errorf("restore SRAM settings failed: %w", ErrCommandFailed)
+		}
+	}
+	d.restoreSRAM = false
+	return nil
+}
+
+// sideExchange sends a request prepared by fill and receives the response
+// without disturbing the request buffer, which may hold a command prepared
+// before connecting. Callers must hold d.mx and be connected.
+func (d *MCP2221) sideExchange(fill func(request []byte)) error {
+	var saved [64]byte
+	copy(saved[:], d.request)
+	defer copy(d.request, saved[:])
+	resetBuffer(d.request)
+	fill(d.request)
+	ctx := context.Background()
+	err := d.send(ctx)
+	if err != nil {
+		return err
+	}
+	return d.receive(ctx)
+}
@@ -0,0 +1,65 @@
+package adapter
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSRAMShadowRecord(t *testing.T) {
+	var s sramShadow
+	assert.True(t, s.empty())
+
+	request := make([]byte, 64)
+	request[sramSetDACValue] = sramAlter | 0x10
+	request[sramSetGPIO] = sramAlter
+	copy(request[sramSetGP0:], []byte{0x00, 0x08, 0x02, 0x08})
+	s.record(request)
+
+	request = make([]byte, 64)
+	request[sramSetDACRef] = sramAlter | byte(VoltageReference2V048)
+	request[sramSetInterrupt] = sramAlter | sramInterruptAlterPositive | sramInterruptPositive | sramInterruptClear
+	s.record(request)
+
+	// clearing the interrupt flag alone is not recorded
+	request = make([]byte, 64)
+	request[sramSetInterrupt] = sramAlter | sramInterruptClear
+	s.record(request)
+
+	assert.Equal(t, byte(0), s[sramSetClock])
+	assert.Equal(t, byte(sramAlter|VoltageReference2V048), s[sramSetDACRef])
+	assert.Equal(t, byte(sramAlter|0x10), s[sramSetDACValue])
+	assert.Equal(t, byte(0), s[sramSetADCRef])
+	assert.Equal(t, byte(sramAlter|sramInterruptAlterPositive|sramInterruptPositive), s[sramSetInterrupt])
+	assert.Equal(t, []byte{sramAlter, 0x00, 0x08, 0x02, 0x08}, s[sramSetGPIO:])
+}
+
+func TestSRAMShadowRecordGPIO(t *testing.T) {
+	var s sramShadow
+	// nothing to update before the GP settings are known
+	s.recordGPIO(GP0, gpioSetValue, 1)
+	require.True(t, s.empty())
+
+	request := make([]byte, 64)
+	request[sramSetGPIO] = sramAlter
+	copy(request[sramSetGP0:], []byte{0x08, 0x08, 0x00, 0x00})
+	s.record(request)
+
+	s.recordGPIO(GP0, gpioSetDirection, 0)
+	s.recordGPIO(GP0, gpioSetValue, 1)
+	s.recordGPIO(GP3, gpioSetDirection, 1)
+	assert.Equal(t, []byte{gpioOutputValueMask, 0x08, 0x00, 0x08}, s[sramSetGP0:])
+}
+
+func TestConnectionStateString(t *testing.T) {
+	assert.Equal(t, "connected", StatusConnected.String())
+	assert.Equal(t, "connecting", StatusConnecting.String())
+	assert.Equal(t, "state 9", ConnectionState(9).String())
+}
+
+func TestSubscribeRequiresMonitor(t *testing.T) {
+	d := NewMCP2221()
+	_, _, err := d.Subscribe()
+	assert.ErrorIs(t, err, ErrNoReconnectChannel)
+}
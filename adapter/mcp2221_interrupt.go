@@ -0,0 +1,204 @@
+package adapter
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+)
+
+// Set SRAM Settings interrupt byte: sramAlter enables the modification of
+// the detection conditions, each edge has an alter bit followed by its value
+const (
+	sramInterruptAlterPositive = 0b00010000
+	sramInterruptPositive      = 0b00001000
+	sramInterruptAlterNegative = 0b00000100
+	sramInterruptNegative      = 0b00000010
+	sramInterruptClear         = 0b00000001
+	sramGetInterruptPositive   = 0b01000000
+	sramGetInterruptNegative   = 0b00100000
+)
+
+const defaultInterruptPollingTime = 5 * time.Millisecond
+
+// InterruptEdge selects the GP1 edges latched by the interrupt detector.
+type InterruptEdge byte
+
+const (
+	InterruptNone    InterruptEdge = 0
+	InterruptRising  InterruptEdge = 0b01
+	InterruptFalling InterruptEdge = 0b10
+	InterruptBoth                  = InterruptRising | InterruptFalling
+)
+
+// ParseInterruptEdge accepts "rising", "falling", "both" or "none".
+func ParseInterruptEdge(value string) (InterruptEdge, error) {
+	switch value {
+	case "rising":
+		return InterruptRising, nil
+	case "falling":
+		return InterruptFalling, nil
+	case "both":
+		return InterruptBoth, nil
+	case "none":
+		return InterruptNone, nil
+	}
+	return 0, fmt.Errorf("invalid interrupt edge %q, expected rising, falling, both or none", value)
+}
+
+func (e InterruptEdge) String() string {
+	switch e {
+	case InterruptRising:
+		return "rising"
+	case InterruptFalling:
+		return "falling"
+	case InterruptBoth:
+		return "both"
+	default:
+		return "none"
+	}
+}
+
+func (e InterruptEdge) MarshalYAML() (interface{}, error) {
+	return e.String(), nil
+}
+
+// InterruptEvent reports an edge latched by the interrupt detector. The chip
+// does not tell which edge triggered it nor how many edges occurred since
+// the previous event.
+type InterruptEvent struct {
+	Time time.Time
+}
+
+// ConfigureInterrupt designates GP1 as the interrupt detector input, selects
+// the edges it latches and clears the flag. The other pins keep their
+// configuration. Like other SRAM settings this is lost on reset.
+func (d *MCP2221) ConfigureInterrupt(ctx context.Context, edge InterruptEdge) error {
+	d.mx.Lock()
+	defer d.mx.Unlock()
+	err := d.doGetSRAM(ctx)
+	if err != nil {
+		return fmt.Errorf("could not read GP parameters: %w", err)
+	}
+	var gp [4]byte
+	copy(gp[:], d.response[sramGetGP0:sramGetGP0+4])
+	gp[GP1] = byte(GPIO1InterruptDetection) | byte(GPIOModeIn)
+	err = d.doSetSRAM(ctx, func(request []byte) {
+		request[sramSetInterrupt] = sramAlter | sramInterruptAlterPositive | sramInterruptAlterNegative | sramInterruptClear
+		if edge&InterruptRising != 0 {
+			request[sramSetInterrupt] |= sramInterruptPositive
+		}
+		if edge&InterruptFalling != 0 {
+			request[sramSetInterrupt] |= sramInterruptNegative
+		}
+		request[sramSetGPIO] = sramAlter
+		copy(request[sramSetGP0:], gp[:])
+	})
+	if err != nil {
+		return fmt.Errorf("could not configure interrupt: %w", err)
+	}
+	return nil
+}
+
+// InterruptConfiguration returns the edges currently latched by the
+// interrupt detector.
+func (d *MCP2221) InterruptConfiguration(ctx context.Context) (InterruptEdge, error) {
+	d.mx.Lock()
+	defer d.mx.Unlock()
+	err := d.doGetSRAM(ctx)
+	if err != nil {
+		return InterruptNone, fmt.Errorf("could not read interrupt configuration: %w", err)
+	}
+	edge := InterruptNone
+	if d.response[sramGetInterrupt]&sramGetInterruptPositive != 0 {
+		edge |= InterruptRising
+	}
+	if d.response[sramGetInterrupt]&sramGetInterruptNegative != 0 {
+		edge |= InterruptFalling
+	}
+	return edge, nil
+}
+
+// InterruptFlag tells whether the interrupt detector latched an edge since
+// the flag was last cleared.
+func (d *MCP2221) InterruptFlag(ctx context.Context) (bool, error) {
+	d.mx.Lock()
+	defer d.mx.Unlock()
+	status, err := d.doGetStatus(ctx)
+	if err != nil {
+		return false, fmt.Errorf("could not read interrupt flag: %w", err)
+	}
+	return status.InterruptDetected, nil
+}
+
+// ClearInterrupt clears the interrupt detector flag.
+func (d *MCP2221) ClearInterrupt(ctx context.Context) error {
+	d.mx.Lock()
+	defer d.mx.Unlock()
+	return d.doClearInterrupt(ctx)
+}
+
+func (d *MCP2221) doClearInterrupt(ctx context.Context) error {
+	err := d.doSetSRAM(ctx, func(request []byte) {
+		request[sramSetInterrupt] = sramAlter | sramInterruptClear
+	})
+	if err != nil {
+		return fmt.Errorf("could not clear interrupt flag: %w", err)
+	}
+	return nil
+}
+
+// WatchInterrupt reports the edges latched by the interrupt detector, which
+// must be configured with ConfigureInterrupt, until ctx is done; the channel
+// is then closed. The chip does not notify the host, so the flag is polled
+// every interval (5ms when 0) and cleared after each event. Unlike polling
+// the pin level, edges shorter than the interval are not missed. Events are
+// dropped when the receiver falls behind.
+func (d *MCP2221) WatchInterrupt(ctx context.Context, interval time.Duration) <-chan InterruptEvent {
+	if interval <= 0 {
+		interval = defaultInterruptPollingTime
+	}
+	events := make(chan InterruptEvent, 16)
+	go func() {
+		defer close(events)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				detected, err := d.takeInterrupt(ctx)
+				if err != nil {
+					if ctx.Err() == nil {
+						slog.Debug("interrupt poll failed; will retry", "err", err)
+					}
+					continue
+				}
+				if !detected {
+					continue
+				}
+				select {
+				case events <- InterruptEvent{Time: time.Now()}:
+				default:
+					slog.Warn("interrupt event dropped, receiver too slow")
+				}
+			}
+		}
+	}()
+	return events
+}
+
+// takeInterrupt reads the interrupt flag and clears it when set.
+func (d *MCP2221) takeInterrupt(ctx context.Context) (bool, error) {
+	d.mx.Lock()
+	defer d.mx.Unlock()
+	status, err := d.doGetStatus(ctx)
+	if err != nil {
+		return false, err
+	}
+	if !status.InterruptDetected {
+		return false, nil
+	}
+	return true, d.doClearInterrupt(ctx)
+}
@@ -0,0 +1,17 @@
+package adapter
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseInterruptEdge(t *testing.T) {
+	for _, edge := range []InterruptEdge{InterruptNone, InterruptRising, InterruptFalling, InterruptBoth} {
+		parsed, err := ParseInterruptEdge(edge.String())
+		assert.NoError(t, err)
+		assert.Equal(t, edge, parsed)
+	}
+	_, err := ParseInterruptEdge("high")
+	assert.Error(t, err)
+}
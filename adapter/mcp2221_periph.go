@@ -0,0 +1,334 @@
+package adapter
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/mklimuk/sensors"
+	"periph.io/x/conn/v3/gpio"
+	"periph.io/x/conn/v3/gpio/gpioreg"
+	"periph.io/x/conn/v3/i2c"
+	"periph.io/x/conn/v3/i2c/i2creg"
+	"periph.io/x/conn/v3/physic"
+	"periph.io/x/conn/v3/pin"
+)
+
+var _ i2c.BusCloser = &PeriphBus{}
+var _ gpio.PinIO = &PeriphPin{}
+
+var ErrPWMUnsupported = fmt.Errorf("mcp2221 pin PWM %w", sensors.ErrUnsupported)
+
+// PeriphBus exposes an MCP2221 as a periph.io I2C bus, so that periph device
+// drivers work over the bridge.
+type PeriphBus struct {
+	d    *MCP2221
+	name string
+}
+
+// NewPeriphBus wraps d into a periph.io I2C bus named name.
+func NewPeriphBus(d *MCP2221, name string) *PeriphBus {
+	return &PeriphBus{d: d, name: name}
+}
+
+func (b *PeriphBus) String() string {
+	return b.name
+}
+
+// Tx writes w and then reads r from the device at addr, using a repeated
+// start when both are set.
+func (b *PeriphBus) Tx(addr uint16, w, r []byte) error {
+	if addr > 0x7F {
+		return fmt.Errorf("%s: 10-bit address %#x not supported", b.name, addr)
+	}
+	ctx := context.Background()
+	switch {
+	case len(w) > 0 && len(r) > 0:
+		return b.d.Tx(ctx, byte(addr), w, r)
+	case len(w) > 0:
+		return b.d.WriteToAddr(ctx, byte(addr), w)
+	case len(r) > 0:
+		return b.d.ReadFromAddr(ctx, byte(addr), r)
+	}
+	return nil
+}
+
+// SetSpeed sets the I2C clock of the bridge.
+func (b *PeriphBus) SetSpeed(f physic.Frequency) error {
+	return b.d.SetI2CSpeed(context.Background(), int(f/physic.Hertz))
+}
+
+// Close does nothing: the bridge is shared by the users of the registry.
+func (b *PeriphBus) Close() error {
+	return nil
+}
+
+// PeriphPin exposes a GP pin of an MCP2221 as a periph.io GPIO pin. Only GP1
+// detects edges, through the interrupt detector of the chip.
+type PeriphPin struct {
+	d    *MCP2221
+	pin  GPIOPin
+	name string
+	edge gpio.Edge
+}
+
+// NewPeriphPin wraps the given pin of d into a periph.io pin named name.
+func NewPeriphPin(d *MCP2221, p GPIOPin, name string) *PeriphPin {
+	return &PeriphPin{d: d, pin: p, name: name}
+}
+
+func (p *PeriphPin) String() string {
+	return p.name
+}
+
+func (p *PeriphPin) Name() string {
+	return p.name
+}
+
+func (p *PeriphPin) Number() int {
+	return int(p.pin)
+}
+
+// Function returns the current function of the pin.
+//
+// Deprecated: Use Func.
+func (p *PeriphPin) Function() string {
+	return string(p.Func())
+}
+
+// Func returns gpio.IN or gpio.OUT for pins designated for GPIO operation
+// and the designation name otherwise.
+func (p *PeriphPin) Func() pin.Func {
+	params, err := p.d.GetGPIOParameters(context.Background())
+	if err != nil {
+		return pin.FuncNone
+	}
+	designation, mode := pinParameters(params, p.pin)
+	if designation != GPIOOperation {
+		return pin.Func(fmt.Sprintf("ALT%d", designation))
+	}
+	if mode == GPIOModeOut {
+		return gpio.OUT
+	}
+	return gpio.IN
+}
+
+func (p *PeriphPin) SupportedFuncs() []pin.Func {
+	return []pin.Func{gpio.IN, gpio.OUT}
+}
+
+func (p *PeriphPin) SetFunc(f pin.Func) error {
+	switch f {
+	case gpio.IN:
+		return p.In(gpio.PullNoChange, gpio.NoEdge)
+	case gpio.OUT_LOW:
+		return p.Out(gpio.Low)
+	case gpio.OUT, gpio.OUT_HIGH:
+		return p.Out(gpio.High)
+	}
+	return fmt.Errorf("%s: unsupported function %s", p.name, f)
+}
+
+func (p *PeriphPin) Halt() error {
+	return nil
+}
+
+// In configures the pin as an input. The chip has no pull resistors. Edges
+// are only detected on GP1, which is then designated as the interrupt
+// detector input and reads as low.
+func (p *PeriphPin) In(pull gpio.Pull, edge gpio.Edge) error {
+	if pull != gpio.PullNoChange && pull != gpio.Float {
+		return fmt.Errorf("%s: pull %s not supported", p.name, pull)
+	}
+	ctx := context.Background()
+	if edge != gpio.NoEdge {
+		if p.pin != GP1 {
+			return fmt.Errorf("%s: edge detection only supported on GP1", p.name)
+		}
+		var detect InterruptEdge
+		switch edge {
+		case gpio.RisingEdge:
+			detect = InterruptRising
+		case gpio.FallingEdge:
+			detect = InterruptFalling
+		default:
+			detect = InterruptBoth
+		}
+		err := p.d.ConfigureInterrupt(ctx, detect)
+		if err != nil {
+			return err
+		}
+		p.edge = edge
+		return nil
+	}
+	p.edge = gpio.NoEdge
+	return p.configure(ctx, GPIOModeIn)
+}
+
+// configure sets the direction of the pin, designating it for GPIO
+// operation first when needed.
+func (p *PeriphPin) configure(ctx context.Context, mode GPIOMode) error {
+	err := p.d.SetGPIODirection(ctx, p.pin, mode)
+	if !errors.Is(err, ErrPinNotGPIO) {
+		return err
+	}
+	params, err := p.d.GetGPIOParameters(ctx)
+	if err != nil {
+		return err
+	}
+	setPinParameters(&params, p.pin, GPIOOperation, mode)
+	return p.d.SetGPIOParameters(ctx, params)
+}
+
+func (p *PeriphPin) Read() gpio.Level {
+	values, err := p.d.ReadGPIO(context.Background())
+	if err != nil {
+		return gpio.Low
+	}
+	return values.Value(p.pin) == 1
+}
+
+// WaitForEdge polls the interrupt detector of GP1 until it latches an edge
+// configured with In. A negative timeout waits forever.
+func (p *PeriphPin) WaitForEdge(timeout time.Duration) bool {
+	if p.edge == gpio.NoEdge {
+		return false
+	}
+	ctx := context.Background()
+	if timeout >= 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	for {
+		detected, err := p.d.takeInterrupt(ctx)
+		if err == nil && detected {
+			return true
+		}
+		if pause(ctx, defaultInterruptPollingTime) != nil {
+			return false
+		}
+	}
+}
+
+func (p *PeriphPin) Pull() gpio.Pull {
+	return gpio.Float
+}
+
+func (p *PeriphPin) DefaultPull() gpio.Pull {
+	return gpio.Float
+}
+
+// Out configures the pin as an output driving l.
+func (p *PeriphPin) Out(l gpio.Level) error {
+	ctx := context.Background()
+	var value byte
+	if l {
+		value = 1
+	}
+	// set the level first so that the pin does not glitch when switched to
+	// output
+	err := p.d.SetGPIO(ctx, p.pin, value)
+	if err != nil && !errors.Is(err, ErrPinNotGPIO) {
+		return err
+	}
+	p.edge = gpio.NoEdge
+	err = p.configure(ctx, GPIOModeOut)
+	if err != nil {
+		return err
+	}
+	return p.d.SetGPIO(ctx, p.pin, value)
+}
+
+func (p *PeriphPin) PWM(duty gpio.Duty, f physic.Frequency) error {
+	return ErrPWMUnsupported
+}
+
+func pinParameters(params MCP2221GPIOParameters, p GPIOPin) (GPIODesignation, GPIOMode) {
+	switch p {
+	case GP0:
+		return params.GPIO0Designation, params.GPIO0Mode
+	case GP1:
+		return params.GPIO1Designation, params.GPIO1Mode
+	case GP2:
+		return params.GPIO2Designation, params.GPIO2Mode
+	}
+	return params.GPIO3Designation, params.GPIO3Mode
+}
+
+func setPinParameters(params *MCP2221GPIOParameters, p GPIOPin, designation GPIODesignation, mode GPIOMode) {
+	switch p {
+	case GP0:
+		params.GPIO0Designation, params.GPIO0Mode = designation, mode
+	case GP1:
+		params.GPIO1Designation, params.GPIO1Mode = designation, mode
+	case GP2:
+		params.GPIO2Designation, params.GPIO2Mode = designation, mode
+	case GP3:
+		params.GPIO3Designation, params.GPIO3Mode = designation, mode
+	}
+}
+
+// RegisterPeriph registers d in periph's i2creg under name and its pins in
+// gpioreg as name_GP0 to name_GP3. The returned function unregisters them.
+func RegisterPeriph(d *MCP2221, name string) (func() error, error) {
+	bus := NewPeriphBus(d, name)
+	err := i2creg.Register(name, nil, -1, func() (i2c.BusCloser, error) {
+		return bus, nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("could not register %s: %w", name, err)
+	}
+	var pins []string
+	unregister := func() error {
+		var errs []error
+		for _, p := range pins {
+			errs = append(errs, gpioreg.Unregister(p))
+		}
+		errs = append(errs, i2creg.Unregister(name))
+		return errors.Join(errs...)
+	}
+	for p := GP0; p <= GP3; p++ {
+		pinName := fmt.Sprintf("%s_%s", name, p)
+		err = gpioreg.Register(NewPeriphPin(d, p, pinName))
+		if err != nil {
+			_ = unregister()
+			return nil, fmt.Errorf("could not register %s: %w", pinName, err)
+		}
+		pins = append(pins, pinName)
+	}
+	return unregister, nil
+}
+
+// RegisterAllPeriph registers every attached bridge matching the options
+// with RegisterPeriph, naming them MCP2221-0, MCP2221-1… in enumeration
+// order. The returned function unregisters them.
+func RegisterAllPeriph(opts ...MCP2221Option) ([]string, func() error, error) {
+	var names []string
+	var unregisters []func() error
+	unregisterAll := func() error {
+		var errs []error
+		for _, unregister := range unregisters {
+			errs = append(errs, unregister())
+		}
+		return errors.Join(errs...)
+	}
+	for i, info := range EnumerateMCP2221(opts...) {
+		d := NewMCP2221(append(append([]MCP2221Option(nil), opts...), WithPath(info.Path))...)
+		err := d.Init()
+		if err != nil {
+			_ = unregisterAll()
+			return nil, nil, fmt.Errorf("could not initialize %s: %w", info.Path, err)
+		}
+		name := fmt.Sprintf("MCP2221-%d", i)
+		unregister, err := RegisterPeriph(d, name)
+		if err != nil {
+			_ = unregisterAll()
+			return nil, nil, err
+		}
+		names = append(names, name)
+		unregisters = append(unregisters, unregister)
+	}
+	return names, unregisterAll, nil
+}
@@ -0,0 +1,54 @@
+package adapter
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"periph.io/x/conn/v3/gpio"
+	"periph.io/x/conn/v3/gpio/gpioreg"
+	"periph.io/x/conn/v3/i2c"
+	"periph.io/x/conn/v3/i2c/i2creg"
+)
+
+func TestRegisterPeriph(t *testing.T) {
+	d, fake, dev := newFakeAdapter(t)
+	unregister, err := RegisterPeriph(d, "test-mcp2221")
+	require.NoError(t, err)
+	defer func() { require.NoError(t, unregister()) }()
+
+	bus, err := i2creg.Open("test-mcp2221")
+	require.NoError(t, err)
+	defer bus.Close()
+	dev.data = []byte{0xCA, 0xFE}
+	r := make([]byte, 2)
+	periphDev := i2c.Dev{Bus: bus, Addr: testAddress}
+	require.NoError(t, periphDev.Tx([]byte{0x01}, r))
+	assert.Equal(t, dev.data, r)
+	assert.Equal(t, [][]byte{{0x01}}, dev.written)
+	assert.Error(t, bus.Tx(0x100, []byte{0x01}, nil))
+
+	out := gpioreg.ByName("test-mcp2221_GP0")
+	require.NotNil(t, out)
+	require.NoError(t, out.Out(gpio.High))
+	value, output := fake.Output(GP0)
+	assert.True(t, output)
+	assert.Equal(t, byte(1), value)
+	assert.Equal(t, "OUT", out.Function())
+
+	in := gpioreg.ByName("test-mcp2221_GP2")
+	require.NoError(t, in.In(gpio.Float, gpio.NoEdge))
+	assert.Equal(t, gpio.Low, in.Read())
+	fake.SetInput(GP2, 1)
+	assert.Equal(t, gpio.High, in.Read())
+	assert.Error(t, in.In(gpio.PullUp, gpio.NoEdge))
+	assert.Error(t, in.In(gpio.Float, gpio.RisingEdge))
+	assert.ErrorIs(t, in.PWM(gpio.DutyHalf, 0), ErrPWMUnsupported)
+
+	edge := gpioreg.ByName("test-mcp2221_GP1")
+	require.NoError(t, edge.In(gpio.Float, gpio.RisingEdge))
+	assert.False(t, edge.WaitForEdge(10*time.Millisecond))
+	fake.TriggerInterrupt()
+	assert.True(t, edge.WaitForEdge(time.Second))
+}
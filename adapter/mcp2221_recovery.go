@@ -0,0 +1,113 @@
+package adapter
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/mklimuk/sensors"
+)
+
+var _ sensors.BusRecoverer = &MCP2221{}
+
+// recoveryCancels is the number of transfer cancellations attempted before
+// resetting the chip.
+const recoveryCancels = 3
+
+var (
+	reenumerateDelay   = 100 * time.Millisecond
+	reenumerateTimeout = 3 * time.Second
+)
+
+// RecoverBus frees the bus from a slave holding SDA low. The chip cannot
+// drive its I2C pins directly, so the pending transfer is cancelled, which
+// makes the engine generate a stop condition, up to recoveryCancels times.
+// If the bus is still not idle the chip is reset as a last resort and the
+// SRAM settings changed through the adapter are restored once it enumerates
+// again. ErrBusStuck is returned when the lines are still held low.
+func (d *MCP2221) RecoverBus(ctx context.Context) (sensors.BusRecovery, error) {
+	d.mx.Lock()
+	defer d.mx.Unlock()
+	res, idle, err := d.cancelStuckTransfer(ctx)
+	if err != nil || idle {
+		return res, err
+	}
+	err = d.doReset(ctx)
+	if err != nil {
+		return res, fmt.Errorf("could not reset mcp2221: %w", err)
+	}
+	res.AdapterReset = true
+	deadline := time.Now().Add(reenumerateTimeout)
+	var status *MCP2221Status
+	for {
+		status, err = d.doGetStatus(ctx)
+		if err == nil {
+			break
+		}
+		if time.Now().After(deadline) {
+			return res, fmt.Errorf("mcp2221 did not enumerate after reset: %w", err)
+		}
+		err = pause(ctx, reenumerateDelay)
+		if err != nil {
+			return res, err
+		}
+	}
+	res.After = busLines(status)
+	if !busIdle(status) {
+		return res, fmt.Errorf("%w: SCL %s, SDA %s after mcp2221 reset", sensors.ErrBusStuck, level(res.After.SCL), level(res.After.SDA))
+	}
+	return res, nil
+}
+
+// cancelStuckTransfer cancels the pending transfer until the bus is idle or
+// recoveryCancels attempts were made. Callers must hold d.mx.
+func (d *MCP2221) cancelStuckTransfer(ctx context.Context) (sensors.BusRecovery, bool, error) {
+	var res sensors.BusRecovery
+	err := d.connect()
+	if err != nil {
+		return res, false, fmt.Errorf("could not connect to mcp2221: %w", err)
+	}
+	defer func() {
+		err := d.disconnect()
+		if err != nil {
+			slog.Error("could not disconnect from mcp2221", "err", err)
+		}
+	}()
+	for i := range recoveryCancels {
+		if i > 0 {
+			err = pause(ctx, chipDelay)
+			if err != nil {
+				return res, false, err
+			}
+		}
+		status, err := d.doReleaseBus(ctx)
+		if err != nil {
+			return res, false, fmt.Errorf("could not cancel i2c transfer: %w", err)
+		}
+		res.After = busLines(status)
+		if i == 0 {
+			res.Before = res.After
+		}
+		if busIdle(status) {
+			return res, true, nil
+		}
+		slog.Debug("i2c bus not idle after cancel", "scl", status.SCL, "sda", status.SDA, "state", status.I2CState)
+	}
+	return res, false, nil
+}
+
+func busLines(status *MCP2221Status) sensors.BusLines {
+	return sensors.BusLines{SCL: status.SCL != 0, SDA: status.SDA != 0}
+}
+
+func busIdle(status *MCP2221Status) bool {
+	return busLines(status).Idle() && status.I2CState == i2cStateIdle
+}
+
+func level(high bool) string {
+	if high {
+		return "high"
+	}
+	return "low"
+}
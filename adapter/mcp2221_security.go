@@ -0,0 +1,97 @@
+package adapter
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+const cmdSendPassword = 0xB2
+
+// passwordLength is the size of the flash access password. Shorter passwords
+// are padded with zeros.
+const passwordLength = 8
+
+var ErrPasswordRejected = errors.New("password rejected")
+var ErrPasswordAttemptsExceeded = errors.New("too many password attempts, reset the chip to retry")
+
+func encodePassword(password string) ([]byte, error) {
+	if len(password) > passwordLength {
+		return nil, fmt.Errorf("password too long: %d bytes, at most %d", len(password), passwordLength)
+	}
+	res := make([]byte, passwordLength)
+	copy(res, password)
+	return res, nil
+}
+
+// SendPassword unlocks the flash settings of a password protected chip until
+// the next reset. The accepted password is kept in memory so that later
+// chip settings writes preserve it.
+func (d *MCP2221) SendPassword(ctx context.Context, password string) error {
+	encoded, err := encodePassword(password)
+	if err != nil {
+		return err
+	}
+	d.mx.Lock()
+	defer d.mx.Unlock()
+	d.resetBuffers()
+	d.request[0] = cmdSendPassword
+	copy(d.request[2:2+passwordLength], encoded)
+	err = d.exchange(ctx)
+	if err != nil {
+		return fmt.Errorf("could not send password: %w", err)
+	}
+	switch d.response[1] {
+	case 0x00:
+		d.password = encoded
+		return nil
+	case 0x01:
+		return ErrPasswordRejected
+	case 0x03:
+		return ErrPasswordAttemptsExceeded
+	default:
+		return ErrCommandFailed
+	}
+}
+
+// SetPassword protects the flash settings with password, replacing the
+// current one. A chip that is already protected must be unlocked with
+// SendPassword first.
+func (d *MCP2221) SetPassword(ctx context.Context, password string) error {
+	encoded, err := encodePassword(password)
+	if err != nil {
+		return err
+	}
+	d.mx.Lock()
+	defer d.mx.Unlock()
+	err = d.doWriteChipSettings(ctx, nil, &securityChange{security: ChipPasswordProtected, password: encoded}, false)
+	if err != nil {
+		return fmt.Errorf("could not set password: %w", err)
+	}
+	return nil
+}
+
+// RemovePassword turns off the flash settings protection. The chip must be
+// unlocked with SendPassword first.
+func (d *MCP2221) RemovePassword(ctx context.Context) error {
+	d.mx.Lock()
+	defer d.mx.Unlock()
+	err := d.doWriteChipSettings(ctx, nil, &securityChange{security: ChipUnsecured}, false)
+	if err != nil {
+		return fmt.Errorf("could not remove password: %w", err)
+	}
+	return nil
+}
+
+// LockPermanently makes the flash settings read-only for good. This cannot be
+// undone: the USB strings, chip and GP settings can never be changed again.
+// A password protected chip must be unlocked with SendPassword first.
+func (d *MCP2221) LockPermanently(ctx context.Context) error {
+	d.mx.Lock()
+	defer d.mx.Unlock()
+	err := d.doWriteChipSettings(ctx, nil, &securityChange{security: ChipPermanentlyLocked}, false)
+	if err != nil {
+		return fmt.Errorf("could not lock chip: %w", err)
+	}
+	return nil
+}
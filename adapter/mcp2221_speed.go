@@ -0,0 +1,94 @@
+package adapter
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+)
+
+// I2C speed limits. The bus clock is derived from the 12 MHz internal clock
+// with an 8-bit divider, which puts the slowest clock above the 30 kHz some
+// devices (e.g. the AGS02MA) are specified for.
+const (
+	i2cClock    = 12_000_000
+	MinI2CSpeed = i2cClock/(0xFF+3) + 1
+	MaxI2CSpeed = 400_000
+)
+
+// Status/Set Parameters request and response bytes used for setting the
+// I2C speed
+const (
+	statusSetSpeedOffset = 3
+	statusSetSpeed       = 0x20
+	statusSpeedNotSet    = 0x21
+)
+
+// WithI2CSpeed sets the I2C clock frequency in Hz applied when the device is
+// connected and after every Reset.
+func WithI2CSpeed(hz int) MCP2221Option {
+	return func(o *MCP2221Options) {
+		o.I2CSpeed = hz
+	}
+}
+
+// i2cSpeedDivider returns the divider producing the closest clock not faster
+// than hz.
+func i2cSpeedDivider(hz int) (byte, error) {
+	if hz < MinI2CSpeed || hz > MaxI2CSpeed {
+		return 0, fmt.Errorf("unsupported i2c speed %d Hz, expected %d-%d Hz", hz, MinI2CSpeed, MaxI2CSpeed)
+	}
+	div := (i2cClock+hz-1)/hz - 3
+	return byte(div), nil
+}
+
+// SetI2CSpeed sets the I2C clock frequency in Hz. The speed is remembered and
+// applied again after Reset and whenever the adapter reconnects to a chip
+// which lost it.
+func (d *MCP2221) SetI2CSpeed(ctx context.Context, hz int) error {
+	_, err := i2cSpeedDivider(hz)
+	if err != nil {
+		return err
+	}
+	d.mx.Lock()
+	defer d.mx.Unlock()
+	d.options.I2CSpeed = hz
+	d.speedApplied = false
+	err = d.connect()
+	if err != nil {
+		return fmt.Errorf("could not connect to mcp2221: %w", err)
+	}
+	defer func() {
+		err := d.disconnect()
+		if err != nil {
+			slog.Error("could not disconnect from mcp2221", "err", err)
+		}
+	}()
+	// a handle kept open by a session does not go through connecting again
+	return d.applyI2CSpeed()
+}
+
+// applyI2CSpeed sends the configured I2C speed unless the chip already uses
+// it. It runs while connecting, see sideExchange. Callers must hold d.mx and
+// be connected.
+func (d *MCP2221) applyI2CSpeed() error {
+	if d.options.I2CSpeed == 0 || d.speedApplied {
+		return nil
+	}
+	div, err := i2cSpeedDivider(d.options.I2CSpeed)
+	if err != nil {
+		return err
+	}
+	err = d.sideExchange(func(request []byte) {
+		request[0] = 0x10
+		request[statusSetSpeedOffset] = statusSetSpeed
+		request[statusSetSpeedOffset+1] = div
+	})
+	if err != nil {
+		return fmt.Errorf("set i2c speed failed: %w", err)
+	}
+	if d.response[statusSetSpeedOffset] == statusSpeedNotSet {
+		return fmt.Errorf("i2c speed not set, a transfer is in progress: %w", ErrCommandFailed)
+	}
+	d.speedApplied = true
+	return nil
+}
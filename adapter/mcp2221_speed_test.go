@@ -0,0 +1,26 @@
+package adapter
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestI2CSpeedDivider(t *testing.T) {
+	for hz, expected := range map[int]byte{
+		MaxI2CSpeed: 27,
+		100_000:     117,
+		50_000:      237,
+		MinI2CSpeed: 0xFF,
+	} {
+		div, err := i2cSpeedDivider(hz)
+		assert.NoError(t, err, hz)
+		assert.Equal(t, expected, div, hz)
+	}
+	_, err := i2cSpeedDivider(30_000)
+	assert.Error(t, err)
+	_, err = i2cSpeedDivider(MinI2CSpeed - 1)
+	assert.Error(t, err)
+	_, err = i2cSpeedDivider(1_000_000)
+	assert.Error(t, err)
+}
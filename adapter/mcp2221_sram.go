@@ -0,0 +1,151 @@
+package adapter
+
+import (
+	"context"
+	"fmt"
+)
+
+// SRAM settings commands. Settings written to SRAM take effect immediately
+// and are lost on reset, unlike the flash settings which define power-up
+// defaults.
+const (
+	cmdSetSRAMSettings = 0x60
+	cmdGetSRAMSettings = 0x61
+)
+
+// Set SRAM Settings request layout
+const (
+	sramSetClock     = 2
+	sramSetDACRef    = 3
+	sramSetDACValue  = 4
+	sramSetADCRef    = 5
+	sramSetInterrupt = 6
+	sramSetGPIO      = 7
+	sramSetGP0       = 8
+	// sramAlter marks a request byte as valid; bytes without it are ignored
+	// by the chip.
+	sramAlter = 0x80
+)
+
+// Get SRAM Settings response layout
+const (
+	sramGetChip      = 4
+	sramGetClock     = 5
+	sramGetDAC       = 6
+	sramGetInterrupt = 7
+	sramGetGP0       = 22
+)
+
+// VoltageReference selects the reference used by the ADC or the DAC. The
+// value is encoded as in the SRAM settings: bits 2:1 select the internal
+// reference level (Vrm) and bit 0 selects Vrm (1) or VDD (0).
+type VoltageReference byte
+
+const (
+	VoltageReferenceVDD   VoltageReference = 0b000
+	VoltageReference1V024 VoltageReference = 0b011
+	VoltageReference2V048 VoltageReference = 0b101
+	VoltageReference4V096 VoltageReference = 0b111
+)
+
+// ParseVoltageReference accepts "vdd", "1.024", "2.048" or "4.096".
+func ParseVoltageReference(value string) (VoltageReference, error) {
+	switch value {
+	case "vdd", "VDD":
+		return VoltageReferenceVDD, nil
+	case "1.024", "1.024V":
+		return VoltageReference1V024, nil
+	case "2.048", "2.048V":
+		return VoltageReference2V048, nil
+	case "4.096", "4.096V":
+		return VoltageReference4V096, nil
+	}
+	return 0, fmt.Errorf("invalid voltage reference %q, expected vdd, 1.024, 2.048 or 4.096", value)
+}
+
+func (r VoltageReference) String() string {
+	switch r {
+	case VoltageReference1V024:
+		return "1.024V"
+	case VoltageReference2V048:
+		return "2.048V"
+	case VoltageReference4V096:
+		return "4.096V"
+	default:
+		return "VDD"
+	}
+}
+
+func (r VoltageReference) MarshalYAML() (interface{}, error) {
+	return r.String(), nil
+}
+
+// Volts returns the reference voltage given the supply voltage vdd.
+func (r VoltageReference) Volts(vdd float64) float64 {
+	switch r {
+	case VoltageReference1V024:
+		return 1.024
+	case VoltageReference2V048:
+		return 2.048
+	case VoltageReference4V096:
+		return 4.096
+	default:
+		return vdd
+	}
+}
+
+// voltageReferenceFromSRAM decodes a reference stored as a 2-bit Vrm level
+// followed by the source selection bit, starting at bit shift.
+func voltageReferenceFromSRAM(value byte, shift uint) VoltageReference {
+	vrm := (value >> (shift + 1)) & 0b11
+	if (value>>shift)&0x01 == 0 || vrm == 0 {
+		return VoltageReferenceVDD
+	}
+	return VoltageReference(vrm<<1 | 0x01)
+}
+
+// doGetSRAM reads the current SRAM settings into d.response. Callers must
+// hold d.mx.
+func (d *MCP2221) doGetSRAM(ctx context.Context) error {
+	d.resetBuffers()
+	d.request[0] = cmdGetSRAMSettings
+	err := d.exchange(ctx)
+	if err != nil {
+		return fmt.Errorf("get SRAM settings failed: %w", err)
+	}
+	if d.response[1] != 0x00 {
+		return ErrCommandFailed
+	}
+	return nil
+}
+
+// doSetSRAM sends a Set SRAM Settings request; fill sets the request bytes
+// to alter (each with sramAlter set). Callers must hold d.mx.
+//
+// The chip reverts the GP pins to their power-up configuration whenever SRAM
+// settings are written without the GPIO section, so unless fill alters the
+// GPIO configuration the current one is read back and sent along.
+func (d *MCP2221) doSetSRAM(ctx context.Context, fill func(request []byte)) error {
+	err := d.doGetSRAM(ctx)
+	if err != nil {
+		return err
+	}
+	var gp [4]byte
+	copy(gp[:], d.response[sramGetGP0:sramGetGP0+4])
+	d.resetBuffers()
+	d.request[0] = cmdSetSRAMSettings
+	fill(d.request)
+	if d.request[sramSetGPIO] == 0 {
+		d.request[sramSetGPIO] = sramAlter
+		copy(d.request[sramSetGP0:], gp[:])
+	}
+	err = d.exchange(ctx)
+	if err != nil {
+		return fmt.Errorf("set SRAM settings failed: %w", err)
+	}
+	if d.response[1] != 0x00 {
+		return ErrCommandFailed
+	}
+	d.shadow.record(d.request)
+	return nil
+}
@@ -0,0 +1,85 @@
+package adapter
+
+import "fmt"
+
+// status response layout
+const (
+	statusI2CState         = 8
+	statusI2CACK           = 20
+	statusNACKMask         = 0x40
+	statusSCL              = 22
+	statusSDA              = 23
+	statusInterrupt        = 24
+	statusHardwareRevision = 46
+	statusFirmwareRevision = 48
+	// ADC channel 1 value; channels follow as little endian 16-bit words
+	statusADC = 50
+)
+
+// I2CEngineState is the state of the I2C engine state machine reported in
+// the status response.
+type I2CEngineState byte
+
+var i2cEngineStates = map[I2CEngineState]string{
+	0x00: "idle",
+	0x10: "start",
+	0x11: "start ack",
+	0x12: "start timeout",
+	0x15: "repeated start",
+	0x16: "repeated start ack",
+	0x17: "repeated start timeout",
+	0x20: "write address",
+	0x21: "write address wait send",
+	0x22: "write address ack",
+	0x23: "write address timeout",
+	0x24: "address nack stop pending",
+	0x25: "address nack",
+	0x30: "write address high",
+	0x31: "write address high wait send",
+	0x32: "write address high ack",
+	0x33: "write address high timeout",
+	0x40: "write data",
+	0x41: "write data wait send",
+	0x42: "write data ack",
+	0x44: "write data timeout",
+	0x45: "write data end no stop",
+	0x50: "read data",
+	0x51: "read data receive enable",
+	0x52: "read data timeout",
+	0x53: "read data ack",
+	0x54: "read data wait",
+	0x55: "read data wait get",
+	0x60: "stop",
+	0x61: "stop wait",
+	0x62: "stop timeout",
+}
+
+func (s I2CEngineState) String() string {
+	name, ok := i2cEngineStates[s]
+	if !ok {
+		name = "unknown"
+	}
+	return fmt.Sprintf("%s (%#02x)", name, byte(s))
+}
+
+func (s I2CEngineState) MarshalYAML() (interface{}, error) {
+	return s.String(), nil
+}
+
+// Timeout tells whether the engine gave up waiting for the bus, typically
+// because a device holds SCL or SDA low.
+func (s I2CEngineState) Timeout() bool {
+	switch s {
+	case 0x12, 0x17, 0x23, 0x33, 0x44, 0x52, 0x62:
+		return true
+	}
+	return false
+}
+
+// revision formats a major and minor revision pair of ASCII characters.
+func revision(b []byte) string {
+	if b[0] == 0 {
+		return ""
+	}
+	return fmt.Sprintf("%c.%c", b[0], b[1])
+}
@@ -0,0 +1,45 @@
+package adapter
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBufferToStatus(t *testing.T) {
+	buf := make([]byte, 64)
+	buf[0] = 0x10
+	buf[8] = 0x62
+	buf[9], buf[10] = 0x2C, 0x01
+	buf[11], buf[12] = 0x3C, 0x00
+	buf[14] = 0x76
+	buf[16] = 0x90
+	buf[20] = 0x40
+	buf[22] = 0x00
+	buf[23] = 0x01
+	buf[24] = 0x01
+	copy(buf[46:50], "A612")
+	copy(buf[50:56], []byte{0xFF, 0x03, 0x00, 0x02, 0x34, 0xF1})
+
+	s := bufferToStatus(buf)
+	assert.Equal(t, I2CEngineState(0x62), s.I2CState)
+	assert.Equal(t, "stop timeout (0x62)", s.I2CState.String())
+	assert.True(t, s.I2CState.Timeout())
+	assert.True(t, s.I2CAddressNACK)
+	assert.Equal(t, uint16(300), s.LastI2CRequestedSize)
+	assert.Equal(t, uint16(60), s.LastI2CTransferredSize)
+	assert.Equal(t, 0x76, s.I2CSpeedDivider)
+	assert.Equal(t, byte(0x90), s.I2CAddress)
+	assert.Equal(t, byte(0), s.SCL)
+	assert.Equal(t, byte(1), s.SDA)
+	assert.True(t, s.InterruptDetected)
+	assert.Equal(t, "A.6", s.HardwareRevision)
+	assert.Equal(t, "1.2", s.FirmwareRevision)
+	assert.Equal(t, [3]uint16{0x3FF, 0x200, 0x134}, s.ADC)
+}
+
+func TestI2CEngineState_String(t *testing.T) {
+	assert.Equal(t, "idle (0x00)", I2CEngineState(0).String())
+	assert.Equal(t, "unknown (0x99)", I2CEngineState(0x99).String())
+	assert.False(t, I2CEngineState(0x25).Timeout())
+}
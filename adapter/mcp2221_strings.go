@@ -0,0 +1,130 @@
+package adapter
+
+import (
+	"context"
+	"fmt"
+	"unicode/utf16"
+)
+
+// USBString selects one of the string descriptors stored in flash.
+type USBString byte
+
+const (
+	USBManufacturer USBString = flashManufacturer
+	USBProduct      USBString = flashProduct
+	USBSerial       USBString = flashSerial
+)
+
+func (s USBString) String() string {
+	switch s {
+	case USBManufacturer:
+		return "manufacturer"
+	case USBProduct:
+		return "product"
+	case USBSerial:
+		return "serial"
+	}
+	return fmt.Sprintf("string %d", byte(s))
+}
+
+// USBStringMaxLength is the longest descriptor, in UTF-16 code units, the chip
+// can store.
+const USBStringMaxLength = 30
+
+// usbStringDescriptor is the USB string descriptor type stored in front of
+// the characters.
+const usbStringDescriptor = 0x03
+
+// USBStrings are the USB string descriptors of the chip along with the factory
+// serial number, which cannot be changed.
+type USBStrings struct {
+	Manufacturer  string `json:"manufacturer" yaml:"manufacturer"`
+	Product       string `json:"product" yaml:"product"`
+	Serial        string `json:"serial" yaml:"serial"`
+	FactorySerial string `json:"factory_serial" yaml:"factory_serial"`
+}
+
+// ReadUSBStrings returns all USB string descriptors and the factory serial
+// number.
+func (d *MCP2221) ReadUSBStrings(ctx context.Context) (USBStrings, error) {
+	d.mx.Lock()
+	defer d.mx.Unlock()
+	var res USBStrings
+	var err error
+	res.Manufacturer, err = d.doReadUSBString(ctx, USBManufacturer)
+	if err != nil {
+		return res, err
+	}
+	res.Product, err = d.doReadUSBString(ctx, USBProduct)
+	if err != nil {
+		return res, err
+	}
+	res.Serial, err = d.doReadUSBString(ctx, USBSerial)
+	if err != nil {
+		return res, err
+	}
+	err = d.doReadFlash(ctx, flashFactorySerial)
+	if err != nil {
+		return res, fmt.Errorf("could not read factory serial: %w", err)
+	}
+	size := min(int(d.response[2]), len(d.response)-4)
+	res.FactorySerial = string(d.response[4 : 4+size])
+	return res, nil
+}
+
+// ReadUSBString returns the given USB string descriptor.
+func (d *MCP2221) ReadUSBString(ctx context.Context, kind USBString) (string, error) {
+	d.mx.Lock()
+	defer d.mx.Unlock()
+	return d.doReadUSBString(ctx, kind)
+}
+
+// WriteUSBString stores the given USB string descriptor in flash. The host
+// sees the new value after the chip is reset and enumerates again.
+func (d *MCP2221) WriteUSBString(ctx context.Context, kind USBString, value string) error {
+	units := utf16.Encode([]rune(value))
+	if len(units) > USBStringMaxLength {
+		return fmt.Errorf("%s string too long: %d characters, at most %d", kind, len(units), USBStringMaxLength)
+	}
+	d.mx.Lock()
+	defer d.mx.Unlock()
+	d.resetBuffers()
+	d.request[0] = cmdWriteFlash
+	d.request[1] = byte(kind)
+	d.request[2] = byte(len(units)*2 + 2)
+	d.request[3] = usbStringDescriptor
+	for i, u := range units {
+		d.request[4+2*i] = byte(u)
+		d.request[5+2*i] = byte(u >> 8)
+	}
+	err := d.exchange(ctx)
+	if err != nil {
+		return fmt.Errorf("could not write %s string: %w", kind, err)
+	}
+	return flashWriteStatus(d.response[1])
+}
+
+// doReadUSBString reads and decodes a string descriptor. Callers must hold
+// d.mx.
+func (d *MCP2221) doReadUSBString(ctx context.Context, kind USBString) (string, error) {
+	err := d.doReadFlash(ctx, byte(kind))
+	if err != nil {
+		return "", fmt.Errorf("could not read %s string: %w", kind, err)
+	}
+	return decodeUSBString(d.response[2:])
+}
+
+// decodeUSBString decodes a string descriptor consisting of its length in
+// bytes (including the two header bytes), the descriptor type and UTF-16LE
+// characters.
+func decodeUSBString(src []byte) (string, error) {
+	size := int(src[0])
+	if size < 2 || size > len(src) || size%2 != 0 || src[1] != usbStringDescriptor {
+		return "", fmt.Errorf("invalid string descriptor (length %d, type %#x)", src[0], src[1])
+	}
+	units := make([]uint16, 0, (size-2)/2)
+	for i := 2; i < size; i += 2 {
+		units = append(units, uint16(src[i])|uint16(src[i+1])<<8)
+	}
+	return string(utf16.Decode(units)), nil
+}
@@ -0,0 +1,34 @@
+package adapter
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecodeUSBString(t *testing.T) {
+	raw := []byte{0x0C, 0x03, 'M', 0, 'C', 0, 'P', 0, 0xB5, 0x00, '1', 0, 0xFF}
+	s, err := decodeUSBString(raw)
+	require.NoError(t, err)
+	assert.Equal(t, "MCPµ1", s)
+
+	s, err = decodeUSBString([]byte{0x02, 0x03})
+	require.NoError(t, err)
+	assert.Empty(t, s)
+
+	_, err = decodeUSBString([]byte{0x04, 0x01, 'a', 0})
+	assert.Error(t, err, "wrong descriptor type")
+	_, err = decodeUSBString([]byte{0x06, 0x03, 'a', 0})
+	assert.Error(t, err, "length beyond buffer")
+	_, err = decodeUSBString([]byte{0x03, 0x03, 'a', 0})
+	assert.Error(t, err, "odd length")
+}
+
+func TestEncodePassword(t *testing.T) {
+	p, err := encodePassword("secret")
+	require.NoError(t, err)
+	assert.Equal(t, []byte{'s', 'e', 'c', 'r', 'e', 't', 0, 0}, p)
+	_, err = encodePassword("too-long-pw")
+	assert.Error(t, err)
+}
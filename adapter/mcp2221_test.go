@@ -1,7 +1,308 @@
 package adapter
 
-import "testing"
+import (
+	"bytes"
+	"context"
+	"testing"
 
-func Test(t *testing.T) {
+	"github.com/mklimuk/sensors"
+	"github.com/mklimuk/sensors/sim"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
 
+const testAddress = 0x42
+
+// memoryDevice records the writes it receives and answers reads with data.
+type memoryDevice struct {
+	written [][]byte
+	data    []byte
+}
+
+func (m *memoryDevice) Write(w []byte) error {
+	m.written = append(m.written, bytes.Clone(w))
+	return nil
+}
+
+func (m *memoryDevice) Read(r []byte) error {
+	copy(r, m.data)
+	return nil
+}
+
+func newFakeAdapter(t *testing.T, opts ...MCP2221Option) (*MCP2221, *fakeMCP2221, *memoryDevice) {
+	t.Helper()
+	bus := sim.NewBus()
+	dev := &memoryDevice{}
+	bus.Attach(testAddress, dev)
+	fake := newFakeMCP2221(bus)
+	d := NewMCP2221(append([]MCP2221Option{WithTransport(fake)}, opts...)...)
+	require.NoError(t, d.Init())
+	return d, fake, dev
+}
+
+func sequence(n int) []byte {
+	res := make([]byte, n)
+	for i := range res {
+		res[i] = byte(i)
+	}
+	return res
+}
+
+func TestMCP2221_WriteRead(t *testing.T) {
+	ctx := context.Background()
+	d, _, dev := newFakeAdapter(t)
+	dev.data = []byte{0xDE, 0xAD, 0xBE, 0xEF}
+
+	require.NoError(t, d.WriteToAddr(ctx, testAddress, []byte{0x01, 0x02}))
+	r := make([]byte, 4)
+	require.NoError(t, d.ReadFromAddr(ctx, testAddress, r))
+	assert.Equal(t, dev.data, r)
+
+	r = make([]byte, 2)
+	require.NoError(t, sensors.WriteRead(ctx, d, testAddress, []byte{0x03}, r))
+	assert.Equal(t, []byte{0xDE, 0xAD}, r)
+	assert.Equal(t, [][]byte{{0x01, 0x02}, {0x03}}, dev.written)
+}
+
+func TestMCP2221_MultiReportTransfers(t *testing.T) {
+	ctx := context.Background()
+	d, _, dev := newFakeAdapter(t)
+	dev.data = sequence(130)
+
+	w := sequence(150)
+	require.NoError(t, d.WriteToAddr(ctx, testAddress, w))
+	require.Len(t, dev.written, 1)
+	assert.Equal(t, w, dev.written[0])
+
+	r := make([]byte, 130)
+	require.NoError(t, d.ReadFromAddr(ctx, testAddress, r))
+	assert.Equal(t, dev.data, r)
+}
+
+func TestMCP2221_NACK(t *testing.T) {
+	ctx := context.Background()
+	d, _, _ := newFakeAdapter(t)
+
+	err := d.ReadFromAddr(ctx, 0x10, make([]byte, 2))
+	assert.ErrorIs(t, err, ErrI2CNACK)
+	err = d.WriteToAddr(ctx, 0x10, sequence(100))
+	assert.ErrorIs(t, err, ErrI2CNACK)
+	err = d.WriteToAddr(ctx, 0x10, []byte{0x01})
+	assert.ErrorIs(t, err, sensors.ErrNACK)
+	var nack *sensors.NACKError
+	require.ErrorAs(t, err, &nack)
+	assert.Equal(t, byte(0x10), nack.Address)
+}
+
+func TestMCP2221_BusyReleasesBus(t *testing.T) {
+	ctx := context.Background()
+	d, fake, dev := newFakeAdapter(t)
+
+	fake.SetBusy(1)
+	err := d.WriteToAddr(ctx, testAddress, []byte{0x01})
+	assert.ErrorIs(t, err, sensors.ErrBusBusy)
+	assert.Equal(t, 1, fake.Cancels())
+	assert.Empty(t, dev.written)
+
+	fake.SetBusy(1)
+	err = d.ReadFromAddr(ctx, testAddress, make([]byte, 1))
+	assert.ErrorIs(t, err, sensors.ErrBusBusy)
+	assert.Equal(t, 2, fake.Cancels())
+
+	// the register is not read when its address could not be written
+	fake.SetBusy(1)
+	err = d.Tx(ctx, testAddress, []byte{0x01}, make([]byte, 1))
+	assert.ErrorIs(t, err, sensors.ErrBusBusy)
+	assert.Equal(t, 3, fake.Cancels())
+
+	require.NoError(t, d.WriteToAddr(ctx, testAddress, []byte{0x01}))
+	assert.Len(t, dev.written, 1)
+}
+
+func TestMCP2221_StickySession(t *testing.T) {
+	ctx := context.Background()
+	d, fake, _ := newFakeAdapter(t)
+
+	for range 3 {
+		_, err := d.ReadGPIO(ctx)
+		require.NoError(t, err)
+	}
+	assert.Equal(t, 3, fake.Opens())
+
+	require.NoError(t, d.Open(ctx))
+	for range 3 {
+		_, err := d.ReadGPIO(ctx)
+		require.NoError(t, err)
+	}
+	require.NoError(t, d.Close())
+	assert.Equal(t, 4, fake.Opens())
+}
+
+func TestMCP2221_ShortReadInvalidatesHandle(t *testing.T) {
+	ctx := context.Background()
+	d, fake, _ := newFakeAdapter(t)
+	require.NoError(t, d.Open(ctx))
+	defer d.Close()
+
+	fake.ShortReads(1)
+	_, err := d.ReadGPIO(ctx)
+	assert.ErrorContains(t, err, "short read")
+	assert.Equal(t, 1, fake.Opens())
+
+	_, err = d.ReadGPIO(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 2, fake.Opens())
+}
+
+func TestMCP2221_I2CSpeed(t *testing.T) {
+	ctx := context.Background()
+	d, fake, _ := newFakeAdapter(t, WithI2CSpeed(MaxI2CSpeed))
+	expected, err := i2cSpeedDivider(MaxI2CSpeed)
+	require.NoError(t, err)
+
+	require.NoError(t, d.WriteToAddr(ctx, testAddress, []byte{0x01}))
+	assert.Equal(t, expected, fake.Divider())
+
+	require.NoError(t, d.SetI2CSpeed(ctx, 100000))
+	expected, err = i2cSpeedDivider(100000)
+	require.NoError(t, err)
+	assert.Equal(t, expected, fake.Divider())
+}
+
+func TestMCP2221_GPIO(t *testing.T) {
+	ctx := context.Background()
+	d, fake, _ := newFakeAdapter(t)
+
+	require.NoError(t, d.SetGPIOParameters(ctx, MCP2221GPIOParameters{
+		GPIO0Mode: GPIOModeOut,
+		GPIO1Mode: GPIOModeIn,
+		GPIO2Mode: GPIOModeIn,
+		GPIO3Mode: GPIOModeIn,
+	}))
+	require.NoError(t, d.SetGPIO(ctx, GP0, 1))
+	value, output := fake.Output(GP0)
+	assert.True(t, output)
+	assert.Equal(t, byte(1), value)
+
+	fake.SetInput(GP1, 1)
+	values, err := d.ReadGPIO(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, byte(1), values.Value(GP0))
+	assert.Equal(t, byte(1), values.Value(GP1))
+	assert.Equal(t, byte(0), values.Value(GP2))
+
+	// SRAM updates keep the output level
+	require.NoError(t, d.SetDAC(ctx, 0x10))
+	value, output = fake.Output(GP0)
+	assert.True(t, output)
+	assert.Equal(t, byte(1), value)
+
+	err = d.SetClockOutput(ctx, ClockOutput{Frequency: Clock12MHz, Duty: ClockDuty50})
+	require.NoError(t, err)
+	assert.ErrorIs(t, d.SetGPIO(ctx, GP1, 1), ErrPinNotGPIO)
+}
+
+func TestMCP2221_FlashSettings(t *testing.T) {
+	ctx := context.Background()
+	d, _, _ := newFakeAdapter(t)
+
+	require.NoError(t, d.WriteUSBString(ctx, USBProduct, "test bridge"))
+	product, err := d.ReadUSBString(ctx, USBProduct)
+	require.NoError(t, err)
+	assert.Equal(t, "test bridge", product)
+
+	require.NoError(t, d.WriteChipSettings(ctx, func(s *ChipSettings) {
+		s.RequestedMilliAmps = 200
+	}))
+	require.NoError(t, d.SetPassword(ctx, "secret"))
+
+	// a new adapter does not know the password
+	other := NewMCP2221(WithTransport(d.options.Transport))
+	err = other.WriteChipSettings(ctx, func(s *ChipSettings) {})
+	assert.ErrorIs(t, err, ErrChipProtected)
+	assert.ErrorIs(t, other.SendPassword(ctx, "wrong"), ErrPasswordRejected)
+	require.NoError(t, other.SendPassword(ctx, "secret"))
+	settings, err := other.ReadChipSettings(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 200, settings.RequestedMilliAmps)
+	assert.Equal(t, ChipPasswordProtected, settings.Security)
+}
+
+func TestMCP2221_RestoresSettingsAfterUnplug(t *testing.T) {
+	ctx := context.Background()
+	d, fake, _ := newFakeAdapter(t, WithI2CSpeed(MaxI2CSpeed))
+	require.NoError(t, d.Open(ctx))
+	defer d.Close()
+	require.NoError(t, d.SetGPIOParameters(ctx, MCP2221GPIOParameters{GPIO0Mode: GPIOModeOut}))
+	require.NoError(t, d.SetGPIO(ctx, GP0, 1))
+	assert.Equal(t, StatusConnected, d.State())
+
+	fake.Unplug()
+	_, err := d.ReadGPIO(ctx)
+	assert.Error(t, err)
+	assert.Equal(t, StatusConnecting, d.State())
+	fake.Plug()
+	_, output := fake.Output(GP0)
+	require.False(t, output)
+
+	_, err = d.ReadGPIO(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, StatusConnected, d.State())
+	value, output := fake.Output(GP0)
+	assert.True(t, output)
+	assert.Equal(t, byte(1), value)
+	expected, err := i2cSpeedDivider(MaxI2CSpeed)
+	require.NoError(t, err)
+	assert.Equal(t, expected, fake.Divider())
+}
+
+func TestMCP2221_RecoverBus(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("idle bus", func(t *testing.T) {
+		d, fake, _ := newFakeAdapter(t)
+		res, err := d.RecoverBus(ctx)
+		require.NoError(t, err)
+		assert.True(t, res.Before.Idle())
+		assert.True(t, res.After.Idle())
+		assert.False(t, res.AdapterReset)
+		assert.Equal(t, 1, fake.Cancels())
+	})
+
+	t.Run("released by cancel", func(t *testing.T) {
+		d, fake, _ := newFakeAdapter(t)
+		fake.HoldSDA(2)
+		assert.ErrorIs(t, d.WriteToAddr(ctx, testAddress, []byte{0x01}), sensors.ErrBusBusy)
+		fake.HoldSDA(2)
+		res, err := d.RecoverBus(ctx)
+		require.NoError(t, err)
+		assert.False(t, res.Before.SDA)
+		assert.True(t, res.After.Idle())
+		assert.False(t, res.AdapterReset)
+		require.NoError(t, d.WriteToAddr(ctx, testAddress, []byte{0x01}))
+	})
+
+	t.Run("released by reset", func(t *testing.T) {
+		d, fake, _ := newFakeAdapter(t)
+		require.NoError(t, d.SetGPIOParameters(ctx, MCP2221GPIOParameters{GPIO0Mode: GPIOModeOut}))
+		require.NoError(t, d.SetGPIO(ctx, GP0, 1))
+		fake.HoldSDA(recoveryCancels + 1)
+		res, err := d.RecoverBus(ctx)
+		require.NoError(t, err)
+		assert.True(t, res.AdapterReset)
+		assert.True(t, res.After.Idle())
+		assert.Equal(t, 1, fake.Resets())
+		value, output := fake.Output(GP0)
+		assert.True(t, output)
+		assert.Equal(t, byte(1), value)
+	})
+
+	t.Run("stuck", func(t *testing.T) {
+		d, fake, _ := newFakeAdapter(t)
+		fake.HoldSDA(-1)
+		res, err := d.RecoverBus(ctx)
+		assert.ErrorIs(t, err, sensors.ErrBusStuck)
+		assert.True(t, res.AdapterReset)
+		assert.False(t, res.After.SDA)
+	})
 }
@@ -32,7 +32,7 @@ const (
 	statusBitRDY = 0x01
 )
 
-var ErrNotReady = fmt.Errorf("ags02ma: data not ready or sensor in pre-heat stage")
+var ErrNotReady = fmt.Errorf("ags02ma: data not ready or sensor in pre-heat stage: %w", sensors.ErrNotReady)
 
 const (
 	TVOCModeDirectRead    byte = 0x00
@@ -40,6 +40,7 @@ const (
 )
 
 type AGS02MAOpts struct {
+	Address        byte
 	ConfigureDelay time.Duration
 	ReadDelay      time.Duration
 	TxDelay        time.Duration
@@ -48,6 +49,13 @@ type AGS02MAOpts struct {
 
 type AGS02MAOpt func(*AGS02MAOpts)
 
+// WithAddress sets the I2C address of the sensor, 0x1A by default.
+func WithAddress(address byte) AGS02MAOpt {
+	return func(o *AGS02MAOpts) {
+		o.Address = address
+	}
+}
+
 func WithConfigureDelay(delay time.Duration) AGS02MAOpt {
 	return func(o *AGS02MAOpts) {
 		o.ConfigureDelay = delay
@@ -94,6 +102,7 @@ type AGS02MA struct {
 
 func NewAGS02MA(transport sensors.I2CBus, opts ...AGS02MAOpt) *AGS02MA {
 	config := AGS02MAOpts{
+		Address:        ags02maAddress,
 		ConfigureDelay: 2 * time.Second,
 		ReadDelay:      1500 * time.Millisecond,
 		TxDelay:        100 * time.Millisecond,
@@ -108,7 +117,7 @@ func NewAGS02MA(transport sensors.I2CBus, opts ...AGS02MAOpt) *AGS02MA {
 	return &AGS02MA{
 		config:    config,
 		transport: transport,
-		addr:      ags02maAddress,
+		addr:      config.Address,
 		buf:       make([]byte, 5),
 		delayDone: ch, // initially ready (closed channel)
 	}
@@ -242,7 +251,7 @@ func (s *AGS02MA) GetTVOCWithRegisterWrite(ctx context.Context) (uint32, error)
 	}
 	crc := checkCRC(s.buf[:4])
 	if crc != s.buf[4] {
-		return 0, fmt.Errorf("ags02ma: crc mismatch: expected %#x, got %#x", s.buf[4], crc)
+		return 0, fmt.Errorf("ags02ma: %w", &sensors.CRCError{Expected: s.buf[4], Got: crc})
 	}
 	status := s.buf[0]
 	if status&statusBitRDY != 0 {
@@ -259,51 +268,38 @@ func (s *AGS02MA) ReadVersion(ctx context.Context) (int, error) {
 	if err := s.waitForDelay(ctx); err != nil {
 		return 0, err
 	}
-
-	s.mx.Lock()
-	err := s.transport.WriteToAddr(ctx, s.addr, []byte{regVersion})
-	if err != nil {
-		s.mx.Unlock()
-		return 0, fmt.Errorf("ags02ma: write reg 0x11 failed: %w", err)
-	}
-	s.mx.Unlock()
-
-	// Small guard delay; part of the operation sequence, so we wait synchronously.
-	timer := time.NewTimer(s.config.TxDelay)
-	defer timer.Stop()
-	select {
-	case <-timer.C:
-	case <-ctx.Done():
-		return 0, ctx.Err()
-	}
-
-	s.mx.Lock()
-	err = s.transport.ReadFromAddr(ctx, s.addr, s.buf)
-	s.mx.Unlock()
-
+	err := s.readRegister(ctx, regVersion)
 	if err != nil {
-		return 0, fmt.Errorf("ags02ma: read failed: %w", err)
+		return 0, err
 	}
 	crc := checkCRC(s.buf[:4])
 	if crc != s.buf[4] {
-		return 0, fmt.Errorf("ags02ma: crc mismatch: expected %#x, got %#x", s.buf[4], crc)
+		return 0, fmt.Errorf("ags02ma: %w", &sensors.CRCError{Expected: s.buf[4], Got: crc})
 	}
 	return int(s.buf[3]), nil
 }
 
-func (s *AGS02MA) ReadResistance(ctx context.Context) (int, error) {
-	// Wait for any pending delay from previous operations
-	if err := s.waitForDelay(ctx); err != nil {
-		return 0, err
+// readRegister reads the content of reg into s.buf. The sensor needs the
+// configured guard delay to prepare the data after the register pointer
+// write, so the write and the read are joined by a repeated start only when
+// the delay is disabled and the transport supports combined transactions.
+func (s *AGS02MA) readRegister(ctx context.Context, reg byte) error {
+	if tx, ok := s.transport.(sensors.I2CTransactor); ok && s.config.TxDelay <= 0 {
+		s.mx.Lock()
+		err := tx.Tx(ctx, s.addr, []byte{reg}, s.buf)
+		s.mx.Unlock()
+		if err != nil {
+			return fmt.Errorf("ags02ma: read reg %#02x failed: %w", reg, err)
+		}
+		return nil
 	}
 
 	s.mx.Lock()
-	err := s.transport.WriteToAddr(ctx, s.addr, []byte{regResistance})
+	err := s.transport.WriteToAddr(ctx, s.addr, []byte{reg})
+	s.mx.Unlock()
 	if err != nil {
-		s.mx.Unlock()
-		return 0, fmt.Errorf("ags02ma: write reg 0x20 failed: %w", err)
+		return fmt.Errorf("ags02ma: write reg %#02x failed: %w", reg, err)
 	}
-	s.mx.Unlock()
 
 	// Small guard delay; part of the operation sequence, so we wait synchronously.
 	timer := time.NewTimer(s.config.TxDelay)
@@ -311,19 +307,30 @@ func (s *AGS02MA) ReadResistance(ctx context.Context) (int, error) {
 	select {
 	case <-timer.C:
 	case <-ctx.Done():
-		return 0, ctx.Err()
+		return ctx.Err()
 	}
 
 	s.mx.Lock()
 	err = s.transport.ReadFromAddr(ctx, s.addr, s.buf)
 	s.mx.Unlock()
+	if err != nil {
+		return fmt.Errorf("ags02ma: read failed: %w", err)
+	}
+	return nil
+}
 
+func (s *AGS02MA) ReadResistance(ctx context.Context) (int, error) {
+	// Wait for any pending delay from previous operations
+	if err := s.waitForDelay(ctx); err != nil {
+		return 0, err
+	}
+	err := s.readRegister(ctx, regResistance)
 	if err != nil {
-		return 0, fmt.Errorf("ags02ma: read failed: %w", err)
+		return 0, err
 	}
 	crc := checkCRC(s.buf[:4])
 	if crc != s.buf[4] {
-		return 0, fmt.Errorf("ags02ma: crc mismatch: expected %#x, got %#x", s.buf[4], crc)
+		return 0, fmt.Errorf("ags02ma: %w", &sensors.CRCError{Expected: s.buf[4], Got: crc})
 	}
 	// Recommended 1.5 second delay after resistance read (runs asynchronously)
 	s.scheduleDelay(ctx, s.config.ReadDelay)
@@ -335,34 +342,13 @@ func (s *AGS02MA) Calibrate(ctx context.Context) error {
 	if err := s.waitForDelay(ctx); err != nil {
 		return err
 	}
-
-	s.mx.Lock()
-	err := s.transport.WriteToAddr(ctx, s.addr, []byte{regCalibrate})
-	if err != nil {
-		s.mx.Unlock()
-		return fmt.Errorf("ags02ma: write reg 0x01 failed: %w", err)
-	}
-	s.mx.Unlock()
-
-	// Small guard delay; part of the operation sequence, so we wait synchronously.
-	timer := time.NewTimer(s.config.TxDelay)
-	defer timer.Stop()
-	select {
-	case <-timer.C:
-	case <-ctx.Done():
-		return ctx.Err()
-	}
-
-	s.mx.Lock()
-	err = s.transport.ReadFromAddr(ctx, s.addr, s.buf)
-	s.mx.Unlock()
-
+	err := s.readRegister(ctx, regCalibrate)
 	if err != nil {
-		return fmt.Errorf("ags02ma: read failed: %w", err)
+		return err
 	}
 	crc := checkCRC(s.buf[:4])
 	if crc != s.buf[4] {
-		return fmt.Errorf("ags02ma: crc mismatch: expected %#x, got %#x", s.buf[4], crc)
+		return fmt.Errorf("ags02ma: %w", &sensors.CRCError{Expected: s.buf[4], Got: crc})
 	}
 	// Recommended 1.5 second delay after calibrate (runs asynchronously)
 	s.scheduleDelay(ctx, s.config.ReadDelay)
@@ -29,6 +29,14 @@ type I2CBus interface {
 	AddressableWriter
 }
 
+// I2CTransactor is implemented by buses able to perform a combined
+// write-then-read transfer joined by a repeated start condition, so the bus
+// is not released between writing a register pointer and reading its
+// content.
+type I2CTransactor interface {
+	Tx(ctx context.Context, address byte, w, r []byte) error
+}
+
 type I2CDevice interface {
 	BusReader
 	BusWriter
@@ -42,3 +50,21 @@ type AddressLocker interface {
 	LockAddr(addr byte)
 	UnlockAddr(addr byte)
 }
+
+// WriteRead writes w to the device at address and then reads len(r) bytes
+// back. It uses a single repeated-start transaction when the bus implements
+// I2CTransactor and falls back to a separate write and read otherwise.
+func WriteRead(ctx context.Context, bus I2CBus, address byte, w, r []byte) error {
+	if tx, ok := bus.(I2CTransactor); ok {
+		return tx.Tx(ctx, address, w, r)
+	}
+	err := bus.WriteToAddr(ctx, address, w)
+	if err != nil {
+		return fmt.Errorf("could not write to %#x: %w", address, err)
+	}
+	err = bus.ReadFromAddr(ctx, address, r)
+	if err != nil {
+		return fmt.Errorf("could not read from %#x: %w", address, err)
+	}
+	return nil
+}
@@ -0,0 +1,66 @@
+package sensors
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type recordingBus struct {
+	calls []string
+	data  []byte
+	err   error
+}
+
+func (b *recordingBus) WriteToAddr(ctx context.Context, address byte, buffer []byte) error {
+	b.calls = append(b.calls, "write")
+	return b.err
+}
+
+func (b *recordingBus) ReadFromAddr(ctx context.Context, address byte, buffer []byte) error {
+	b.calls = append(b.calls, "read")
+	copy(buffer, b.data)
+	return nil
+}
+
+func (b *recordingBus) Release(ctx context.Context) error {
+	return nil
+}
+
+type transactingBus struct {
+	recordingBus
+}
+
+func (b *transactingBus) Tx(ctx context.Context, address byte, w, r []byte) error {
+	b.calls = append(b.calls, "tx")
+	copy(r, b.data)
+	return nil
+}
+
+func TestWriteRead_FallsBackToWriteAndRead(t *testing.T) {
+	bus := &recordingBus{data: []byte{0x42}}
+	buf := make([]byte, 1)
+	err := WriteRead(context.Background(), bus, 0x10, []byte{0x01}, buf)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"write", "read"}, bus.calls)
+	assert.Equal(t, byte(0x42), buf[0])
+}
+
+func TestWriteRead_PrefersTransaction(t *testing.T) {
+	bus := &transactingBus{recordingBus{data: []byte{0x42}}}
+	buf := make([]byte, 1)
+	err := WriteRead(context.Background(), bus, 0x10, []byte{0x01}, buf)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"tx"}, bus.calls)
+	assert.Equal(t, byte(0x42), buf[0])
+}
+
+func TestWriteRead_WriteErrorSkipsRead(t *testing.T) {
+	writeErr := errors.New("nack")
+	bus := &recordingBus{err: writeErr}
+	err := WriteRead(context.Background(), bus, 0x10, []byte{0x01}, make([]byte, 1))
+	assert.ErrorIs(t, err, writeErr)
+	assert.Equal(t, []string{"write"}, bus.calls)
+}
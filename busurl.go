@@ -0,0 +1,104 @@
+package sensors
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+var ErrUnknownBusScheme = errors.New("unknown bus scheme")
+
+// BusOpener opens the bus described by u. Query values configure the bus,
+// e.g. speed=100k.
+type BusOpener func(ctx context.Context, u *url.URL) (I2CBus, error)
+
+var busOpenersMx sync.RWMutex
+var busOpeners = make(map[string]BusOpener)
+
+// RegisterBus makes the buses of scheme available to OpenBus. It is meant to
+// be called from the init function of the package providing the bus and
+// panics when the scheme is registered twice.
+func RegisterBus(scheme string, open BusOpener) {
+	busOpenersMx.Lock()
+	defer busOpenersMx.Unlock()
+	if _, ok := busOpeners[scheme]; ok {
+		panic(fmt.Sprintf("bus scheme %s registered twice", scheme))
+	}
+	busOpeners[scheme] = open
+}
+
+// BusSchemes returns the sorted list of registered schemes.
+func BusSchemes() []string {
+	busOpenersMx.RLock()
+	defer busOpenersMx.RUnlock()
+	schemes := make([]string, 0, len(busOpeners))
+	for scheme := range busOpeners {
+		schemes = append(schemes, scheme)
+	}
+	slices.Sort(schemes)
+	return schemes
+}
+
+// OpenBus opens the bus identified by rawURL, whose scheme must have been
+// registered by importing the package providing it:
+//
+//	mcp2221://?serial=X&speed=20k   adapter package
+//	cp2112://?serial=X&speed=100k   adapter package
+//	i2cdev:///dev/i2c-1?speed=100k  i2c package
+//	sim://scenario.yaml             sim package
+//	tcp://host:7777?token=X         remote package
+//
+// The caller must close the returned bus when it implements io.Closer.
+func OpenBus(ctx context.Context, rawURL string) (I2CBus, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid bus url %q: %w", rawURL, err)
+	}
+	busOpenersMx.RLock()
+	open, ok := busOpeners[u.Scheme]
+	busOpenersMx.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("%w %q, expected one of %s", ErrUnknownBusScheme, u.Scheme, strings.Join(BusSchemes(), ", "))
+	}
+	bus, err := open(ctx, u)
+	if err != nil {
+		return nil, fmt.Errorf("could not open %s bus: %w", u.Scheme, err)
+	}
+	return bus, nil
+}
+
+// CheckBusQuery returns an error naming the first query value of u not in
+// allowed, so that misspelled options are not silently ignored.
+func CheckBusQuery(u *url.URL, allowed ...string) error {
+	for key := range u.Query() {
+		if !slices.Contains(allowed, key) {
+			return fmt.Errorf("unknown option %q, expected one of %s", key, strings.Join(allowed, ", "))
+		}
+	}
+	return nil
+}
+
+// ParseSpeed parses a bus clock frequency given in Hz, optionally with a k or
+// M multiplier and a Hz suffix, e.g. 400000, 100k or 1MHz.
+func ParseSpeed(value string) (int, error) {
+	s := strings.TrimSuffix(strings.ToLower(strings.TrimSpace(value)), "hz")
+	multiplier := 1.0
+	switch {
+	case strings.HasSuffix(s, "k"):
+		multiplier = 1e3
+		s = strings.TrimSuffix(s, "k")
+	case strings.HasSuffix(s, "m"):
+		multiplier = 1e6
+		s = strings.TrimSuffix(s, "m")
+	}
+	f, err := strconv.ParseFloat(s, 64)
+	if err != nil || f <= 0 {
+		return 0, fmt.Errorf("invalid speed %q, expected Hz like 100000 or 100k", value)
+	}
+	return int(f * multiplier), nil
+}
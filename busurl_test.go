@@ -0,0 +1,55 @@
+package sensors
+
+import (
+	"context"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOpenBus(t *testing.T) {
+	var opened *url.URL
+	RegisterBus("test", func(ctx context.Context, u *url.URL) (I2CBus, error) {
+		err := CheckBusQuery(u, "speed")
+		if err != nil {
+			return nil, err
+		}
+		opened = u
+		return &recordingBus{}, nil
+	})
+	assert.Panics(t, func() {
+		RegisterBus("test", nil)
+	})
+	assert.Contains(t, BusSchemes(), "test")
+
+	bus, err := OpenBus(context.Background(), "test:///dev/bus?speed=100k")
+	require.NoError(t, err)
+	assert.IsType(t, &recordingBus{}, bus)
+	assert.Equal(t, "/dev/bus", opened.Path)
+	assert.Equal(t, "100k", opened.Query().Get("speed"))
+
+	_, err = OpenBus(context.Background(), "test://?sped=100k")
+	assert.ErrorContains(t, err, "sped")
+	_, err = OpenBus(context.Background(), "unknown://")
+	assert.ErrorIs(t, err, ErrUnknownBusScheme)
+}
+
+func TestParseSpeed(t *testing.T) {
+	for value, hz := range map[string]int{
+		"400000": 400_000,
+		"100k":   100_000,
+		"20kHz":  20_000,
+		"1M":     1_000_000,
+		"1.5MHz": 1_500_000,
+	} {
+		got, err := ParseSpeed(value)
+		require.NoError(t, err, value)
+		assert.Equal(t, hz, got, value)
+	}
+	for _, value := range []string{"", "fast", "-100k", "k"} {
+		_, err := ParseSpeed(value)
+		assert.Error(t, err, value)
+	}
+}
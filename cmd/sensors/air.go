@@ -9,10 +9,11 @@ import (
 	chlog "github.com/charmbracelet/log"
 	"github.com/urfave/cli/v2"
 
+	"github.com/mklimuk/sensors"
 	"github.com/mklimuk/sensors/adapter"
 	"github.com/mklimuk/sensors/air"
+	"github.com/mklimuk/sensors/cmd/sensors/command"
 	"github.com/mklimuk/sensors/cmd/sensors/console"
-	"github.com/mklimuk/sensors/i2c"
 	"github.com/mklimuk/sensors/snsctx"
 )
 
@@ -29,14 +30,6 @@ var airCmd = cli.Command{
 var airVersionCmd = cli.Command{
 	Name: "version",
 	Flags: []cli.Flag{
-		&cli.StringFlag{
-			Name:  "adapter,a",
-			Value: "mcp2221",
-		},
-		&cli.StringFlag{
-			Name:  "device,d",
-			Value: "/dev/i2c-1",
-		},
 		&cli.BoolFlag{Name: "verbose,v"},
 	},
 	Action: func(c *cli.Context) error {
@@ -52,30 +45,12 @@ var airVersionCmd = cli.Command{
 		}
 		slog.SetDefault(slog.New(charm))
 
-		var s *air.AGS02MA
-		switch c.String("adapter") {
-		case "mcp2221":
-			ad := adapter.NewMCP2221()
-			if err := ad.Init(); err != nil {
-				return console.Exit(1, "adapter initialization error: %s", console.Red(err))
-			}
-			s = air.NewAGS02MA(ad)
-		case "generic":
-			fallthrough
-		case "nanopi":
-			bus, err := i2c.NewGenericBus(c.String("device"))
-			if err != nil {
-				return console.Exit(1, "adapter initialization error: %s", console.Red(err))
-			}
-			defer func() {
-				err := bus.Close()
-				if err != nil {
-					console.Errorf("error closing bus: %s", console.Red(err))
-				}
-			}()
-			bus.SetSpeed(20) // 20 kHz
-			s = air.NewAGS02MA(bus)
+		bus, closeBus, err := airBusFromContext(c)
+		if err != nil {
+			return err
 		}
+		defer closeBus()
+		s := air.NewAGS02MA(bus)
 		ver, err := s.ReadVersion(ctx)
 		if err != nil {
 			return console.Exit(1, "error reading version: %s", console.Red(err))
@@ -88,14 +63,6 @@ var airVersionCmd = cli.Command{
 var airCalibrateCmd = cli.Command{
 	Name: "calibrate",
 	Flags: []cli.Flag{
-		&cli.StringFlag{
-			Name:  "adapter,a",
-			Value: "mcp2221",
-		},
-		&cli.StringFlag{
-			Name:  "device,d",
-			Value: "/dev/i2c-1",
-		},
 		&cli.BoolFlag{Name: "verbose,v"},
 	},
 	Action: func(c *cli.Context) error {
@@ -111,31 +78,13 @@ var airCalibrateCmd = cli.Command{
 		}
 		slog.SetDefault(slog.New(charm))
 
-		var s *air.AGS02MA
-		switch c.String("adapter") {
-		case "mcp2221":
-			ad := adapter.NewMCP2221()
-			if err := ad.Init(); err != nil {
-				return console.Exit(1, "adapter initialization error: %s", console.Red(err))
-			}
-			s = air.NewAGS02MA(ad)
-		case "generic":
-			fallthrough
-		case "nanopi":
-			bus, err := i2c.NewGenericBus(c.String("device"))
-			if err != nil {
-				return console.Exit(1, "adapter initialization error: %s", console.Red(err))
-			}
-			defer func() {
-				err := bus.Close()
-				if err != nil {
-					console.Errorf("error closing bus: %s", console.Red(err))
-				}
-			}()
-			bus.SetSpeed(20) // 20 kHz
-			s = air.NewAGS02MA(bus)
+		bus, closeBus, err := airBusFromContext(c)
+		if err != nil {
+			return err
 		}
-		err := s.Configure(ctx)
+		defer closeBus()
+		s := air.NewAGS02MA(bus)
+		err = s.Configure(ctx)
 		if err != nil {
 			return console.Exit(1, "error configuring: %s", console.Red(err))
 		}
@@ -152,14 +101,6 @@ var airCalibrateCmd = cli.Command{
 var airReadTvocCmd = cli.Command{
 	Name: "tvoc",
 	Flags: []cli.Flag{
-		&cli.StringFlag{
-			Name:  "adapter,a",
-			Value: "mcp2221",
-		},
-		&cli.StringFlag{
-			Name:  "device,d",
-			Value: "/dev/i2c-1",
-		},
 		&cli.StringFlag{
 			Name:  "mode,m",
 			Value: "register-write",
@@ -189,32 +130,14 @@ var airReadTvocCmd = cli.Command{
 		}
 		slog.SetDefault(slog.New(charm))
 
-		var s *air.AGS02MA
-		switch c.String("adapter") {
-		case "mcp2221":
-			ad := adapter.NewMCP2221()
-			if err := ad.Init(); err != nil {
-				return console.Exit(1, "adapter initialization error: %s", console.Red(err))
-			}
-			s = air.NewAGS02MA(ad, air.WithTVOCMode(mode))
-		case "generic":
-			fallthrough
-		case "nanopi":
-			bus, err := i2c.NewGenericBus(c.String("device"))
-			if err != nil {
-				return console.Exit(1, "adapter initialization error: %s", console.Red(err))
-			}
-			defer func() {
-				err := bus.Close()
-				if err != nil {
-					console.Errorf("error closing bus: %s", console.Red(err))
-				}
-			}()
-			bus.SetSpeed(20) // 20 kHz
-			s = air.NewAGS02MA(bus, air.WithTVOCMode(mode))
+		bus, closeBus, err := airBusFromContext(c)
+		if err != nil {
+			return err
 		}
+		defer closeBus()
+		s := air.NewAGS02MA(bus, air.WithTVOCMode(mode))
 
-		err := s.Configure(ctx)
+		err = s.Configure(ctx)
 		if err != nil {
 			return console.Exit(1, "error configuring: %s", console.Red(err))
 		}
@@ -232,14 +155,6 @@ var airReadTvocCmd = cli.Command{
 var airReadResistanceCmd = cli.Command{
 	Name: "resistance",
 	Flags: []cli.Flag{
-		&cli.StringFlag{
-			Name:  "adapter,a",
-			Value: "mcp2221",
-		},
-		&cli.StringFlag{
-			Name:  "device,d",
-			Value: "/dev/i2c-1",
-		},
 		&cli.BoolFlag{Name: "verbose,v"},
 	},
 	Action: func(c *cli.Context) error {
@@ -255,37 +170,14 @@ var airReadResistanceCmd = cli.Command{
 		}
 		slog.SetDefault(slog.New(charm))
 
-		var s *air.AGS02MA
-		switch c.String("adapter") {
-		case "mcp2221":
-			ad := adapter.NewMCP2221()
-			if err := ad.Init(); err != nil {
-				return console.Exit(1, "adapter initialization error: %s", console.Red(err))
-			}
-			s = air.NewAGS02MA(ad)
-			ppb, err := s.GetTVOC(ctx)
-			if err != nil {
-				return console.Exit(1, "error getting TVOC read: %s", console.Red(err))
-			}
-			console.Printf("%d ppb\n", ppb)
-		case "generic":
-			fallthrough
-		case "nanopi":
-			bus, err := i2c.NewGenericBus(c.String("device"))
-			if err != nil {
-				return console.Exit(1, "adapter initialization error: %s", console.Red(err))
-			}
-			defer func() {
-				err := bus.Close()
-				if err != nil {
-					console.Errorf("error closing bus: %s", console.Red(err))
-				}
-			}()
-			bus.SetSpeed(20) // 20 kHz
-			s = air.NewAGS02MA(bus)
+		bus, closeBus, err := airBusFromContext(c)
+		if err != nil {
+			return err
 		}
+		defer closeBus()
+		s := air.NewAGS02MA(bus)
 
-		err := s.Configure(ctx)
+		err = s.Configure(ctx)
 		if err != nil {
 			return console.Exit(1, "error configuring: %s", console.Red(err))
 		}
@@ -299,3 +191,14 @@ var airReadResistanceCmd = cli.Command{
 		return nil
 	},
 }
+
+// airBusFromContext runs the bus at 20 kHz unless a speed is given, as the
+// AGS02MA requires 30 kHz or less. The mcp2221 runs at the slowest clock it
+// supports instead.
+func airBusFromContext(c *cli.Context) (sensors.I2CBus, func(), error) {
+	speed := 20_000
+	if command.BusScheme(c) == "mcp2221" {
+		speed = adapter.MinI2CSpeed
+	}
+	return command.OpenBus(c, speed)
+}
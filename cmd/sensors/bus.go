@@ -0,0 +1,14 @@
+package main
+
+import (
+	"github.com/urfave/cli/v2"
+
+	"github.com/mklimuk/sensors"
+	"github.com/mklimuk/sensors/cmd/sensors/command"
+)
+
+// busFromContext opens the bus selected with the global bus flag. The
+// returned function releases the bus and must always be called.
+func busFromContext(c *cli.Context) (sensors.I2CBus, func(), error) {
+	return command.OpenBus(c, 0)
+}
@@ -0,0 +1,42 @@
+package command
+
+import (
+	"fmt"
+
+	"github.com/mklimuk/sensors/adapter"
+	"github.com/mklimuk/sensors/gobotadaptor"
+	"github.com/urfave/cli/v2"
+	"gobot.io/x/gobot/v2"
+	"gobot.io/x/gobot/v2/drivers/i2c"
+)
+
+// i2cAdaptor is a Gobot adaptor with an I2C bus.
+type i2cAdaptor interface {
+	gobot.Adaptor
+	i2c.Connector
+}
+
+// i2cAdaptorFromContext connects a Gobot adaptor over the bus selected with
+// the global bus flag. The returned function finalizes it and must always be
+// called.
+func i2cAdaptorFromContext(c *cli.Context) (i2cAdaptor, func(), error) {
+	bus, closeBus, err := OpenBus(c, 0)
+	if err != nil {
+		return nil, nil, err
+	}
+	var a *gobotadaptor.Adaptor
+	if bridge, ok := bus.(*adapter.MCP2221); ok {
+		a = gobotadaptor.NewMCP2221Adaptor(bridge)
+	} else {
+		a = gobotadaptor.NewAdaptor(gobotadaptor.WithI2C(bus))
+	}
+	err = a.Connect()
+	if err != nil {
+		closeBus()
+		return nil, nil, fmt.Errorf("adaptor connect error: %w", err)
+	}
+	return a, func() {
+		_ = a.Finalize()
+		closeBus()
+	}, nil
+}
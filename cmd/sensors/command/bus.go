@@ -0,0 +1,70 @@
+package command
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/url"
+
+	"github.com/urfave/cli/v2"
+
+	"github.com/mklimuk/sensors"
+	"github.com/mklimuk/sensors/cmd/sensors/console"
+	// register the bus schemes
+	_ "github.com/mklimuk/sensors/adapter"
+	_ "github.com/mklimuk/sensors/i2c"
+	_ "github.com/mklimuk/sensors/remote"
+	_ "github.com/mklimuk/sensors/sim"
+)
+
+// BusURLHelp lists the bus urls accepted by the global bus flag.
+const BusURLHelp = "bus url: mcp2221://?serial=X&speed=100k, cp2112://?serial=X, i2cdev:///dev/i2c-1?speed=100k, sim://scenario.yaml or tcp://host:7777?token=X"
+
+// OpenBus opens the bus selected with the global bus flag, completing its
+// url with the global adapter-serial and i2c-speed flags and then with
+// defaultSpeed (in Hz, 0 keeps the bus default) when it does not set them.
+func OpenBus(c *cli.Context, defaultSpeed int) (sensors.I2CBus, func(), error) {
+	u, err := url.Parse(c.String("bus"))
+	if err != nil {
+		return nil, nil, console.Exit(1, "invalid bus url: %s", console.Red(err))
+	}
+	q := u.Query()
+	switch u.Scheme {
+	case "mcp2221", "cp2112":
+		if serial := c.String("adapter-serial"); serial != "" && !q.Has("serial") {
+			q.Set("serial", serial)
+		}
+		fallthrough
+	case "i2cdev":
+		speed := c.Int("i2c-speed")
+		if speed == 0 {
+			speed = defaultSpeed
+		}
+		if speed != 0 && !q.Has("speed") {
+			q.Set("speed", fmt.Sprint(speed))
+		}
+	}
+	u.RawQuery = q.Encode()
+	bus, err := sensors.OpenBus(context.Background(), u.String())
+	if err != nil {
+		return nil, nil, console.Exit(1, "adapter initialization error: %s", console.Red(err))
+	}
+	closer, ok := bus.(io.Closer)
+	if !ok {
+		return bus, func() {}, nil
+	}
+	return bus, func() {
+		if err := closer.Close(); err != nil {
+			console.Errorf("error closing bus: %s", console.Red(err))
+		}
+	}, nil
+}
+
+// BusScheme returns the scheme of the global bus flag.
+func BusScheme(c *cli.Context) string {
+	u, err := url.Parse(c.String("bus"))
+	if err != nil {
+		return ""
+	}
+	return u.Scheme
+}
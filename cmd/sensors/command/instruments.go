@@ -9,7 +9,6 @@ import (
 	"github.com/mklimuk/sensors/cmd/sensors/console"
 	"github.com/urfave/cli/v2"
 	"gobot.io/x/gobot/v2/drivers/i2c"
-	"gobot.io/x/gobot/v2/platforms/friendlyelec/nanopi"
 )
 
 // MCP4661-103 I2C addresses (example, update as needed)
@@ -19,14 +18,13 @@ var PotentiometerGetCmd = &cli.Command{
 	Name:  "get",
 	Usage: "get potentiometer values",
 	Action: func(c *cli.Context) error {
-		npi := nanopi.NewNeoAdaptor()
-		err := npi.I2cBusAdaptor.Connect()
+		adaptor, closeAdaptor, err := i2cAdaptorFromContext(c)
 		if err != nil {
-			return fmt.Errorf("adaptor connect error: %w", err)
+			return err
 		}
-		defer npi.I2cBusAdaptor.Finalize()
+		defer closeAdaptor()
 		for i, addr := range mcp4661Addresses {
-			val, err := getKnobValue(npi, addr)
+			val, err := getKnobValue(adaptor, addr)
 			if err != nil {
 				slog.Error("knob read error", "knob", i, "addr", addr, "error", err)
 				continue
@@ -38,9 +36,7 @@ var PotentiometerGetCmd = &cli.Command{
 }
 
 func getKnobValue(adaptor i2c.Connector, addr uint8) (uint16, error) {
-	board := i2c.NewGenericDriver(adaptor, "mcp4661", int(addr), func(c i2c.Config) {
-		c.SetBus(2)
-	})
+	board := i2c.NewGenericDriver(adaptor, "mcp4661", int(addr))
 	err := board.Start()
 	if err != nil {
 		return 0, fmt.Errorf("start error: %v", err)
@@ -101,17 +97,14 @@ var PotentiometerSetCmd = &cli.Command{
 		if err != nil || val < 0 || val > 255 {
 			return fmt.Errorf("invalid value: %d", val)
 		}
-		npi := nanopi.NewNeoAdaptor()
-		err = npi.I2cBusAdaptor.Connect()
+		adaptor, closeAdaptor, err := i2cAdaptorFromContext(c)
 		if err != nil {
-			return fmt.Errorf("adaptor connect error: %w", err)
+			return err
 		}
-		defer npi.I2cBusAdaptor.Finalize()
+		defer closeAdaptor()
 
 		addr := mcp4661Addresses[knobIdx]
-		board := i2c.NewGenericDriver(npi, "mcp4661", int(addr), func(c i2c.Config) {
-			c.SetBus(2)
-		})
+		board := i2c.NewGenericDriver(adaptor, "mcp4661", int(addr))
 		err = board.Start()
 		if err != nil {
 			return fmt.Errorf("knob %d (addr %#x) start error: %v", knobIdx, addr, err)
@@ -1,14 +1,18 @@
 package command
 
 import (
+	"context"
 	"encoding/hex"
 	"fmt"
 
+	"github.com/mklimuk/sensors/adapter"
+	eeprom "github.com/mklimuk/sensors/memory/25aa1024"
 	"github.com/urfave/cli/v2"
-	"gobot.io/x/gobot/v2/drivers/spi"
 	"gobot.io/x/gobot/v2/platforms/friendlyelec/nanopi"
 )
 
+const memoryCapacity = 0x20000
+
 var MemoryReadCmd = &cli.Command{
 	Name:  "read",
 	Usage: "read amplifier DSP memory",
@@ -18,24 +22,24 @@ var MemoryReadCmd = &cli.Command{
 		&cli.IntFlag{Name: "length", Usage: "number of bytes to read", Value: 16},
 	},
 	Action: func(c *cli.Context) error {
-		adaptor := nanopi.NewNeoAdaptor()
-		spiDev := spi.NewDriver(adaptor, "spi")
-		err := spiDev.Start()
-		if err != nil {
-			return fmt.Errorf("SPI device start error: %w", err)
-		}
 		addr := c.Int("address")
 		length := c.Int("length")
-		if addr < 0 || addr > 0x1FFFF {
-			fmt.Println("Address out of range (0-0x1FFFF)")
-			return nil
+		if addr < 0 || addr >= memoryCapacity {
+			return fmt.Errorf("address out of range: %d", addr)
 		}
 		if length <= 0 || length > 256 {
 			return fmt.Errorf("length out of range: %d", length)
 		}
-		// 25AA1024 read: 0x03 [addr_hi] [addr_mid] [addr_lo]
-		cmd := []byte{0x03, byte(addr >> 16), byte(addr >> 8), byte(addr)}
-		fmt.Println(hex.Dump(cmd))
+		mem, closeMemory, err := memoryFromContext(c)
+		if err != nil {
+			return err
+		}
+		defer closeMemory()
+		data, err := mem.Read(uint32(addr), length)
+		if err != nil {
+			return fmt.Errorf("memory read error: %w", err)
+		}
+		fmt.Print(hex.Dump(data))
 		return nil
 	},
 }
@@ -49,42 +53,88 @@ var MemoryWriteCmd = &cli.Command{
 		&cli.StringFlag{Name: "data", Usage: "hex bytes to write (e.g. '01FF23')", Required: true},
 	},
 	Action: func(c *cli.Context) error {
-		adaptor := nanopi.NewNeoAdaptor()
-		spiDev := spi.NewDriver(adaptor, "spi")
-		err := spiDev.Start()
-		if err != nil {
-			return fmt.Errorf("SPI device start error: %w", err)
-		}
 		addr := c.Int("address")
-		if addr < 0 || addr > 0x1FFFF {
+		if addr < 0 || addr >= memoryCapacity {
 			return fmt.Errorf("address out of range: %d", addr)
 		}
 		data, err := hexStringToBytes(c.String("data"))
 		if err != nil {
 			return fmt.Errorf("invalid data hex string: %w", err)
 		}
-		// 25AA1024 write enable: 0x06
-		/*err = spiDev.Write([]byte{0x06})
+		mem, closeMemory, err := memoryFromContext(c)
 		if err != nil {
-			return fmt.Errorf("SPI write enable error: %w", err)
+			return err
 		}
-		// 25AA1024 write: 0x02 [addr_hi] [addr_mid] [addr_lo] [data...]
-		cmd := []byte{0x02, byte(addr >> 16), byte(addr >> 8), byte(addr)}
-		cmd = append(cmd, data...)
-		if err := spiDev.Write(cmd); err != nil {
-			return fmt.Errorf("SPI write error: %w", err)
+		defer closeMemory()
+		err = mem.Write(uint32(addr), data)
+		if err != nil {
+			return fmt.Errorf("memory write error: %w", err)
 		}
 		fmt.Printf("Wrote %d bytes to DSP memory 0x%05X: % X\n", len(data), addr, data)
-		return nil*/
-		fmt.Println(hex.Dump(data))
 		return nil
 	},
 }
 
+// memoryFromContext opens the memory on the SPI bus selected by the memory
+// command flags: directly over an MCP2210 bridge or through the Gobot NanoPi
+// adaptor. The returned function releases it and must always be called.
+func memoryFromContext(c *cli.Context) (*eeprom.EEPROM25AA1024, func(), error) {
+	if device := c.String("device"); device != "25AA1024" {
+		return nil, nil, fmt.Errorf("unsupported memory device: %s", device)
+	}
+	switch c.String("spi") {
+	case "mcp2210":
+		cs := c.Int("chip-select")
+		if cs < 0 || cs >= adapter.MCP2210Pins {
+			return nil, nil, fmt.Errorf("invalid chip select pin: %d", cs)
+		}
+		opts := []adapter.MCP2210Option{adapter.WithChipSelect(adapter.MCP2210Pin(cs))}
+		if serial := c.String("adapter-serial"); serial != "" {
+			opts = append(opts, adapter.WithMCP2210Serial(serial))
+		}
+		bridge := adapter.NewMCP2210(opts...)
+		err := bridge.Open(context.Background())
+		if err != nil {
+			return nil, nil, fmt.Errorf("mcp2210 open error: %w", err)
+		}
+		return eeprom.NewWithBus(bridge), func() { _ = bridge.Close() }, nil
+	case "nanopi":
+		adaptor := nanopi.NewNeoAdaptor()
+		err := adaptor.Connect()
+		if err != nil {
+			return nil, nil, fmt.Errorf("adaptor connect error: %w", err)
+		}
+		mem := eeprom.New(adaptor, "spi", 0)
+		err = mem.Start()
+		if err != nil {
+			_ = adaptor.Finalize()
+			return nil, nil, fmt.Errorf("SPI device start error: %w", err)
+		}
+		return mem, func() {
+			_ = mem.Halt()
+			_ = adaptor.Finalize()
+		}, nil
+	default:
+		return nil, nil, fmt.Errorf("unknown spi bus: %s", c.String("spi"))
+	}
+}
+
 var MemoryCmd = &cli.Command{
 	Name:    "memory",
 	Aliases: []string{"mem"},
 	Usage:   "memory-related operations",
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:  "spi",
+			Value: "mcp2210",
+			Usage: "spi bus: mcp2210 (USB bridge) or nanopi (board spi through Gobot)",
+		},
+		&cli.IntFlag{
+			Name:  "chip-select",
+			Value: 0,
+			Usage: "mcp2210 pin used as the chip select",
+		},
+	},
 	Subcommands: []*cli.Command{
 		MemoryReadCmd,
 		MemoryWriteCmd,
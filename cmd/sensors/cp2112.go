@@ -0,0 +1,154 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/mklimuk/sensors/adapter"
+	"github.com/mklimuk/sensors/cmd/sensors/console"
+	"github.com/mklimuk/sensors/snsctx"
+	"github.com/urfave/cli/v2"
+	"gopkg.in/yaml.v3"
+)
+
+// cp2112Options returns the options selecting and configuring the bridge
+// from the global adapter-serial and i2c-speed flags.
+func cp2112Options(c *cli.Context) []adapter.CP2112Option {
+	var opts []adapter.CP2112Option
+	if serial := c.String("adapter-serial"); serial != "" {
+		opts = append(opts, adapter.WithCP2112Serial(serial))
+	}
+	if speed := c.Int("i2c-speed"); speed != 0 {
+		opts = append(opts, adapter.WithCP2112Speed(speed))
+	}
+	return opts
+}
+
+var cp2112Cmd = cli.Command{
+	Name: "cp2112",
+	Subcommands: cli.Commands{
+		&cp2112StatusCmd,
+		&cp2112ReleaseCmd,
+		&cp2112GPIOCmd,
+	},
+}
+
+var cp2112StatusCmd = cli.Command{
+	Name:        "status",
+	Description: "print the chip version, SMBus and GPIO configuration",
+	Action: func(c *cli.Context) error {
+		a := adapter.NewCP2112(cp2112Options(c)...)
+		if err := a.Open(context.Background()); err != nil {
+			return console.Exit(1, "could not open adapter: %s", console.Red(err))
+		}
+		defer func() { _ = a.Close() }()
+		version, err := a.Version()
+		if err != nil {
+			return console.Exit(1, "adapter communication error: %s", console.Red(err))
+		}
+		smbus, err := a.SMBusConfig()
+		if err != nil {
+			return console.Exit(1, "adapter communication error: %s", console.Red(err))
+		}
+		gpio, err := a.GPIOConfig()
+		if err != nil {
+			return console.Exit(1, "adapter communication error: %s", console.Red(err))
+		}
+		enc := yaml.NewEncoder(os.Stdout)
+		for _, v := range []any{version, smbus, gpio} {
+			err = enc.Encode(v)
+			if err != nil {
+				return console.Exit(1, "encoding error: %s", console.Red(err))
+			}
+		}
+		return nil
+	},
+}
+
+var cp2112ReleaseCmd = cli.Command{
+	Name:        "release",
+	Description: "cancel the pending transfer",
+	Flags: []cli.Flag{
+		&cli.BoolFlag{Name: "verbose,v"},
+	},
+	Action: func(c *cli.Context) error {
+		a := adapter.NewCP2112(cp2112Options(c)...)
+		ctx := snsctx.SetVerbose(context.Background(), c.Bool("verbose"))
+		if err := a.Release(ctx); err != nil {
+			return console.Exit(1, "adapter communication error: %s", console.Red(err))
+		}
+		return nil
+	},
+}
+
+var cp2112GPIOCmd = cli.Command{
+	Name: "gpio",
+	Subcommands: cli.Commands{
+		&cp2112GPIOReadCmd,
+		&cp2112GPIOSetCmd,
+	},
+}
+
+var cp2112GPIOReadCmd = cli.Command{
+	Name: "read",
+	Action: func(c *cli.Context) error {
+		a := adapter.NewCP2112(cp2112Options(c)...)
+		values, err := a.ReadGPIO()
+		if err != nil {
+			return console.Exit(1, "could not read values: %s", console.Red(err))
+		}
+		for pin := range 8 {
+			console.Printf("GPIO.%d: %d\n", pin, values>>pin&1)
+		}
+		return nil
+	},
+}
+
+var cp2112GPIOSetCmd = cli.Command{
+	Name:      "set",
+	ArgsUsage: "<pin 0-7> <value 0|1>",
+	Flags: []cli.Flag{
+		&cli.BoolFlag{Name: "open-drain", Usage: "drive the pin as open-drain instead of push-pull"},
+	},
+	Action: func(c *cli.Context) error {
+		if c.NArg() != 2 {
+			return console.Exit(1, "expected pin and value arguments")
+		}
+		pin, err := strconv.Atoi(c.Args().Get(0))
+		if err != nil || pin < 0 || pin > 7 {
+			return console.Exit(1, "invalid pin: %s", console.Red(c.Args().Get(0)))
+		}
+		value, err := strconv.Atoi(c.Args().Get(1))
+		if err != nil || (value != 0 && value != 1) {
+			return console.Exit(1, "invalid value: %s", console.Red(c.Args().Get(1)))
+		}
+		a := adapter.NewCP2112(cp2112Options(c)...)
+		if err := a.Open(context.Background()); err != nil {
+			return console.Exit(1, "could not open adapter: %s", console.Red(err))
+		}
+		defer func() { _ = a.Close() }()
+		cfg, err := a.GPIOConfig()
+		if err != nil {
+			return console.Exit(1, "could not read configuration: %s", console.Red(err))
+		}
+		mask := byte(1) << pin
+		cfg.Direction |= mask
+		if c.Bool("open-drain") {
+			cfg.PushPull &^= mask
+		} else {
+			cfg.PushPull |= mask
+		}
+		err = a.SetGPIOConfig(cfg)
+		if err != nil {
+			return console.Exit(1, "could not configure pin: %s", console.Red(err))
+		}
+		err = a.WriteGPIO(byte(value)<<pin, mask)
+		if err != nil {
+			return console.Exit(1, "could not set value: %s", console.Red(err))
+		}
+		console.Printf("GPIO.%d set to %s\n", pin, console.Green(fmt.Sprint(value)))
+		return nil
+	},
+}
@@ -36,7 +36,7 @@ var gpioReadCmd = cli.Command{
 	Action: func(c *cli.Context) error {
 		ctx := snsctx.SetVerbose(context.Background(), c.Bool("verbose"))
 		addr := c.Int("address")
-		mcp2221 := adapter.NewMCP2221()
+		mcp2221 := adapter.NewMCP2221(mcp2221Options(c)...)
 		err := mcp2221.Init()
 		if err != nil {
 			return console.Exit(1, "adapter initialization error: %s", console.Red(err))
@@ -74,7 +74,7 @@ var gpioStatusCmd = cli.Command{
 	},
 	Action: func(c *cli.Context) error {
 		addr := c.Int("address")
-		a := adapter.NewMCP2221()
+		a := adapter.NewMCP2221(mcp2221Options(c)...)
 		err := a.Init()
 		if err != nil {
 			return console.Exit(1, "adapter initialization error: %s", console.Red(err))
@@ -110,7 +110,7 @@ var gpioConfigureCmd = cli.Command{
 		if err != nil {
 			return console.Exit(1, "could not decode data: %v", err)
 		}
-		a := adapter.NewMCP2221()
+		a := adapter.NewMCP2221(mcp2221Options(c)...)
 		err = a.Init()
 		if err != nil {
 			return console.Exit(1, "adapter initialization error: %s", console.Red(err))
@@ -146,7 +146,7 @@ var gpioPullCmd = cli.Command{
 		if err != nil {
 			return console.Exit(1, "could not decode data: %v", err)
 		}
-		a := adapter.NewMCP2221()
+		a := adapter.NewMCP2221(mcp2221Options(c)...)
 		err = a.Init()
 		if err != nil {
 			return console.Exit(1, "adapter initialization error: %s", console.Red(err))
@@ -0,0 +1,164 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/urfave/cli/v2"
+	"periph.io/x/conn/v3/gpio/gpioreg"
+
+	"github.com/mklimuk/sensors"
+	"github.com/mklimuk/sensors/cmd/sensors/console"
+	"github.com/mklimuk/sensors/i2c"
+	"github.com/mklimuk/sensors/scanner"
+	"github.com/mklimuk/sensors/snsctx"
+)
+
+var i2cCmd = cli.Command{
+	Name:  "i2c",
+	Usage: "generic i2c bus operations",
+	Subcommands: cli.Commands{
+		&i2cScanCmd,
+		&i2cRecoverCmd,
+	},
+}
+
+var i2cScanCmd = cli.Command{
+	Name:  "scan",
+	Usage: "probe the bus for devices and identify known chips",
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:  "mode",
+			Value: "write",
+			Usage: "presence probe: write (zero-length write, a read on adapters without them) or read (single byte read)",
+		},
+		&cli.BoolFlag{Name: "json", Usage: "print results as JSON"},
+		&cli.BoolFlag{Name: "verbose", Aliases: []string{"v"}},
+	},
+	Action: func(c *cli.Context) error {
+		bus, closeBus, err := busFromContext(c)
+		if err != nil {
+			return err
+		}
+		defer closeBus()
+		var mode scanner.ProbeMode
+		switch c.String("mode") {
+		case "read":
+			mode = scanner.ProbeRead
+		case "write":
+			mode = scanner.ProbeWrite
+		default:
+			return console.Exit(1, "invalid probe mode: %s", console.Red(c.String("mode")))
+		}
+		if a, ok := bus.(interface {
+			Open(context.Context) error
+			Close() error
+		}); ok {
+			// keep one HID handle for the ~112 probes of the scan
+			if err := a.Open(c.Context); err != nil {
+				return console.Exit(1, "could not open adapter: %s", console.Red(err))
+			}
+			defer func() { _ = a.Close() }()
+		}
+		ctx := snsctx.SetVerbose(context.Background(), c.Bool("verbose"))
+		results, err := scanner.Scan(ctx, bus, scanner.WithProbeMode(mode))
+		if err != nil {
+			return console.Exit(1, "scan error: %s", console.Red(err))
+		}
+		if c.Bool("json") {
+			enc := json.NewEncoder(os.Stdout)
+			enc.SetIndent("", "  ")
+			if err := enc.Encode(results); err != nil {
+				return console.Exit(1, "encoding error: %s", console.Red(err))
+			}
+			return nil
+		}
+		if len(results) == 0 {
+			console.Print("no devices found")
+			return nil
+		}
+		w := tabwriter.NewWriter(os.Stdout, 12, 0, 1, ' ', 0)
+		_, _ = fmt.Fprintf(w, "ADDRESS\tDEVICE\tCANDIDATES\n")
+		for _, r := range results {
+			identified := r.Identified
+			if identified == "" {
+				identified = "?"
+			}
+			_, _ = fmt.Fprintf(w, "%#02x\t%s\t%s\n", r.Address, identified, strings.Join(r.Candidates, ", "))
+		}
+		_ = w.Flush()
+		return nil
+	},
+}
+
+var i2cRecoverCmd = cli.Command{
+	Name:  "recover",
+	Usage: "free the bus from a slave holding SDA low",
+	Description: "cancels the pending transfer and clocks the stuck slave out of its transfer; " +
+		"the mcp2221 cannot drive its i2c pins so it is reset as a last resort, " +
+		"an i2cdev bus is bit-banged on the pins given with --scl-pin and --sda-pin or reported by the driver; " +
+		"exits with 1 when the bus is still stuck and with 2 when the bus cannot be recovered",
+	Flags: []cli.Flag{
+		&cli.StringFlag{Name: "scl-pin", Usage: "GPIO pin wired to SCL (i2cdev bus)"},
+		&cli.StringFlag{Name: "sda-pin", Usage: "GPIO pin wired to SDA (i2cdev bus)"},
+		&cli.BoolFlag{Name: "json", Usage: "print the result as JSON"},
+		&cli.BoolFlag{Name: "verbose", Aliases: []string{"v"}},
+	},
+	Action: func(c *cli.Context) error {
+		bus, closeBus, err := busFromContext(c)
+		if err != nil {
+			return err
+		}
+		defer closeBus()
+		if g, ok := bus.(*i2c.GenericBus); ok && (c.IsSet("scl-pin") || c.IsSet("sda-pin")) {
+			scl := gpioreg.ByName(c.String("scl-pin"))
+			sda := gpioreg.ByName(c.String("sda-pin"))
+			if scl == nil || sda == nil {
+				return console.Exit(1, "unknown recovery pins: SCL %q, SDA %q", c.String("scl-pin"), c.String("sda-pin"))
+			}
+			g.SetRecoveryPins(scl, sda)
+		}
+		ctx := snsctx.SetVerbose(context.Background(), c.Bool("verbose"))
+		res, err := sensors.RecoverBus(ctx, bus)
+		if c.Bool("json") {
+			enc := json.NewEncoder(os.Stdout)
+			enc.SetIndent("", "  ")
+			if err := enc.Encode(res); err != nil {
+				return console.Exit(1, "encoding error: %s", console.Red(err))
+			}
+		}
+		if errors.Is(err, sensors.ErrRecoveryUnsupported) {
+			return console.Exit(2, "recovery not performed: %s", console.Red(err))
+		}
+		if err != nil && !errors.Is(err, sensors.ErrBusStuck) {
+			return console.Exit(1, "recovery error: %s", console.Red(err))
+		}
+		if !c.Bool("json") {
+			console.Printf("before:  SCL %s SDA %s\n", lineLevel(res.Before.SCL), lineLevel(res.Before.SDA))
+			if res.ClockPulses > 0 {
+				console.Printf("clocked: %d pulses\n", res.ClockPulses)
+			}
+			if res.AdapterReset {
+				console.Print("adapter reset")
+			}
+			console.Printf("after:   SCL %s SDA %s\n", lineLevel(res.After.SCL), lineLevel(res.After.SDA))
+		}
+		if err != nil {
+			return console.Exit(1, "%s", console.Red(err))
+		}
+		console.Print(console.Green("bus idle"))
+		return nil
+	},
+}
+
+func lineLevel(high bool) string {
+	if high {
+		return console.Green("high")
+	}
+	return console.Red("low")
+}
@@ -9,7 +9,6 @@ import (
 	chlog "github.com/charmbracelet/log"
 	"github.com/urfave/cli/v2"
 
-	"github.com/mklimuk/sensors/adapter"
 	"github.com/mklimuk/sensors/cmd/sensors/console"
 	"github.com/mklimuk/sensors/environment"
 	"github.com/mklimuk/sensors/snsctx"
@@ -26,14 +25,6 @@ var lightReadCmd = cli.Command{
 	Name:    "read",
 	Aliases: []string{"rd"},
 	Flags: []cli.Flag{
-		&cli.StringFlag{
-			Name:  "adapter,a",
-			Value: "mcp2221",
-		},
-		&cli.StringFlag{
-			Name:  "adapter-product,p",
-			Value: "00dd",
-		},
 		&cli.StringFlag{
 			Name:  "sensor,s",
 			Value: "bh1750",
@@ -59,31 +50,24 @@ var lightReadCmd = cli.Command{
 
 		switch c.String("sensor") {
 		case "bh1750":
-			switch c.String("adapter") {
-			case "mcp2221":
-				productID, err := toUint16(c.String("adapter-product"))
-				if err != nil {
-					return console.Exit(1, "invalid adapter product: %s", console.Red(err))
-				}
-				a := adapter.NewMCP2221(adapter.WithProductID(productID))
-				err = a.Init()
-				if err != nil {
-					return console.Exit(1, "adapter initialization error: %s", console.Red(err))
-				}
-				var addr byte
-				switch c.String("addr") {
-				case "h":
-					addr = environment.BH1750AddrHigh
-				default:
-					addr = environment.BH1750AddrLow
-				}
-				s := environment.NewBH1750(a, addr)
-				lux, err := s.GetLux(ctx)
-				if err != nil {
-					console.Errorf("error getting light sensor read: %s", console.Red(err))
-				}
-				console.Printf("%s lux\n", console.White(lux))
+			a, closeBus, err := busFromContext(c)
+			if err != nil {
+				return err
+			}
+			defer closeBus()
+			var addr byte
+			switch c.String("addr") {
+			case "h":
+				addr = environment.BH1750AddrHigh
+			default:
+				addr = environment.BH1750AddrLow
+			}
+			s := environment.NewBH1750(a, addr)
+			lux, err := s.GetLux(ctx)
+			if err != nil {
+				console.Errorf("error getting light sensor read: %s", console.Red(err))
 			}
+			console.Printf("%s lux\n", console.White(lux))
 		}
 		return nil
 	},
@@ -9,6 +9,7 @@ import (
 	"time"
 
 	chlog "github.com/charmbracelet/log"
+	"github.com/mklimuk/sensors/cmd/sensors/command"
 	"github.com/muesli/termenv"
 	"github.com/urfave/cli/v2"
 )
@@ -32,6 +33,21 @@ func run() int {
 			Name:  "verbose",
 			Usage: "enable verbose logging",
 		},
+		&cli.StringFlag{
+			Name:    "bus",
+			Aliases: []string{"b"},
+			EnvVars: []string{"SNS_BUS"},
+			Value:   "mcp2221://",
+			Usage:   command.BusURLHelp,
+		},
+		&cli.StringFlag{
+			Name:  "adapter-serial",
+			Usage: "USB serial number of the bridge to use when several are attached",
+		},
+		&cli.IntFlag{
+			Name:  "i2c-speed",
+			Usage: "i2c clock frequency in Hz (0 keeps the adapter default)",
+		},
 	}
 	app.Before = func(ctx *cli.Context) error {
 		charm := chlog.NewWithOptions(os.Stdout, chlog.Options{
@@ -51,10 +67,15 @@ func run() int {
 		&tempReadCmd,
 		&usbCmd,
 		&mcp2221Cmd,
+		&cp2112Cmd,
 		&gpioCmd,
 		&motionCmd,
 		&lightCmd,
 		&airCmd,
+		&i2cCmd,
+		&serveBusCmd,
+		command.PotentiometerCmd,
+		command.MemoryCmd,
 	}
 	err := app.Run(os.Args)
 	if err != nil {
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"bufio"
 	"context"
 	"encoding/hex"
 	"fmt"
@@ -10,6 +11,7 @@ import (
 	"strconv"
 	"strings"
 	"syscall"
+	"text/tabwriter"
 	"time"
 
 	"github.com/mklimuk/sensors/adapter"
@@ -29,23 +31,68 @@ func toUint16(value string) (uint16, error) {
 	return uint16(productID), nil
 }
 
+// mcp2221Options returns the options selecting and configuring the bridge
+// requested with the global flags.
+func mcp2221Options(c *cli.Context) []adapter.MCP2221Option {
+	var opts []adapter.MCP2221Option
+	if serial := c.String("adapter-serial"); serial != "" {
+		opts = append(opts, adapter.WithSerial(serial))
+	}
+	if speed := c.Int("i2c-speed"); speed != 0 {
+		opts = append(opts, adapter.WithI2CSpeed(speed))
+	}
+	return opts
+}
+
 func mcp2221FromContext(c *cli.Context) (*adapter.MCP2221, error) {
 	productID, err := toUint16(c.String("product"))
 	if err != nil {
 		return nil, cli.Exit(err.Error(), 1)
 	}
-	return adapter.NewMCP2221(adapter.WithProductID(productID)), nil
+	return adapter.NewMCP2221(append(mcp2221Options(c), adapter.WithProductID(productID))...), nil
 }
 
 var mcp2221Cmd = cli.Command{
 	Name: "mcp2221",
 	Subcommands: cli.Commands{
+		&mcp2221ListCmd,
 		&mcp2221StatusCmd,
 		&mcp2221ReleaseCmd,
 		&mcp2221GPIOCmd,
 		&mcp2221ResetCmd,
 		&mcp2221ChipCmd,
 		&mcp2221ButtonCmd,
+		&mcp2221ADCCmd,
+		&mcp2221DACCmd,
+		&mcp2221StringsCmd,
+		&mcp2221PasswordCmd,
+		&mcp2221ClockCmd,
+	},
+}
+
+var mcp2221ListCmd = cli.Command{
+	Name:        "list",
+	Description: "list attached mcp2221 bridges",
+	Flags: []cli.Flag{
+		&cli.StringFlag{Name: "product,p", Value: "00dd"},
+	},
+	Action: func(c *cli.Context) error {
+		productID, err := toUint16(c.String("product"))
+		if err != nil {
+			return cli.Exit(err.Error(), 1)
+		}
+		devices := adapter.EnumerateMCP2221(adapter.WithProductID(productID))
+		if len(devices) == 0 {
+			console.Print("no mcp2221 bridges found")
+			return nil
+		}
+		w := tabwriter.NewWriter(os.Stdout, 8, 0, 2, ' ', 0)
+		_, _ = fmt.Fprintf(w, "INDEX\tSERIAL\tPATH\tMANUFACTURER\tPRODUCT\n")
+		for i, dev := range devices {
+			_, _ = fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\n", i, dev.Serial, dev.Path, dev.Manufacturer, dev.Product)
+		}
+		_ = w.Flush()
+		return nil
 	},
 }
 
@@ -74,6 +121,12 @@ var mcp2221StatusCmd = cli.Command{
 		if err != nil {
 			return console.Exit(1, "encoding error: %s", console.Red(err))
 		}
+		if status.SCL == 0 || status.SDA == 0 {
+			console.Printf("%s SCL=%d SDA=%d; a device may be holding the bus\n", console.Red("bus lines low:"), status.SCL, status.SDA)
+		}
+		if status.I2CState.Timeout() {
+			console.Printf("%s %s; run release to cancel the transfer\n", console.Red("i2c engine stuck:"), status.I2CState)
+		}
 		settings, err := a.GetGPIOParameters(ctx)
 		if err != nil {
 			return console.Exit(1, "could not read gpio parameters: %s", console.Red(err))
@@ -83,6 +136,15 @@ var mcp2221StatusCmd = cli.Command{
 		if err != nil {
 			return console.Exit(1, "encoding error: %s", console.Red(err))
 		}
+		powerUp, err := a.ReadFlashGPIOParameters(ctx)
+		if err != nil {
+			return console.Exit(1, "could not read power-up gpio parameters: %s", console.Red(err))
+		}
+		fmt.Println("GPIO power-up settings:")
+		err = enc.Encode(powerUp)
+		if err != nil {
+			return console.Exit(1, "encoding error: %s", console.Red(err))
+		}
 		return nil
 	},
 }
@@ -148,6 +210,9 @@ var mcp2221GPIOCmd = cli.Command{
 	},
 	Subcommands: cli.Commands{
 		&mcp2221GPIOReadCmd,
+		&mcp2221GPIOSaveCmd,
+		&mcp2221GPIOSetCmd,
+		&mcp2221GPIOToggleCmd,
 	},
 }
 
@@ -176,7 +241,6 @@ var mcp2221GPIOReadCmd = cli.Command{
 		if err != nil {
 			return console.Exit(1, "could not set parameters: %s", console.Red(err))
 		}
-		time.Sleep(100 * time.Millisecond)
 		params, err := a.GetGPIOParameters(ctx)
 		if err != nil {
 			return console.Exit(1, "could not get parameters: %s", console.Red(err))
@@ -198,6 +262,134 @@ var mcp2221GPIOReadCmd = cli.Command{
 	},
 }
 
+var mcp2221GPIOSaveCmd = cli.Command{
+	Name:        "save",
+	Description: "persist the current GPIO configuration to flash as the power-up default",
+	Flags: []cli.Flag{
+		&cli.StringFlag{Name: "product,p", Value: "00dd"},
+		&cli.BoolFlag{Name: "verbose,v"},
+	},
+	Action: func(c *cli.Context) error {
+		a, err := mcp2221FromContext(c)
+		if err != nil {
+			return err
+		}
+		err = a.Init()
+		if err != nil {
+			return console.Exit(1, "adapter initialization error: %s", console.Red(err))
+		}
+		ctx := snsctx.SetVerbose(context.Background(), c.Bool("verbose"))
+		params, err := a.GetGPIOParameters(ctx)
+		if err != nil {
+			return console.Exit(1, "could not get parameters: %s", console.Red(err))
+		}
+		err = a.WriteFlashGPIOParameters(ctx, params)
+		if err != nil {
+			return console.Exit(1, "could not write parameters to flash: %s", console.Red(err))
+		}
+		enc := yaml.NewEncoder(os.Stdout)
+		err = enc.Encode(params)
+		if err != nil {
+			return console.Exit(1, "params encoding error: %s", console.Red(err))
+		}
+		return nil
+	},
+}
+
+var mcp2221GPIOSetCmd = cli.Command{
+	Name:        "set",
+	Usage:       "set <pin> <0|1>",
+	Description: "switch a GPIO pin to output and drive it low (0) or high (1)",
+	Flags: []cli.Flag{
+		&cli.StringFlag{Name: "product,p", Value: "00dd"},
+		&cli.BoolFlag{Name: "verbose,v"},
+	},
+	Action: func(c *cli.Context) error {
+		if c.NArg() != 2 {
+			return console.Exit(1, "expected pin and value arguments")
+		}
+		pin, err := adapter.ParseGPIOPin(c.Args().Get(0))
+		if err != nil {
+			return console.Exit(1, "%s", console.Red(err))
+		}
+		var value byte
+		switch c.Args().Get(1) {
+		case "0":
+		case "1":
+			value = 1
+		default:
+			return console.Exit(1, "invalid value %q, expected 0 or 1", c.Args().Get(1))
+		}
+		a, err := mcp2221FromContext(c)
+		if err != nil {
+			return err
+		}
+		err = a.Init()
+		if err != nil {
+			return console.Exit(1, "adapter initialization error: %s", console.Red(err))
+		}
+		ctx := snsctx.SetVerbose(context.Background(), c.Bool("verbose"))
+		err = driveGPIO(ctx, a, pin, value)
+		if err != nil {
+			return console.Exit(1, "%s", console.Red(err))
+		}
+		console.Printf("%s set to %d\n", console.Bold(pin), value)
+		return nil
+	},
+}
+
+var mcp2221GPIOToggleCmd = cli.Command{
+	Name:        "toggle",
+	Usage:       "toggle <pin>",
+	Description: "switch a GPIO pin to output and invert its current level",
+	Flags: []cli.Flag{
+		&cli.StringFlag{Name: "product,p", Value: "00dd"},
+		&cli.BoolFlag{Name: "verbose,v"},
+	},
+	Action: func(c *cli.Context) error {
+		if c.NArg() != 1 {
+			return console.Exit(1, "expected pin argument")
+		}
+		pin, err := adapter.ParseGPIOPin(c.Args().Get(0))
+		if err != nil {
+			return console.Exit(1, "%s", console.Red(err))
+		}
+		a, err := mcp2221FromContext(c)
+		if err != nil {
+			return err
+		}
+		err = a.Init()
+		if err != nil {
+			return console.Exit(1, "adapter initialization error: %s", console.Red(err))
+		}
+		ctx := snsctx.SetVerbose(context.Background(), c.Bool("verbose"))
+		vals, err := a.ReadGPIO(ctx)
+		if err != nil {
+			return console.Exit(1, "could not read values: %s", console.Red(err))
+		}
+		var value byte
+		if vals.Value(pin) == 0 {
+			value = 1
+		}
+		err = driveGPIO(ctx, a, pin, value)
+		if err != nil {
+			return console.Exit(1, "%s", console.Red(err))
+		}
+		console.Printf("%s set to %d\n", console.Bold(pin), value)
+		return nil
+	},
+}
+
+// driveGPIO sets the output level before switching the pin to output so that
+// an input pin does not briefly drive the previously latched level.
+func driveGPIO(ctx context.Context, a *adapter.MCP2221, pin adapter.GPIOPin, value byte) error {
+	err := a.SetGPIO(ctx, pin, value)
+	if err != nil {
+		return err
+	}
+	return a.SetGPIODirection(ctx, pin, adapter.GPIOModeOut)
+}
+
 var mcp2221ChipCmd = cli.Command{
 	Name:        "chip",
 	Description: "read mcp2221 chip settings",
@@ -212,9 +404,14 @@ var mcp2221ChipCmd = cli.Command{
 		if err != nil {
 			return err
 		}
-		err = mcp.ReadChipSettings(c.Context)
+		settings, err := mcp.ReadChipSettings(c.Context)
+		if err != nil {
+			return console.Exit(1, "could not read chip settings: %s", console.Red(err))
+		}
+		enc := yaml.NewEncoder(os.Stdout)
+		err = enc.Encode(settings)
 		if err != nil {
-			console.Errorf("could not read chip settings: %v", err)
+			return console.Exit(1, "encoding error: %s", console.Red(err))
 		}
 		return nil
 	},
@@ -242,10 +439,10 @@ var mcp2221UpdateVendorCmd = cli.Command{
 		if err != nil {
 			return cli.Exit(err.Error(), 1)
 		}
-		mcp := adapter.NewMCP2221(adapter.WithProductID(deviceProductID))
+		mcp := adapter.NewMCP2221(append(mcp2221Options(c), adapter.WithProductID(deviceProductID))...)
 		err = mcp.UpdateVendorAndProductID(c.Context, vendor, product, c.Bool("dryrun"))
 		if err != nil {
-			return cli.Exit(fmt.Sprintf("could not read chip settings: %v", err), 1)
+			return cli.Exit(fmt.Sprintf("could not update chip settings: %v", err), 1)
 		}
 		return nil
 	},
@@ -261,6 +458,10 @@ var mcp2221ButtonCmd = cli.Command{
 			Value:   5 * time.Millisecond,
 			Usage:   "polling interval (USB HID round-trip is ~2-3ms; values <5ms may miss frames)",
 		},
+		&cli.StringFlag{
+			Name:  "interrupt",
+			Usage: "watch the GP1 interrupt detector for rising, falling or both edges instead of polling pin levels",
+		},
 		&cli.BoolFlag{Name: "verbose,v"},
 	},
 	Action: func(c *cli.Context) error {
@@ -278,6 +479,10 @@ var mcp2221ButtonCmd = cli.Command{
 		)
 		defer cancel()
 
+		if c.String("interrupt") != "" {
+			return watchButtonInterrupt(ctx, a, c.String("interrupt"), c.Duration("interval"))
+		}
+
 		if err := a.SetGPIOParameters(ctx, adapter.MCP2221GPIOParameters{
 			GPIO0Mode: adapter.GPIOModeIn,
 			GPIO1Mode: adapter.GPIOModeIn,
@@ -300,6 +505,8 @@ var mcp2221ButtonCmd = cli.Command{
 			}
 		}()
 
+		reportConnectionChanges(ctx, a)
+
 		interval := c.Duration("interval")
 		console.Printf("monitoring GP0..GP3 every %s; press Ctrl-C to stop\n", interval)
 		ticker := time.NewTicker(interval)
@@ -332,6 +539,58 @@ var mcp2221ButtonCmd = cli.Command{
 	},
 }
 
+// watchButtonInterrupt reports the edges latched by the GP1 interrupt
+// detector, which catches presses shorter than the polling interval.
+func watchButtonInterrupt(ctx context.Context, a *adapter.MCP2221, edgeName string, interval time.Duration) error {
+	edge, err := adapter.ParseInterruptEdge(edgeName)
+	if err != nil {
+		return console.Exit(1, "%s", console.Red(err))
+	}
+	if err := a.ConfigureInterrupt(ctx, edge); err != nil {
+		return console.Exit(1, "could not configure GP1 interrupt: %s", console.Red(err))
+	}
+	if err := a.Open(ctx); err != nil {
+		console.Errorf("initial mcp2221 open failed (will retry on first poll): %s", console.Red(err))
+	}
+	defer func() {
+		if err := a.Close(); err != nil {
+			slog.Debug("mcp2221 close failed", "err", err)
+		}
+	}()
+	reportConnectionChanges(ctx, a)
+	console.Printf("watching GP1 for %s edges; press Ctrl-C to stop\n", edge)
+	for ev := range a.WatchInterrupt(ctx, interval) {
+		console.Printf("%s %s %s\n", ev.Time.Format("15:04:05.000"), console.Bold("GP1"), console.Green(strings.ToUpper(edge.String())+" EDGE"))
+	}
+	console.Print("stopping button monitor")
+	return nil
+}
+
+// reportConnectionChanges monitors the adapter connection until ctx is done
+// so that an unplugged bridge is reconnected with its GPIO settings restored,
+// and prints the connection state changes.
+func reportConnectionChanges(ctx context.Context, a *adapter.MCP2221) {
+	if err := a.Monitor(ctx, time.Second); err != nil {
+		slog.Debug("mcp2221 monitor not started", "err", err)
+		return
+	}
+	states, _, err := a.Subscribe()
+	if err != nil {
+		slog.Debug("mcp2221 state subscription failed", "err", err)
+		return
+	}
+	go func() {
+		for state := range states {
+			ts := time.Now().Format("15:04:05.000")
+			if state == adapter.StatusConnected {
+				console.Printf("%s %s %s\n", ts, console.Bold("mcp2221"), console.Green("RECONNECTED"))
+				continue
+			}
+			console.Printf("%s %s %s\n", ts, console.Bold("mcp2221"), console.Red("DISCONNECTED"))
+		}
+	}()
+}
+
 // reportButtonChange prints a colored line when a GPIO pin transitions, or on
 // the very first sample to establish initial state. Active-low: 0 = pressed.
 func reportButtonChange(name string, cur, prev byte, first bool) {
@@ -345,3 +604,428 @@ func reportButtonChange(name string, cur, prev byte, first bool) {
 	}
 	console.Printf("%s %s %s\n", ts, console.Bold(name), console.Green("RELEASED"))
 }
+
+var mcp2221ADCCmd = cli.Command{
+	Name:        "adc",
+	Description: "read the mcp2221 ADC channels (GP1..GP3 designated as ADC inputs), optionally sampling continuously",
+	Flags: []cli.Flag{
+		&cli.StringFlag{Name: "product,p", Value: "00dd"},
+		&cli.StringFlag{
+			Name:  "ref,r",
+			Usage: "set the ADC voltage reference before sampling: vdd, 1.024, 2.048 or 4.096",
+		},
+		&cli.Float64Flag{
+			Name:  "vdd",
+			Value: 3.3,
+			Usage: "chip supply voltage used for conversion when the reference is VDD",
+		},
+		&cli.DurationFlag{
+			Name:  "interval,i",
+			Usage: "sample continuously at this interval until interrupted (0 = single sample)",
+		},
+		&cli.BoolFlag{Name: "verbose,v"},
+	},
+	Action: func(c *cli.Context) error {
+		a, err := mcp2221FromContext(c)
+		if err != nil {
+			return err
+		}
+		if err := a.Init(); err != nil {
+			return console.Exit(1, "adapter initialization error: %s", console.Red(err))
+		}
+		ctx, cancel := signal.NotifyContext(
+			snsctx.SetVerbose(context.Background(), c.Bool("verbose")),
+			os.Interrupt, syscall.SIGTERM,
+		)
+		defer cancel()
+
+		if c.String("ref") != "" {
+			ref, err := adapter.ParseVoltageReference(c.String("ref"))
+			if err != nil {
+				return console.Exit(1, "%s", console.Red(err))
+			}
+			if err := a.SetADCReference(ctx, ref); err != nil {
+				return console.Exit(1, "could not set ADC reference: %s", console.Red(err))
+			}
+		}
+		vdd := c.Float64("vdd")
+		interval := c.Duration("interval")
+		if interval <= 0 {
+			reading, err := a.ReadADC(ctx)
+			if err != nil {
+				return console.Exit(1, "could not read ADC: %s", console.Red(err))
+			}
+			printADCReading(reading, vdd)
+			return nil
+		}
+
+		if err := a.Open(ctx); err != nil {
+			console.Errorf("initial mcp2221 open failed (will retry on first read): %s", console.Red(err))
+		}
+		defer func() {
+			if err := a.Close(); err != nil {
+				slog.Debug("mcp2221 close failed", "err", err)
+			}
+		}()
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			reading, err := a.ReadADC(ctx)
+			if err != nil {
+				if ctx.Err() != nil {
+					return nil
+				}
+				slog.Debug("adc read failed; will retry", "err", err)
+			} else {
+				printADCReading(reading, vdd)
+			}
+			select {
+			case <-ctx.Done():
+				return nil
+			case <-ticker.C:
+			}
+		}
+	},
+}
+
+func printADCReading(reading adapter.ADCReading, vdd float64) {
+	ts := time.Now().Format("15:04:05.000")
+	console.Printf("%s ref=%s", ts, reading.Reference)
+	for _, ch := range []adapter.ADCChannel{adapter.ADC1, adapter.ADC2, adapter.ADC3} {
+		console.Printf("  %s %4d (%.3fV)", console.Bold(fmt.Sprintf("ADC%d", ch)), reading.Value(ch), reading.Volts(ch, vdd))
+	}
+	console.Print("")
+}
+
+var mcp2221DACCmd = cli.Command{
+	Name:        "dac",
+	Description: "show the mcp2221 DAC output (GP2/GP3 designated as DAC outputs)",
+	Flags: []cli.Flag{
+		&cli.StringFlag{Name: "product,p", Value: "00dd"},
+		&cli.Float64Flag{Name: "vdd", Value: 3.3, Usage: "chip supply voltage used when the reference is VDD"},
+		&cli.BoolFlag{Name: "verbose,v"},
+	},
+	Subcommands: cli.Commands{
+		&mcp2221DACSetCmd,
+	},
+	Action: func(c *cli.Context) error {
+		a, err := mcp2221FromContext(c)
+		if err != nil {
+			return err
+		}
+		if err := a.Init(); err != nil {
+			return console.Exit(1, "adapter initialization error: %s", console.Red(err))
+		}
+		ctx := snsctx.SetVerbose(context.Background(), c.Bool("verbose"))
+		setting, err := a.ReadDAC(ctx)
+		if err != nil {
+			return console.Exit(1, "could not read DAC: %s", console.Red(err))
+		}
+		console.Printf("ref=%s code=%d (%.3fV)\n", setting.Reference, setting.Value, setting.Volts(c.Float64("vdd")))
+		return nil
+	},
+}
+
+var mcp2221DACSetCmd = cli.Command{
+	Name:        "set",
+	Usage:       "set <code 0-31> | set --volts <V>",
+	Description: "set the mcp2221 DAC output as a raw 5-bit code or as a voltage",
+	Flags: []cli.Flag{
+		&cli.StringFlag{Name: "product,p", Value: "00dd"},
+		&cli.StringFlag{
+			Name:  "ref,r",
+			Usage: "DAC voltage reference: vdd, 1.024, 2.048 or 4.096 (unchanged if omitted with a raw code)",
+		},
+		&cli.Float64Flag{Name: "volts", Usage: "output voltage; the closest code is selected"},
+		&cli.Float64Flag{Name: "vdd", Value: 3.3, Usage: "chip supply voltage used when the reference is VDD"},
+		&cli.BoolFlag{Name: "verbose,v"},
+	},
+	Action: func(c *cli.Context) error {
+		a, err := mcp2221FromContext(c)
+		if err != nil {
+			return err
+		}
+		if err := a.Init(); err != nil {
+			return console.Exit(1, "adapter initialization error: %s", console.Red(err))
+		}
+		ctx := snsctx.SetVerbose(context.Background(), c.Bool("verbose"))
+		var ref adapter.VoltageReference
+		if c.String("ref") != "" {
+			ref, err = adapter.ParseVoltageReference(c.String("ref"))
+			if err != nil {
+				return console.Exit(1, "%s", console.Red(err))
+			}
+		}
+		vdd := c.Float64("vdd")
+		if c.IsSet("volts") {
+			if c.String("ref") == "" {
+				current, err := a.ReadDAC(ctx)
+				if err != nil {
+					return console.Exit(1, "could not read DAC reference: %s", console.Red(err))
+				}
+				ref = current.Reference
+			}
+			out, err := a.SetDACVolts(ctx, c.Float64("volts"), ref, vdd)
+			if err != nil {
+				return console.Exit(1, "could not set DAC output: %s", console.Red(err))
+			}
+			console.Printf("DAC set to %.3fV (ref=%s)\n", out, ref)
+			return nil
+		}
+		if c.NArg() != 1 {
+			return console.Exit(1, "expected DAC code argument or --volts")
+		}
+		code, err := strconv.ParseUint(c.Args().Get(0), 0, 8)
+		if err != nil || code > adapter.DACMaxValue {
+			return console.Exit(1, "invalid DAC code %q, expected 0-%d", c.Args().Get(0), adapter.DACMaxValue)
+		}
+		if c.String("ref") != "" {
+			if err := a.SetDACReference(ctx, ref); err != nil {
+				return console.Exit(1, "could not set DAC reference: %s", console.Red(err))
+			}
+		}
+		if err := a.SetDAC(ctx, byte(code)); err != nil {
+			return console.Exit(1, "could not set DAC code: %s", console.Red(err))
+		}
+		console.Printf("DAC code set to %d\n", code)
+		return nil
+	},
+}
+
+var mcp2221StringsCmd = cli.Command{
+	Name:        "strings",
+	Description: "show the mcp2221 USB manufacturer, product and serial number strings",
+	Flags: []cli.Flag{
+		&cli.StringFlag{Name: "product,p", Value: "00dd"},
+		&cli.BoolFlag{Name: "verbose,v"},
+	},
+	Subcommands: cli.Commands{
+		&mcp2221StringsSetCmd,
+	},
+	Action: func(c *cli.Context) error {
+		a, err := mcp2221FromContext(c)
+		if err != nil {
+			return err
+		}
+		if err := a.Init(); err != nil {
+			return console.Exit(1, "adapter initialization error: %s", console.Red(err))
+		}
+		ctx := snsctx.SetVerbose(context.Background(), c.Bool("verbose"))
+		strs, err := a.ReadUSBStrings(ctx)
+		if err != nil {
+			return console.Exit(1, "could not read USB strings: %s", console.Red(err))
+		}
+		enc := yaml.NewEncoder(os.Stdout)
+		err = enc.Encode(strs)
+		if err != nil {
+			return console.Exit(1, "encoding error: %s", console.Red(err))
+		}
+		return nil
+	},
+}
+
+var mcp2221StringsSetCmd = cli.Command{
+	Name:        "set",
+	Description: "write the mcp2221 USB strings to flash; they are reported after the next reset",
+	Flags: []cli.Flag{
+		&cli.StringFlag{Name: "product,p", Value: "00dd"},
+		&cli.StringFlag{Name: "manufacturer", Usage: "manufacturer string"},
+		&cli.StringFlag{Name: "product-string", Usage: "product string"},
+		&cli.StringFlag{Name: "serial-number", Usage: "serial number string"},
+		&cli.StringFlag{Name: "password", Usage: "flash access password of a protected chip"},
+		&cli.BoolFlag{Name: "verbose,v"},
+	},
+	Action: func(c *cli.Context) error {
+		updates := []struct {
+			flag string
+			kind adapter.USBString
+		}{
+			{"manufacturer", adapter.USBManufacturer},
+			{"product-string", adapter.USBProduct},
+			{"serial-number", adapter.USBSerial},
+		}
+		a, err := mcp2221FromContext(c)
+		if err != nil {
+			return err
+		}
+		if err := a.Init(); err != nil {
+			return console.Exit(1, "adapter initialization error: %s", console.Red(err))
+		}
+		ctx := snsctx.SetVerbose(context.Background(), c.Bool("verbose"))
+		if c.IsSet("password") {
+			if err := a.SendPassword(ctx, c.String("password")); err != nil {
+				return console.Exit(1, "could not unlock chip: %s", console.Red(err))
+			}
+		}
+		written := 0
+		for _, u := range updates {
+			if !c.IsSet(u.flag) {
+				continue
+			}
+			if err := a.WriteUSBString(ctx, u.kind, c.String(u.flag)); err != nil {
+				return console.Exit(1, "could not write %s string: %s", u.kind, console.Red(err))
+			}
+			console.Printf("%s string set to %q\n", u.kind, c.String(u.flag))
+			written++
+		}
+		if written == 0 {
+			return console.Exit(1, "nothing to write, expected --manufacturer, --product-string or --serial-number")
+		}
+		return nil
+	},
+}
+
+var mcp2221PasswordCmd = cli.Command{
+	Name:        "password",
+	Description: "manage the mcp2221 flash access password and chip security",
+	Subcommands: cli.Commands{
+		&mcp2221PasswordSetCmd,
+		&mcp2221PasswordRemoveCmd,
+		&mcp2221PasswordLockCmd,
+	},
+}
+
+var mcp2221PasswordSetCmd = cli.Command{
+	Name:        "set",
+	Description: "protect the flash settings with a password (up to 8 characters)",
+	Flags: []cli.Flag{
+		&cli.StringFlag{Name: "product,p", Value: "00dd"},
+		&cli.StringFlag{Name: "current", Usage: "current password of a protected chip"},
+		&cli.StringFlag{Name: "new", Usage: "new password", Required: true},
+		&cli.BoolFlag{Name: "verbose,v"},
+	},
+	Action: func(c *cli.Context) error {
+		a, ctx, err := mcp2221Unlocked(c)
+		if err != nil {
+			return err
+		}
+		if err := a.SetPassword(ctx, c.String("new")); err != nil {
+			return console.Exit(1, "%s", console.Red(err))
+		}
+		console.Print("password set")
+		return nil
+	},
+}
+
+var mcp2221PasswordRemoveCmd = cli.Command{
+	Name:        "remove",
+	Description: "remove the flash access password",
+	Flags: []cli.Flag{
+		&cli.StringFlag{Name: "product,p", Value: "00dd"},
+		&cli.StringFlag{Name: "current", Usage: "current password", Required: true},
+		&cli.BoolFlag{Name: "verbose,v"},
+	},
+	Action: func(c *cli.Context) error {
+		a, ctx, err := mcp2221Unlocked(c)
+		if err != nil {
+			return err
+		}
+		if err := a.RemovePassword(ctx); err != nil {
+			return console.Exit(1, "%s", console.Red(err))
+		}
+		console.Print("password removed")
+		return nil
+	},
+}
+
+var mcp2221PasswordLockCmd = cli.Command{
+	Name:        "lock",
+	Description: "PERMANENTLY lock the flash settings; this cannot be undone",
+	Flags: []cli.Flag{
+		&cli.StringFlag{Name: "product,p", Value: "00dd"},
+		&cli.StringFlag{Name: "current", Usage: "current password of a protected chip"},
+		&cli.BoolFlag{Name: "verbose,v"},
+	},
+	Action: func(c *cli.Context) error {
+		a, ctx, err := mcp2221Unlocked(c)
+		if err != nil {
+			return err
+		}
+		strs, err := a.ReadUSBStrings(ctx)
+		if err != nil {
+			return console.Exit(1, "could not read USB strings: %s", console.Red(err))
+		}
+		console.Printf("%s the chip settings, GPIO defaults and USB strings of %s (serial %q) can never be changed again.\n",
+			console.Red("This cannot be undone:"), strs.Product, strs.Serial)
+		console.Printf("Type %s to confirm: ", console.Bold("LOCK"))
+		answer, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+		if strings.TrimSpace(answer) != "LOCK" {
+			return console.Exit(1, "aborted, chip left unchanged")
+		}
+		if err := a.LockPermanently(ctx); err != nil {
+			return console.Exit(1, "%s", console.Red(err))
+		}
+		console.Print("chip permanently locked")
+		return nil
+	},
+}
+
+// mcp2221Unlocked initializes the adapter and sends the password given with
+// the current flag, if any.
+func mcp2221Unlocked(c *cli.Context) (*adapter.MCP2221, context.Context, error) {
+	a, err := mcp2221FromContext(c)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := a.Init(); err != nil {
+		return nil, nil, console.Exit(1, "adapter initialization error: %s", console.Red(err))
+	}
+	ctx := snsctx.SetVerbose(context.Background(), c.Bool("verbose"))
+	if c.IsSet("current") {
+		if err := a.SendPassword(ctx, c.String("current")); err != nil {
+			return nil, nil, console.Exit(1, "could not unlock chip: %s", console.Red(err))
+		}
+	}
+	return a, ctx, nil
+}
+
+var mcp2221ClockCmd = cli.Command{
+	Name:        "clock",
+	Description: "show or configure the mcp2221 reference clock output on GP1",
+	Flags: []cli.Flag{
+		&cli.StringFlag{Name: "product,p", Value: "00dd"},
+		&cli.StringFlag{
+			Name:  "frequency,f",
+			Usage: "output frequency: 24MHz, 12MHz, 6MHz, 3MHz, 1.5MHz, 750kHz or 375kHz",
+		},
+		&cli.StringFlag{Name: "duty", Usage: "duty cycle in percent: 0, 25, 50 or 75"},
+		&cli.BoolFlag{Name: "verbose,v"},
+	},
+	Action: func(c *cli.Context) error {
+		a, err := mcp2221FromContext(c)
+		if err != nil {
+			return err
+		}
+		if err := a.Init(); err != nil {
+			return console.Exit(1, "adapter initialization error: %s", console.Red(err))
+		}
+		ctx := snsctx.SetVerbose(context.Background(), c.Bool("verbose"))
+		clock, err := a.ClockOutput(ctx)
+		if err != nil {
+			return console.Exit(1, "could not read clock output: %s", console.Red(err))
+		}
+		if c.IsSet("frequency") || c.IsSet("duty") {
+			if c.IsSet("frequency") {
+				clock.Frequency, err = adapter.ParseClockFrequency(c.String("frequency"))
+				if err != nil {
+					return console.Exit(1, "%s", console.Red(err))
+				}
+			}
+			if c.IsSet("duty") {
+				clock.Duty, err = adapter.ParseClockDuty(c.String("duty"))
+				if err != nil {
+					return console.Exit(1, "%s", console.Red(err))
+				}
+			}
+			if err := a.SetClockOutput(ctx, clock); err != nil {
+				return console.Exit(1, "could not set clock output: %s", console.Red(err))
+			}
+		}
+		enc := yaml.NewEncoder(os.Stdout)
+		err = enc.Encode(clock)
+		if err != nil {
+			return console.Exit(1, "encoding error: %s", console.Red(err))
+		}
+		return nil
+	},
+}
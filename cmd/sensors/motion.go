@@ -2,11 +2,16 @@ package main
 
 import (
 	"context"
+	"log/slog"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
 	"github.com/mklimuk/sensors/accel"
+	"github.com/mklimuk/sensors/adapter"
 	"github.com/mklimuk/sensors/snsctx"
 
-	"github.com/mklimuk/sensors/adapter"
 	"github.com/mklimuk/sensors/cmd/sensors/console"
 	"github.com/urfave/cli/v2"
 )
@@ -23,10 +28,6 @@ var motionCmd = cli.Command{
 var motionInitCmd = cli.Command{
 	Name: "init",
 	Flags: []cli.Flag{
-		&cli.StringFlag{
-			Name:  "adapter,a",
-			Value: "mcp2221",
-		},
 		&cli.StringFlag{
 			Name:  "sensor,s",
 			Value: "bma220",
@@ -37,13 +38,15 @@ var motionInitCmd = cli.Command{
 		ctx := snsctx.SetVerbose(context.Background(), c.Bool("verbose"))
 		switch c.String("sensor") {
 		case "bma220":
-			switch c.String("adapter") {
-			case "mcp2221":
-				s := accel.NewBMA220(adapter.NewMCP2221())
-				err := s.InitMotionDetection(ctx)
-				if err != nil {
-					console.Errorf("error initializing BMA220: %s", console.Red(err))
-				}
+			a, closeBus, err := busFromContext(c)
+			if err != nil {
+				return err
+			}
+			defer closeBus()
+			s := accel.NewBMA220(a)
+			err = s.InitMotionDetection(ctx)
+			if err != nil {
+				console.Errorf("error initializing BMA220: %s", console.Red(err))
 			}
 		}
 		return nil
@@ -53,50 +56,93 @@ var motionInitCmd = cli.Command{
 var motionCheckCmd = cli.Command{
 	Name: "check",
 	Flags: []cli.Flag{
-		&cli.StringFlag{
-			Name:  "adapter,a",
-			Value: "mcp2221",
-		},
 		&cli.StringFlag{
 			Name:  "sensor,s",
 			Value: "bma220",
 		},
+		&cli.StringFlag{
+			Name:  "interrupt",
+			Usage: "watch the sensor INT line wired to the mcp2221 GP1 for rising, falling or both edges instead of checking once",
+		},
+		&cli.DurationFlag{
+			Name:  "interval,i",
+			Value: 5 * time.Millisecond,
+			Usage: "GP1 interrupt flag polling interval",
+		},
 		&cli.BoolFlag{Name: "verbose,v"},
 	},
 	Action: func(c *cli.Context) error {
 		ctx := snsctx.SetVerbose(context.Background(), c.Bool("verbose"))
 		switch c.String("sensor") {
 		case "bma220":
-			switch c.String("adapter") {
-			case "mcp2221":
-				a := adapter.NewMCP2221()
-				err := a.Init()
-				if err != nil {
-					return console.Exit(1, "adapter initialization error: %s", console.Red(err))
-				}
-				s := accel.NewBMA220(a)
-				motion, err := s.CheckMotionInterrupt(ctx)
-				if err != nil {
-					console.Errorf("error checking motion detection on BMA220: %s", console.Red(err))
-				}
-				if motion == 0x01 {
-					console.Printf("motion interrupt: %s\n", console.Yellow(motion))
-				} else {
-					console.Printf("motion interrupt: %s\n", console.Green(motion))
+			a, closeBus, err := busFromContext(c)
+			if err != nil {
+				return err
+			}
+			defer closeBus()
+			s := accel.NewBMA220(a)
+			if c.String("interrupt") != "" {
+				bridge, ok := a.(*adapter.MCP2221)
+				if !ok {
+					return console.Exit(1, "interrupt watching requires an mcp2221 bus")
 				}
+				ctx, cancel := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+				defer cancel()
+				return watchMotionInterrupt(ctx, bridge, s, c.String("interrupt"), c.Duration("interval"))
+			}
+			motion, err := s.CheckMotionInterrupt(ctx)
+			if err != nil {
+				console.Errorf("error checking motion detection on BMA220: %s", console.Red(err))
+			}
+			if motion == 0x01 {
+				console.Printf("motion interrupt: %s\n", console.Yellow(motion))
+			} else {
+				console.Printf("motion interrupt: %s\n", console.Green(motion))
 			}
 		}
 		return nil
 	},
 }
 
+// watchMotionInterrupt waits for the BMA220 INT line on the GP1 interrupt
+// detector and reads and resets the motion interrupt on every edge instead
+// of polling the sensor registers.
+func watchMotionInterrupt(ctx context.Context, a *adapter.MCP2221, s *accel.BMA220, edgeName string, interval time.Duration) error {
+	edge, err := adapter.ParseInterruptEdge(edgeName)
+	if err != nil {
+		return console.Exit(1, "%s", console.Red(err))
+	}
+	if err := a.ConfigureInterrupt(ctx, edge); err != nil {
+		return console.Exit(1, "could not configure GP1 interrupt: %s", console.Red(err))
+	}
+	if err := a.Open(ctx); err != nil {
+		console.Errorf("initial mcp2221 open failed (will retry on first poll): %s", console.Red(err))
+	}
+	defer func() {
+		if err := a.Close(); err != nil {
+			slog.Debug("mcp2221 close failed", "err", err)
+		}
+	}()
+	reportConnectionChanges(ctx, a)
+	console.Printf("watching GP1 for %s edges; press Ctrl-C to stop\n", edge)
+	for ev := range a.WatchInterrupt(ctx, interval) {
+		motion, err := s.CheckMotionInterrupt(ctx)
+		if err != nil {
+			console.Errorf("error checking motion detection on BMA220: %s", console.Red(err))
+			continue
+		}
+		console.Printf("%s motion interrupt: %s\n", ev.Time.Format("15:04:05.000"), console.Yellow(motion))
+		if err := s.ResetMotionInterrupt(ctx); err != nil {
+			console.Errorf("error resetting motion detection on BMA220: %s", console.Red(err))
+		}
+	}
+	console.Print("stopping motion monitor")
+	return nil
+}
+
 var motionResetCmd = cli.Command{
 	Name: "reset",
 	Flags: []cli.Flag{
-		&cli.StringFlag{
-			Name:  "adapter,a",
-			Value: "mcp2221",
-		},
 		&cli.StringFlag{
 			Name:  "sensor,s",
 			Value: "bma220",
@@ -107,18 +153,15 @@ var motionResetCmd = cli.Command{
 		ctx := snsctx.SetVerbose(context.Background(), c.Bool("verbose"))
 		switch c.String("sensor") {
 		case "bma220":
-			switch c.String("adapter") {
-			case "mcp2221":
-				a := adapter.NewMCP2221()
-				err := a.Init()
-				if err != nil {
-					return console.Exit(1, "adapter initialization error: %s", console.Red(err))
-				}
-				s := accel.NewBMA220(a)
-				err = s.ResetMotionInterrupt(ctx)
-				if err != nil {
-					console.Errorf("error resetting motion detection on BMA220: %s", console.Red(err))
-				}
+			a, closeBus, err := busFromContext(c)
+			if err != nil {
+				return err
+			}
+			defer closeBus()
+			s := accel.NewBMA220(a)
+			err = s.ResetMotionInterrupt(ctx)
+			if err != nil {
+				console.Errorf("error resetting motion detection on BMA220: %s", console.Red(err))
 			}
 		}
 		return nil
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"context"
+	"net"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/urfave/cli/v2"
+
+	"github.com/mklimuk/sensors/cmd/sensors/console"
+	"github.com/mklimuk/sensors/remote"
+)
+
+var serveBusCmd = cli.Command{
+	Name:  "serve-bus",
+	Usage: "serve the adapter bus over TCP to remote clients",
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:  "listen",
+			Value: "127.0.0.1:7777",
+			Usage: "TCP address to listen on; addresses other than loopback require a token",
+		},
+		&cli.StringFlag{
+			Name:    "token",
+			EnvVars: []string{"SNS_BUS_TOKEN"},
+			Usage:   "shared token required from the clients",
+		},
+		&cli.DurationFlag{
+			Name:  "timeout",
+			Value: remote.DefaultTimeout,
+			Usage: "maximum duration of a request",
+		},
+	},
+	Action: func(c *cli.Context) error {
+		if c.String("token") == "" && !isLoopback(c.String("listen")) {
+			return console.Exit(1, "refusing to serve the bus on %s without a token", c.String("listen"))
+		}
+		bus, closeBus, err := busFromContext(c)
+		if err != nil {
+			return err
+		}
+		defer closeBus()
+		ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer cancel()
+		if a, ok := bus.(interface {
+			Open(context.Context) error
+			Close() error
+		}); ok {
+			// keep one HID handle for all the clients
+			if err := a.Open(ctx); err != nil {
+				return console.Exit(1, "could not open adapter: %s", console.Red(err))
+			}
+			defer func() { _ = a.Close() }()
+		}
+		srv := remote.NewServer(bus, remote.WithToken(c.String("token")), remote.WithTimeout(c.Duration("timeout")))
+		console.Printf("serving %s on %s; press Ctrl-C to stop\n", c.String("bus"), c.String("listen"))
+		err = srv.ListenAndServe(ctx, c.String("listen"))
+		if err != nil {
+			return console.Exit(1, "server error: %s", console.Red(err))
+		}
+		return nil
+	},
+}
+
+// isLoopback tells whether the TCP address only accepts local connections.
+// An empty host listens on all interfaces.
+func isLoopback(address string) bool {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil || host == "" {
+		return false
+	}
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
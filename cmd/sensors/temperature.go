@@ -4,11 +4,8 @@ import (
 	"context"
 	"strconv"
 
-	"github.com/mklimuk/sensors"
-	"github.com/mklimuk/sensors/adapter"
 	"github.com/mklimuk/sensors/cmd/sensors/console"
 	"github.com/mklimuk/sensors/environment"
-	"github.com/mklimuk/sensors/i2c"
 	"github.com/mklimuk/sensors/snsctx"
 	"github.com/urfave/cli/v2"
 )
@@ -17,18 +14,10 @@ var tempReadCmd = cli.Command{
 	Name:    "temperature",
 	Aliases: []string{"temp"},
 	Flags: []cli.Flag{
-		&cli.StringFlag{
-			Name:  "adapter,a",
-			Value: "mcp2221",
-		},
 		&cli.StringFlag{
 			Name:  "sensor,s",
 			Value: "hih6021",
 		},
-		&cli.StringFlag{
-			Name:  "device,d",
-			Value: "/dev/i2c-1",
-		},
 		&cli.StringFlag{
 			Name:  "addr",
 			Value: "4d",
@@ -38,31 +27,11 @@ var tempReadCmd = cli.Command{
 	Action: func(c *cli.Context) error {
 		ctx := snsctx.SetVerbose(context.Background(), c.Bool("verbose"))
 
-		var a sensors.I2CBus
-		switch c.String("adapter") {
-		case "mcp2221":
-			mcp2221 := adapter.NewMCP2221()
-			err := mcp2221.Init()
-			if err != nil {
-				return console.Exit(1, "adapter initialization error: %s", console.Red(err))
-			}
-			a = mcp2221
-		case "generic":
-			fallthrough
-		case "nanopi":
-			var err error
-			bus, err := i2c.NewGenericBus(c.String("device"))
-			if err != nil {
-				return console.Exit(1, "adapter initialization error: %s", console.Red(err))
-			}
-			defer func() {
-				err := bus.Close()
-				if err != nil {
-					console.Errorf("error closing bus: %s", console.Red(err))
-				}
-			}()
-			a = bus
+		a, closeBus, err := busFromContext(c)
+		if err != nil {
+			return err
 		}
+		defer closeBus()
 		switch c.String("sensor") {
 		case "tc74":
 			addr := c.String("addr")
@@ -42,6 +42,8 @@ var usbDetectCmd = cli.Command{
 	Action: func(c *cli.Context) error {
 		predefined := map[string][]uint16{
 			"MCP2221": {adapter.VendorID, adapter.ProductID},
+			"CP2112":  {adapter.CP2112VendorID, adapter.CP2112ProductID},
+			"MCP2210": {adapter.MCP2210VendorID, adapter.MCP2210ProductID},
 		}
 
 		// List all HID devices
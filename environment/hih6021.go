@@ -18,8 +18,8 @@ const minReadInterval = time.Second
 
 var divider = float32(1<<14 - 2)
 
-var ErrStaleData = fmt.Errorf("stale data")
-var ErrCommandMode = fmt.Errorf("device in command mode")
+var ErrStaleData = fmt.Errorf("hih6021: %w", sensors.ErrStale)
+var ErrCommandMode = fmt.Errorf("hih6021: device in command mode: %w", sensors.ErrNotReady)
 
 // HIH6021 represents Honywell HumidIcon™ Digital Humidity/Temperature sensor
 type HIH6021 struct {
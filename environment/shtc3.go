@@ -14,8 +14,9 @@ const shtc3Address = 0x70
 
 // Commands (Big Endian on the wire)
 const (
-	shtc3CmdWake  uint16 = 0x3517
-	shtc3CmdSleep uint16 = 0xB098
+	shtc3CmdWake   uint16 = 0x3517
+	shtc3CmdSleep  uint16 = 0xB098
+	shtc3CmdReadID uint16 = 0xEFC8
 
 	// Normal power, clock stretching disabled
 	// Measure T first, then RH
@@ -61,6 +62,38 @@ func (s *SHTC3) GetTempAndHum(ctx context.Context) (float32, float32, error) {
 	return s.lastTemp, s.lastHum, nil
 }
 
+// SHTC3 ID register bits identifying the product (bit 11 and bits 5:0)
+const (
+	SHTC3IDMask  uint16 = 0x083F
+	SHTC3IDValue uint16 = 0x0807
+)
+
+// ReadID wakes the sensor up, reads its 16-bit ID register and puts it back
+// to sleep. A device is an SHTC3 if id&SHTC3IDMask == SHTC3IDValue.
+func (s *SHTC3) ReadID(ctx context.Context) (id uint16, err error) {
+	if err := s.writeCmd(ctx, shtc3CmdWake); err != nil {
+		return 0, fmt.Errorf("shtc3: wake failed: %w", err)
+	}
+	// put the sensor back to sleep on every exit path
+	defer func() {
+		sleepErr := s.writeCmd(ctx, shtc3CmdSleep)
+		if sleepErr != nil && err == nil {
+			id, err = 0, fmt.Errorf("shtc3: sleep failed: %w", sleepErr)
+		}
+	}()
+	time.Sleep(1 * time.Millisecond)
+	buf := make([]byte, 3)
+	var cmd [2]byte
+	binary.BigEndian.PutUint16(cmd[:], shtc3CmdReadID)
+	if err := sensors.WriteRead(ctx, s.transport, shtc3Address, cmd[:], buf); err != nil {
+		return 0, fmt.Errorf("shtc3: read id failed: %w", err)
+	}
+	if err := shtCRC8Check(buf[0:2], buf[2]); err != nil {
+		return 0, fmt.Errorf("shtc3: id: %w", err)
+	}
+	return binary.BigEndian.Uint16(buf[0:2]), nil
+}
+
 func (s *SHTC3) measure(ctx context.Context) error {
 	// Wake up from sleep
 	if err := s.writeCmd(ctx, shtc3CmdWake); err != nil {
@@ -83,11 +116,11 @@ func (s *SHTC3) measure(ctx context.Context) error {
 	}
 
 	// Verify CRC for temperature and humidity words
-	if !shtCRC8Check(buf[0:2], buf[2]) {
-		return fmt.Errorf("shtc3: temperature CRC mismatch")
+	if err := shtCRC8Check(buf[0:2], buf[2]); err != nil {
+		return fmt.Errorf("shtc3: temperature: %w", err)
 	}
-	if !shtCRC8Check(buf[3:5], buf[5]) {
-		return fmt.Errorf("shtc3: humidity CRC mismatch")
+	if err := shtCRC8Check(buf[3:5], buf[5]); err != nil {
+		return fmt.Errorf("shtc3: humidity: %w", err)
 	}
 
 	rawT := binary.BigEndian.Uint16(buf[0:2])
@@ -129,6 +162,12 @@ func shtCRC8(data []byte) byte {
 	return crc
 }
 
-func shtCRC8Check(data []byte, expected byte) bool {
-	return shtCRC8(data) == expected
+// shtCRC8Check returns a *sensors.CRCError when the CRC of data is not
+// expected.
+func shtCRC8Check(data []byte, expected byte) error {
+	crc := shtCRC8(data)
+	if crc != expected {
+		return &sensors.CRCError{Expected: expected, Got: crc}
+	}
+	return nil
 }
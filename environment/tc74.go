@@ -19,6 +19,8 @@ type TC74 struct {
 	transport sensors.I2CBus
 	address   byte
 	lastTemp  float32
+	// hasReading is set once a conversion completed
+	hasReading bool
 }
 
 type TC74Config struct {
@@ -47,12 +49,8 @@ func NewTC74(trans sensors.I2CBus, opts ...TC74ConfigOption) *TC74 {
 
 // GetConfig reads the configuration register (0x01) and returns its value.
 func (sensor *TC74) GetConfig(ctx context.Context) (byte, error) {
-	err := sensor.transport.WriteToAddr(ctx, sensor.address, []byte{tc74ConfigRegister})
-	if err != nil {
-		return 0, fmt.Errorf("tc74: could not write config register request: %w", err)
-	}
 	resp := make([]byte, 1)
-	err = sensor.transport.ReadFromAddr(ctx, sensor.address, resp)
+	err := sensors.WriteRead(ctx, sensor.transport, sensor.address, []byte{tc74ConfigRegister}, resp)
 	if err != nil {
 		return 0, fmt.Errorf("tc74: could not read config register: %w", err)
 	}
@@ -67,27 +65,25 @@ func (sensor *TC74) GetTemperature(ctx context.Context) (float32, error) {
 		return 0, fmt.Errorf("tc74: could not get config: %w", err)
 	}
 	if (config & 0x40) == 0 {
-		// TODO: do we want to return a common error here?
+		// keep the previous reading until a conversion completes
+		if !sensor.hasReading {
+			return 0, fmt.Errorf("tc74: no conversion completed: %w", sensors.ErrNotReady)
+		}
 		return sensor.lastTemp, nil
 	}
-	// Write the temperature register address (0x00)
-	err = sensor.transport.WriteToAddr(ctx, sensor.address, []byte{tc74TempRegister})
-	if err != nil {
-		return 0, fmt.Errorf("tc74: could not write temp register request: %w", err)
-	}
 	resp := make([]byte, 1)
-	err = sensor.transport.ReadFromAddr(ctx, sensor.address, resp)
+	err = sensors.WriteRead(ctx, sensor.transport, sensor.address, []byte{tc74TempRegister}, resp)
 	if err != nil {
 		return 0, fmt.Errorf("tc74: could not read temp register: %w", err)
 	}
 	// Convert 2's complement 8-bit value to int8
 	temp := int8(resp[0])
 	sensor.lastTemp = float32(temp)
+	sensor.hasReading = true
 	return sensor.lastTemp, nil
 }
 
-// GetHumidity is not supported by TC74 and returns an error.
+// GetHumidity is not supported by TC74 and returns sensors.ErrUnsupported.
 func (sensor *TC74) GetHumidity(ctx context.Context) (float32, error) {
-	// TODO: add global unsupported error
-	return 0, nil
+	return 0, fmt.Errorf("tc74: humidity %w", sensors.ErrUnsupported)
 }
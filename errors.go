@@ -0,0 +1,69 @@
+package sensors
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Errors shared by the adapters and drivers of this module. They are wrapped
+// with context, so match them with errors.Is:
+//
+//   - ErrNACK and ErrBusBusy: the transfer may be retried, after RecoverBus
+//     when they persist
+//   - ErrTimeout: the adapter or the device did not complete in time
+//   - ErrCRC: the data was corrupted on the bus, retrying usually succeeds
+//   - ErrNotReady: the device has no data yet, retry later
+//   - ErrStale: the data was already read, the value returned with it is the
+//     previous measurement
+//   - ErrUnsupported: the device or adapter cannot do it, do not retry
+//   - ErrInvalidArgument: the request is out of the device limits, do not
+//     retry
+//   - ErrDisconnected: the adapter is gone and must be reopened
+var (
+	ErrNACK            = errors.New("i2c transfer not acknowledged")
+	ErrTimeout         = errors.New("timeout")
+	ErrCRC             = errors.New("crc mismatch")
+	ErrNotReady        = errors.New("device not ready")
+	ErrStale           = errors.New("stale data")
+	ErrUnsupported     = errors.New("not supported")
+	ErrInvalidArgument = errors.New("invalid argument")
+	ErrDisconnected    = errors.New("adapter disconnected")
+)
+
+// NACKError is returned when the device at Address does not acknowledge a
+// transfer. It matches ErrNACK.
+type NACKError struct {
+	Address byte
+	// Err is the cause reported by the adapter, if any
+	Err error
+}
+
+func (e *NACKError) Error() string {
+	if e.Err != nil {
+		return fmt.Sprintf("i2c address %#x not acknowledged: %v", e.Address, e.Err)
+	}
+	return fmt.Sprintf("i2c address %#x not acknowledged", e.Address)
+}
+
+func (e *NACKError) Is(target error) bool {
+	return target == ErrNACK
+}
+
+func (e *NACKError) Unwrap() error {
+	return e.Err
+}
+
+// CRCError is returned when the checksum sent by a device does not match its
+// data. It matches ErrCRC.
+type CRCError struct {
+	Expected byte
+	Got      byte
+}
+
+func (e *CRCError) Error() string {
+	return fmt.Sprintf("crc mismatch: expected %#x, got %#x", e.Expected, e.Got)
+}
+
+func (e *CRCError) Is(target error) bool {
+	return target == ErrCRC
+}
@@ -0,0 +1,25 @@
+package sensors
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTypedErrors(t *testing.T) {
+	cause := errors.New("remote I/O error")
+	err := fmt.Errorf("read failed: %w", &NACKError{Address: 0x42, Err: cause})
+	assert.ErrorIs(t, err, ErrNACK)
+	assert.ErrorIs(t, err, cause)
+	var nack *NACKError
+	assert.ErrorAs(t, err, &nack)
+	assert.Equal(t, byte(0x42), nack.Address)
+	assert.EqualError(t, err, "read failed: i2c address 0x42 not acknowledged: remote I/O error")
+	assert.NotErrorIs(t, err, ErrCRC)
+
+	err = fmt.Errorf("shtc3: temperature: %w", &CRCError{Expected: 0x12, Got: 0x34})
+	assert.ErrorIs(t, err, ErrCRC)
+	assert.EqualError(t, err, "shtc3: temperature: crc mismatch: expected 0x12, got 0x34")
+}
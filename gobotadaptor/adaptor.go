@@ -0,0 +1,276 @@
+// Package gobotadaptor exposes the buses of this module as a Gobot adaptor,
+// so that Gobot I2C, SPI and GPIO drivers run over an MCP2221, an MCP2210,
+// a CP2112 or any /dev/i2c-N opened with i2c.NewGenericBus instead of a
+// specific board.
+//
+// Example usage:
+//
+//	bridge := adapter.NewMCP2221()
+//	a := gobotadaptor.NewMCP2221Adaptor(bridge)
+//	pot := i2c.NewGenericDriver(a, "mcp4661", 0x28)
+//	led := gpio.NewLedDriver(a, "GP0")
+package gobotadaptor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/mklimuk/sensors"
+	"github.com/mklimuk/sensors/adapter"
+	"gobot.io/x/gobot/v2"
+	"gobot.io/x/gobot/v2/drivers/gpio"
+	"gobot.io/x/gobot/v2/drivers/i2c"
+	"gobot.io/x/gobot/v2/drivers/spi"
+)
+
+var _ gobot.Adaptor = &Adaptor{}
+var _ i2c.Connector = &Adaptor{}
+var _ spi.Connector = &Adaptor{}
+var _ gpio.DigitalReader = &Adaptor{}
+var _ gpio.DigitalWriter = &Adaptor{}
+
+var ErrNoI2C = errors.New("adaptor has no i2c bus")
+var ErrNoSPI = errors.New("adaptor has no spi bus")
+var ErrNoGPIO = errors.New("adaptor has no gpio pins")
+
+// Default SPI parameters reported to the Gobot SPI drivers. The mode and
+// speed requested by the drivers are applied to the buses implementing
+// transferMode and are otherwise configured on the bus itself.
+const (
+	defaultSPIBits     = 8
+	defaultSPIMaxSpeed = 1_000_000
+)
+
+// Pins reads and drives the GPIO pins of an adaptor by name.
+type Pins interface {
+	DigitalRead(pin string) (int, error)
+	DigitalWrite(pin string, val byte) error
+}
+
+// transferMode is implemented by the SPI buses whose mode and clock can be
+// changed, like adapter.MCP2210.
+type transferMode interface {
+	SetTransferMode(mode adapter.SPIMode, hz int) error
+}
+
+// session is implemented by the USB bridges which keep their HID handle
+// open between Open and Close.
+type session interface {
+	Open(ctx context.Context) error
+	Close() error
+}
+
+// Adaptor is a Gobot adaptor over a sensors.I2CBus, a sensors.SPIBus and
+// GPIO pins, any of which may be missing. Gobot bus and chip numbers are
+// ignored as each adaptor has a single bus of each kind.
+type Adaptor struct {
+	mx   sync.Mutex
+	name string
+	i2c  sensors.I2CBus
+	spi  sensors.SPIBus
+	pins Pins
+	// opened lists the bridges whose session was started by Connect
+	opened []session
+}
+
+type Option func(*Adaptor)
+
+// WithI2C sets the I2C bus of the adaptor.
+func WithI2C(bus sensors.I2CBus) Option {
+	return func(a *Adaptor) {
+		a.i2c = bus
+	}
+}
+
+// WithSPI sets the SPI bus of the adaptor.
+func WithSPI(bus sensors.SPIBus) Option {
+	return func(a *Adaptor) {
+		a.spi = bus
+	}
+}
+
+// WithPins sets the GPIO pins of the adaptor.
+func WithPins(pins Pins) Option {
+	return func(a *Adaptor) {
+		a.pins = pins
+	}
+}
+
+// WithName sets the Gobot name of the adaptor.
+func WithName(name string) Option {
+	return func(a *Adaptor) {
+		a.name = name
+	}
+}
+
+func NewAdaptor(opts ...Option) *Adaptor {
+	a := &Adaptor{name: gobot.DefaultName("Sensors")}
+	for _, opt := range opts {
+		opt(a)
+	}
+	return a
+}
+
+// NewMCP2221Adaptor returns an adaptor using the I2C bus of d and its GP0 to
+// GP3 pins, named "GP0" to "GP3" or "0" to "3".
+func NewMCP2221Adaptor(d *adapter.MCP2221, opts ...Option) *Adaptor {
+	return NewAdaptor(append([]Option{WithName(gobot.DefaultName("MCP2221")), WithI2C(d), WithPins(mcp2221Pins{d: d})}, opts...)...)
+}
+
+func (a *Adaptor) Name() string {
+	return a.name
+}
+
+func (a *Adaptor) SetName(name string) {
+	a.name = name
+}
+
+// Connect starts a session on the USB bridges so that they keep one HID
+// handle until Finalize.
+func (a *Adaptor) Connect() error {
+	a.mx.Lock()
+	defer a.mx.Unlock()
+	for _, bus := range []any{a.i2c, a.spi} {
+		s, ok := bus.(session)
+		if !ok {
+			continue
+		}
+		err := s.Open(context.Background())
+		if err != nil {
+			_ = a.finalize()
+			return fmt.Errorf("could not open bus: %w", err)
+		}
+		a.opened = append(a.opened, s)
+	}
+	return nil
+}
+
+// Finalize ends the sessions started by Connect. The buses are not closed
+// as they are owned by the caller.
+func (a *Adaptor) Finalize() error {
+	a.mx.Lock()
+	defer a.mx.Unlock()
+	return a.finalize()
+}
+
+func (a *Adaptor) finalize() error {
+	var errs []error
+	for _, s := range a.opened {
+		errs = append(errs, s.Close())
+	}
+	a.opened = nil
+	return errors.Join(errs...)
+}
+
+// GetI2cConnection returns a connection to the device at address.
+func (a *Adaptor) GetI2cConnection(address int, busNr int) (i2c.Connection, error) {
+	if a.i2c == nil {
+		return nil, ErrNoI2C
+	}
+	if address < 0 || address > 0x7F {
+		return nil, fmt.Errorf("invalid i2c address %#x", address)
+	}
+	return &i2cConnection{bus: a.i2c, address: byte(address)}, nil
+}
+
+func (a *Adaptor) DefaultI2cBus() int {
+	return 0
+}
+
+// GetSpiConnection returns a connection to the device selected by the SPI
+// bus, switching the bus to mode and maxSpeed when it supports it. Only
+// 8-bit words are supported.
+func (a *Adaptor) GetSpiConnection(busNum, chip, mode, bits int, maxSpeed int64) (spi.Connection, error) {
+	if a.spi == nil {
+		return nil, ErrNoSPI
+	}
+	if bits != defaultSPIBits {
+		return nil, fmt.Errorf("unsupported spi word size %d", bits)
+	}
+	if mode < 0 || mode > int(adapter.SPIMode3) {
+		return nil, fmt.Errorf("invalid spi mode %d", mode)
+	}
+	if m, ok := a.spi.(transferMode); ok {
+		err := m.SetTransferMode(adapter.SPIMode(mode), int(maxSpeed))
+		if err != nil {
+			return nil, fmt.Errorf("could not set spi mode: %w", err)
+		}
+	}
+	return &spiConnection{bus: a.spi}, nil
+}
+
+func (a *Adaptor) SpiDefaultBusNumber() int {
+	return 0
+}
+
+func (a *Adaptor) SpiDefaultChipNumber() int {
+	return 0
+}
+
+func (a *Adaptor) SpiDefaultMode() int {
+	return 0
+}
+
+func (a *Adaptor) SpiDefaultBitCount() int {
+	return defaultSPIBits
+}
+
+func (a *Adaptor) SpiDefaultMaxSpeed() int64 {
+	return defaultSPIMaxSpeed
+}
+
+func (a *Adaptor) DigitalRead(pin string) (int, error) {
+	if a.pins == nil {
+		return 0, ErrNoGPIO
+	}
+	return a.pins.DigitalRead(pin)
+}
+
+func (a *Adaptor) DigitalWrite(pin string, val byte) error {
+	if a.pins == nil {
+		return ErrNoGPIO
+	}
+	return a.pins.DigitalWrite(pin, val)
+}
+
+// mcp2221Pins switches the MCP2221 pins to input on read and to output on
+// write, like the Gobot board adaptors do.
+type mcp2221Pins struct {
+	d *adapter.MCP2221
+}
+
+func (p mcp2221Pins) DigitalRead(name string) (int, error) {
+	pin, err := adapter.ParseGPIOPin(name)
+	if err != nil {
+		return 0, err
+	}
+	ctx := context.Background()
+	err = p.d.SetGPIODirection(ctx, pin, adapter.GPIOModeIn)
+	if err != nil {
+		return 0, fmt.Errorf("could not set %s direction: %w", pin, err)
+	}
+	values, err := p.d.ReadGPIO(ctx)
+	if err != nil {
+		return 0, err
+	}
+	return int(values.Value(pin)), nil
+}
+
+func (p mcp2221Pins) DigitalWrite(name string, val byte) error {
+	pin, err := adapter.ParseGPIOPin(name)
+	if err != nil {
+		return err
+	}
+	ctx := context.Background()
+	err = p.d.SetGPIO(ctx, pin, val)
+	if err != nil {
+		return err
+	}
+	err = p.d.SetGPIODirection(ctx, pin, adapter.GPIOModeOut)
+	if err != nil {
+		return fmt.Errorf("could not set %s direction: %w", pin, err)
+	}
+	return nil
+}
@@ -0,0 +1,159 @@
+package gobotadaptor
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/mklimuk/sensors/adapter"
+	"github.com/mklimuk/sensors/sim"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gobot.io/x/gobot/v2/drivers/i2c"
+)
+
+// registers is a device with 8-bit registers addressed by the first byte of
+// each transfer.
+type registers struct {
+	values  [256]byte
+	pointer byte
+}
+
+func (r *registers) Write(w []byte) error {
+	r.pointer = w[0]
+	for _, b := range w[1:] {
+		r.values[r.pointer] = b
+		r.pointer++
+	}
+	return nil
+}
+
+func (r *registers) Read(buf []byte) error {
+	for i := range buf {
+		buf[i] = r.values[r.pointer]
+		r.pointer++
+	}
+	return nil
+}
+
+// loopback records the transfers and answers with their bitwise complement.
+type loopback struct {
+	written [][]byte
+}
+
+func (l *loopback) Transfer(ctx context.Context, w, r []byte) error {
+	l.written = append(l.written, bytes.Clone(w))
+	for i := range r {
+		r[i] = ^w[i]
+	}
+	return nil
+}
+
+// sessionBus counts the sessions started on a simulated bus.
+type sessionBus struct {
+	*sim.Bus
+	opens  int
+	closes int
+}
+
+func (b *sessionBus) Open(ctx context.Context) error {
+	b.opens++
+	return nil
+}
+
+func (b *sessionBus) Close() error {
+	b.closes++
+	return nil
+}
+
+// pins records the levels written and answers reads with them.
+type pins map[string]byte
+
+func (p pins) DigitalRead(pin string) (int, error) {
+	return int(p[pin]), nil
+}
+
+func (p pins) DigitalWrite(pin string, val byte) error {
+	p[pin] = val
+	return nil
+}
+
+func TestI2CAdaptor(t *testing.T) {
+	bus := &sessionBus{Bus: sim.NewBus()}
+	dev := &registers{}
+	bus.Attach(0x28, dev)
+	levels := pins{"GP2": 1}
+
+	a := NewAdaptor(WithI2C(bus), WithPins(levels))
+	require.NoError(t, a.Connect())
+
+	driver := i2c.NewGenericDriver(a, "registers", 0x28)
+	require.NoError(t, driver.Start())
+	require.NoError(t, driver.WriteByteData(0x10, 0xAB))
+	require.NoError(t, driver.WriteWordData(0x20, 0x1234))
+	value, err := driver.ReadByteData(0x10)
+	require.NoError(t, err)
+	assert.Equal(t, byte(0xAB), value)
+	word, err := driver.ReadWordData(0x20)
+	require.NoError(t, err)
+	assert.Equal(t, uint16(0x1234), word)
+	assert.Equal(t, byte(0x34), dev.values[0x20])
+	assert.Equal(t, 1, bus.opens)
+
+	require.NoError(t, a.DigitalWrite("GP0", 1))
+	assert.Equal(t, byte(1), levels["GP0"])
+	read, err := a.DigitalRead("GP2")
+	require.NoError(t, err)
+	assert.Equal(t, 1, read)
+
+	_, err = a.GetI2cConnection(0x80, 0)
+	assert.Error(t, err)
+	_, err = a.GetSpiConnection(0, 0, 0, 8, 0)
+	assert.ErrorIs(t, err, ErrNoSPI)
+
+	require.NoError(t, a.Finalize())
+	assert.Equal(t, 1, bus.closes)
+}
+
+// configurable is a loopback recording the transfer mode requested.
+type configurable struct {
+	loopback
+	mode adapter.SPIMode
+	hz   int
+}
+
+func (c *configurable) SetTransferMode(mode adapter.SPIMode, hz int) error {
+	c.mode = mode
+	c.hz = hz
+	return nil
+}
+
+func TestSPIConnection_TransferMode(t *testing.T) {
+	bus := &configurable{}
+	a := NewAdaptor(WithSPI(bus))
+	_, err := a.GetSpiConnection(0, 0, 3, 8, 4_000_000)
+	require.NoError(t, err)
+	assert.Equal(t, adapter.SPIMode3, bus.mode)
+	assert.Equal(t, 4_000_000, bus.hz)
+
+	_, err = a.GetSpiConnection(0, 0, 4, 8, 4_000_000)
+	assert.Error(t, err)
+}
+
+func TestSPIConnection(t *testing.T) {
+	bus := &loopback{}
+	a := NewAdaptor(WithSPI(bus))
+	conn, err := a.GetSpiConnection(a.SpiDefaultBusNumber(), a.SpiDefaultChipNumber(), a.SpiDefaultMode(), a.SpiDefaultBitCount(), a.SpiDefaultMaxSpeed())
+	require.NoError(t, err)
+
+	data := make([]byte, 2)
+	require.NoError(t, conn.ReadCommandData([]byte{0x03, 0x00}, data))
+	assert.Equal(t, []byte{0xFF, 0xFF}, data)
+	require.NoError(t, conn.WriteBlockData(0x02, []byte{0x01, 0x02}))
+	assert.Equal(t, [][]byte{{0x03, 0x00, 0x00, 0x00}, {0x02, 0x01, 0x02}}, bus.written)
+
+	_, err = a.GetI2cConnection(0x28, 0)
+	assert.ErrorIs(t, err, ErrNoI2C)
+	_, err = a.DigitalRead("GP0")
+	assert.ErrorIs(t, err, ErrNoGPIO)
+}
@@ -0,0 +1,136 @@
+package gobotadaptor
+
+import (
+	"context"
+	"encoding/binary"
+
+	"github.com/mklimuk/sensors"
+	"gobot.io/x/gobot/v2/drivers/i2c"
+	"gobot.io/x/gobot/v2/drivers/spi"
+)
+
+var _ i2c.Connection = &i2cConnection{}
+var _ spi.Connection = &spiConnection{}
+
+// i2cConnection implements the Gobot I2C operations for one device. Register
+// reads use a repeated start when the bus supports it.
+type i2cConnection struct {
+	bus     sensors.I2CBus
+	address byte
+}
+
+func (c *i2cConnection) Read(p []byte) (int, error) {
+	err := c.bus.ReadFromAddr(context.Background(), c.address, p)
+	if err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+func (c *i2cConnection) Write(p []byte) (int, error) {
+	err := c.bus.WriteToAddr(context.Background(), c.address, p)
+	if err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+func (c *i2cConnection) Close() error {
+	return nil
+}
+
+func (c *i2cConnection) ReadByte() (byte, error) {
+	buf := make([]byte, 1)
+	_, err := c.Read(buf)
+	return buf[0], err
+}
+
+func (c *i2cConnection) ReadByteData(reg uint8) (uint8, error) {
+	buf := make([]byte, 1)
+	err := c.ReadBlockData(reg, buf)
+	return buf[0], err
+}
+
+// ReadWordData reads a SMBus word, low byte first.
+func (c *i2cConnection) ReadWordData(reg uint8) (uint16, error) {
+	buf := make([]byte, 2)
+	err := c.ReadBlockData(reg, buf)
+	return binary.LittleEndian.Uint16(buf), err
+}
+
+func (c *i2cConnection) ReadBlockData(reg uint8, data []byte) error {
+	return sensors.WriteRead(context.Background(), c.bus, c.address, []byte{reg}, data)
+}
+
+func (c *i2cConnection) WriteByte(val byte) error {
+	_, err := c.Write([]byte{val})
+	return err
+}
+
+func (c *i2cConnection) WriteBytes(data []byte) error {
+	_, err := c.Write(data)
+	return err
+}
+
+func (c *i2cConnection) WriteByteData(reg uint8, val uint8) error {
+	return c.WriteBytes([]byte{reg, val})
+}
+
+// WriteWordData writes a SMBus word, low byte first.
+func (c *i2cConnection) WriteWordData(reg uint8, val uint16) error {
+	return c.WriteBytes([]byte{reg, byte(val), byte(val >> 8)})
+}
+
+func (c *i2cConnection) WriteBlockData(reg uint8, data []byte) error {
+	return c.WriteBytes(append([]byte{reg}, data...))
+}
+
+// spiConnection implements the Gobot SPI operations over a full-duplex
+// transfer, each call being one transaction.
+type spiConnection struct {
+	bus sensors.SPIBus
+}
+
+// ReadCommandData sends command and reads len(data) bytes clocked out after
+// it.
+func (c *spiConnection) ReadCommandData(command []byte, data []byte) error {
+	w := make([]byte, len(command)+len(data))
+	copy(w, command)
+	r := make([]byte, len(w))
+	err := c.bus.Transfer(context.Background(), w, r)
+	if err != nil {
+		return err
+	}
+	copy(data, r[len(command):])
+	return nil
+}
+
+func (c *spiConnection) ReadByteData(reg uint8) (uint8, error) {
+	buf := make([]byte, 1)
+	err := c.ReadCommandData([]byte{reg}, buf)
+	return buf[0], err
+}
+
+func (c *spiConnection) ReadBlockData(reg uint8, data []byte) error {
+	return c.ReadCommandData([]byte{reg}, data)
+}
+
+func (c *spiConnection) WriteByte(val byte) error {
+	return c.WriteBytes([]byte{val})
+}
+
+func (c *spiConnection) WriteBytes(data []byte) error {
+	return c.bus.Transfer(context.Background(), data, nil)
+}
+
+func (c *spiConnection) WriteByteData(reg uint8, val uint8) error {
+	return c.WriteBytes([]byte{reg, val})
+}
+
+func (c *spiConnection) WriteBlockData(reg uint8, data []byte) error {
+	return c.WriteBytes(append([]byte{reg}, data...))
+}
+
+func (c *spiConnection) Close() error {
+	return nil
+}
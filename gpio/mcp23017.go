@@ -145,12 +145,8 @@ func (m *MCP23017) InitB(ctx context.Context, inout byte) error {
 func (m *MCP23017) readRegistry(ctx context.Context, addr byte) (byte, error) {
 	m.mx.Lock()
 	defer m.mx.Unlock()
-	err := m.transport.WriteToAddr(ctx, m.address, []byte{addr})
-	if err != nil {
-		return 0x00, fmt.Errorf("could not set I/O registry address: %w", err)
-	}
 	buf := make([]byte, 1)
-	err = m.transport.ReadFromAddr(ctx, m.address, buf)
+	err := sensors.WriteRead(ctx, m.transport, m.address, []byte{addr}, buf)
 	if err != nil {
 		return 0x00, fmt.Errorf("could not read gpio data: %w", err)
 	}
@@ -5,8 +5,10 @@ import (
 	"encoding/hex"
 	"fmt"
 	"log/slog"
+	"strings"
 
 	"github.com/mklimuk/sensors"
+	"periph.io/x/conn/v3/gpio"
 	"periph.io/x/conn/v3/i2c"
 	"periph.io/x/conn/v3/i2c/i2creg"
 	"periph.io/x/conn/v3/physic"
@@ -14,9 +16,18 @@ import (
 )
 
 var _ sensors.I2CBus = &GenericBus{}
+var _ sensors.I2CTransactor = &GenericBus{}
+
+// nackMessages are the messages of the errnos returned by the Linux I2C
+// drivers when an address is not acknowledged. periph.io formats them
+// without wrapping the errno.
+var nackMessages = []string{"remote I/O error", "no such device or address"}
 
 type GenericBus struct {
 	bus i2c.BusCloser
+	// scl and sda are the pins used for bus recovery, see SetRecoveryPins
+	scl gpio.PinIO
+	sda gpio.PinIO
 }
 
 func NewGenericBus(dev string) (*GenericBus, error) {
@@ -37,7 +48,7 @@ func NewGenericBus(dev string) (*GenericBus, error) {
 func (b *GenericBus) ReadFromAddr(ctx context.Context, address byte, buffer []byte) error {
 	err := b.bus.Tx(uint16(address), nil, buffer)
 	if err != nil {
-		return fmt.Errorf("could not read from i2c bus %x: %w", address, err)
+		return fmt.Errorf("could not read from i2c bus %x: %w", address, txError(address, err))
 	}
 	slog.Debug("i2c read completed", "address", address, "buffer", hex.Dump(buffer))
 	return nil
@@ -47,11 +58,23 @@ func (b *GenericBus) WriteToAddr(ctx context.Context, address byte, buffer []byt
 	slog.Debug("writing to i2c bus", "address", address, "buffer", hex.Dump(buffer))
 	err := b.bus.Tx(uint16(address), buffer, nil)
 	if err != nil {
-		return fmt.Errorf("could not write to i2c bus %x: %w", address, err)
+		return fmt.Errorf("could not write to i2c bus %x: %w", address, txError(address, err))
 	}
 	return nil
 }
 
+// Tx writes w and reads len(r) bytes from the device at address in a single
+// transaction using a repeated start condition.
+func (b *GenericBus) Tx(ctx context.Context, address byte, w, r []byte) error {
+	slog.Debug("i2c transaction", "address", address, "write", hex.Dump(w))
+	err := b.bus.Tx(uint16(address), w, r)
+	if err != nil {
+		return fmt.Errorf("could not complete i2c transaction with %x: %w", address, txError(address, err))
+	}
+	slog.Debug("i2c transaction completed", "address", address, "read", hex.Dump(r))
+	return nil
+}
+
 // SetSpeed sets the speed of the I2C bus in kHz.
 //
 // Example:
@@ -82,3 +105,14 @@ func (b *GenericBus) Release(ctx context.Context) error {
 func (b *GenericBus) Close() error {
 	return b.bus.Close()
 }
+
+// txError turns the errors reporting an address NACK into a
+// *sensors.NACKError.
+func txError(address byte, err error) error {
+	for _, msg := range nackMessages {
+		if strings.Contains(err.Error(), msg) {
+			return &sensors.NACKError{Address: address, Err: err}
+		}
+	}
+	return err
+}
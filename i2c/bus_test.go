@@ -0,0 +1,38 @@
+package i2c
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/mklimuk/sensors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"periph.io/x/conn/v3/physic"
+)
+
+// failingBus fails every transfer with err.
+type failingBus struct {
+	err error
+}
+
+func (b *failingBus) String() string                    { return "failing" }
+func (b *failingBus) Tx(addr uint16, w, r []byte) error { return b.err }
+func (b *failingBus) SetSpeed(f physic.Frequency) error { return nil }
+func (b *failingBus) Close() error                      { return nil }
+
+func TestGenericBus_NACK(t *testing.T) {
+	ctx := context.Background()
+	bus := &GenericBus{bus: &failingBus{err: errors.New("sysfs-i2c: remote I/O error")}}
+	err := bus.WriteToAddr(ctx, 0x42, []byte{0x01})
+	assert.ErrorIs(t, err, sensors.ErrNACK)
+	var nack *sensors.NACKError
+	require.ErrorAs(t, err, &nack)
+	assert.Equal(t, byte(0x42), nack.Address)
+	assert.ErrorIs(t, bus.Tx(ctx, 0x42, []byte{0x01}, make([]byte, 1)), sensors.ErrNACK)
+
+	bus = &GenericBus{bus: &failingBus{err: errors.New("sysfs-i2c: input/output error")}}
+	err = bus.ReadFromAddr(ctx, 0x42, make([]byte, 1))
+	assert.Error(t, err)
+	assert.NotErrorIs(t, err, sensors.ErrNACK)
+}
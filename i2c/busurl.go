@@ -0,0 +1,36 @@
+package i2c
+
+import (
+	"context"
+	"net/url"
+
+	"github.com/mklimuk/sensors"
+)
+
+func init() {
+	sensors.RegisterBus("i2cdev", openURL)
+}
+
+// openURL opens i2cdev:///dev/i2c-1?speed=100k. The path may also be a
+// periph.io bus name or number, e.g. i2cdev://1.
+func openURL(ctx context.Context, u *url.URL) (sensors.I2CBus, error) {
+	err := sensors.CheckBusQuery(u, "speed")
+	if err != nil {
+		return nil, err
+	}
+	bus, err := NewGenericBus(u.Host + u.Path)
+	if err != nil {
+		return nil, err
+	}
+	if speed := u.Query().Get("speed"); speed != "" {
+		hz, err := sensors.ParseSpeed(speed)
+		if err == nil {
+			err = bus.SetSpeed(hz / 1000)
+		}
+		if err != nil {
+			_ = bus.Close()
+			return nil, err
+		}
+	}
+	return bus, nil
+}
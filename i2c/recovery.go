@@ -0,0 +1,164 @@
+package i2c
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/mklimuk/sensors"
+	"periph.io/x/conn/v3/gpio"
+	"periph.io/x/conn/v3/i2c"
+	"periph.io/x/conn/v3/pin"
+)
+
+var _ sensors.BusRecoverer = &GenericBus{}
+
+// recoveryPulses is the number of clock pulses needed to complete the byte
+// a slave may be stuck in, acknowledge bit included.
+const recoveryPulses = 9
+
+// recoveryHalfPeriod is the half period of the bit-banged clock (100kHz).
+var recoveryHalfPeriod = 5 * time.Microsecond
+
+// SetRecoveryPins sets the GPIO pins wired to SCL and SDA, used by
+// RecoverBus when the bus driver does not report them.
+func (b *GenericBus) SetRecoveryPins(scl, sda gpio.PinIO) {
+	b.scl = scl
+	b.sda = sda
+}
+
+// RecoverBus frees the bus from a slave holding SDA low by bit-banging up to
+// nine clock pulses on SCL followed by a stop condition. The pins are taken
+// from SetRecoveryPins or else from the bus driver, and are switched back to
+// their I2C function afterwards. ErrRecoveryUnsupported is returned when the
+// pins are unknown and ErrBusStuck when the bus is not idle afterwards.
+func (b *GenericBus) RecoverBus(ctx context.Context) (sensors.BusRecovery, error) {
+	scl, sda := b.scl, b.sda
+	if pins, ok := b.bus.(i2c.Pins); ok && (scl == nil || sda == nil) {
+		scl, sda = pins.SCL(), pins.SDA()
+	}
+	if scl == nil || sda == nil || scl == gpio.INVALID || sda == gpio.INVALID {
+		return sensors.BusRecovery{}, fmt.Errorf("%w: SCL and SDA pins unknown", sensors.ErrRecoveryUnsupported)
+	}
+	defer restoreFunc(scl)()
+	defer restoreFunc(sda)()
+	return recoverLines(ctx, scl, sda)
+}
+
+// restoreFunc returns a function setting the pin back to its current
+// function, when the pin driver supports it.
+func restoreFunc(p gpio.PinIO) func() {
+	pf, ok := p.(pin.PinFunc)
+	if !ok {
+		return func() {}
+	}
+	f := pf.Func()
+	return func() {
+		err := pf.SetFunc(f)
+		if err != nil {
+			slog.Error("could not restore pin function", "pin", p.Name(), "func", f, "err", err)
+		}
+	}
+}
+
+// recoverLines runs the recovery sequence on the open-drain lines: SCL is
+// pulsed until the slave releases SDA, then a stop condition is generated.
+// Both lines are released when the sequence is interrupted.
+func recoverLines(ctx context.Context, scl, sda gpio.PinIO) (res sensors.BusRecovery, err error) {
+	err = release(scl)
+	if err == nil {
+		err = release(sda)
+	}
+	if err != nil {
+		return res, err
+	}
+	res.Before = lines(scl, sda)
+	res.After = res.Before
+	if res.Before.Idle() {
+		return res, nil
+	}
+	defer func() {
+		if err != nil {
+			_ = release(scl)
+			_ = release(sda)
+		}
+	}()
+	for sda.Read() == gpio.Low && res.ClockPulses < recoveryPulses {
+		err = scl.Out(gpio.Low)
+		if err != nil {
+			return res, fmt.Errorf("could not drive SCL: %w", err)
+		}
+		err = pause(ctx, recoveryHalfPeriod)
+		if err != nil {
+			return res, err
+		}
+		err = release(scl)
+		if err != nil {
+			return res, err
+		}
+		err = pause(ctx, recoveryHalfPeriod)
+		if err != nil {
+			return res, err
+		}
+		res.ClockPulses++
+	}
+	// stop condition: SDA rises while SCL is high
+	err = scl.Out(gpio.Low)
+	if err == nil {
+		err = sda.Out(gpio.Low)
+	}
+	if err != nil {
+		return res, fmt.Errorf("could not generate stop condition: %w", err)
+	}
+	err = pause(ctx, recoveryHalfPeriod)
+	if err != nil {
+		return res, err
+	}
+	err = release(scl)
+	if err != nil {
+		return res, err
+	}
+	err = pause(ctx, recoveryHalfPeriod)
+	if err != nil {
+		return res, err
+	}
+	err = release(sda)
+	if err != nil {
+		return res, err
+	}
+	err = pause(ctx, recoveryHalfPeriod)
+	if err != nil {
+		return res, err
+	}
+	res.After = lines(scl, sda)
+	if !res.After.Idle() {
+		return res, fmt.Errorf("%w after %d clock pulses: SCL %s, SDA %s", sensors.ErrBusStuck, res.ClockPulses, scl.Read(), sda.Read())
+	}
+	return res, nil
+}
+
+// pause waits for delay unless ctx is done first.
+func pause(ctx context.Context, delay time.Duration) error {
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// release lets the pull-up bring the open-drain line high.
+func release(p gpio.PinIO) error {
+	err := p.In(gpio.PullUp, gpio.NoEdge)
+	if err != nil {
+		return fmt.Errorf("could not release %s: %w", p.Name(), err)
+	}
+	return nil
+}
+
+func lines(scl, sda gpio.PinIO) sensors.BusLines {
+	return sensors.BusLines{SCL: scl.Read() == gpio.High, SDA: sda.Read() == gpio.High}
+}
@@ -0,0 +1,76 @@
+package i2c
+
+import (
+	"context"
+	"testing"
+
+	"github.com/mklimuk/sensors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"periph.io/x/conn/v3/gpio"
+	"periph.io/x/conn/v3/gpio/gpiotest"
+)
+
+// stuckSlave holds SDA low for a number of SCL clock pulses.
+type stuckSlave struct {
+	hold int
+}
+
+type sclPin struct {
+	gpiotest.Pin
+	slave *stuckSlave
+}
+
+func (p *sclPin) Out(l gpio.Level) error {
+	if l == gpio.Low && p.slave.hold > 0 {
+		p.slave.hold--
+	}
+	return p.Pin.Out(l)
+}
+
+type sdaPin struct {
+	gpiotest.Pin
+	slave *stuckSlave
+}
+
+func (p *sdaPin) Read() gpio.Level {
+	if p.slave.hold > 0 {
+		return gpio.Low
+	}
+	return p.Pin.Read()
+}
+
+func stuckBus(hold int) (*sclPin, *sdaPin) {
+	slave := &stuckSlave{hold: hold}
+	return &sclPin{Pin: gpiotest.Pin{N: "SCL"}, slave: slave}, &sdaPin{Pin: gpiotest.Pin{N: "SDA"}, slave: slave}
+}
+
+func TestRecoverLines(t *testing.T) {
+	ctx := context.Background()
+
+	scl, sda := stuckBus(0)
+	res, err := recoverLines(ctx, scl, sda)
+	require.NoError(t, err)
+	assert.True(t, res.Before.Idle())
+	assert.Equal(t, 0, res.ClockPulses)
+
+	scl, sda = stuckBus(3)
+	res, err = recoverLines(ctx, scl, sda)
+	require.NoError(t, err)
+	assert.False(t, res.Before.SDA)
+	assert.Equal(t, 3, res.ClockPulses)
+	assert.True(t, res.After.Idle())
+
+	scl, sda = stuckBus(100)
+	res, err = recoverLines(ctx, scl, sda)
+	assert.ErrorIs(t, err, sensors.ErrBusStuck)
+	assert.Equal(t, recoveryPulses, res.ClockPulses)
+	assert.False(t, res.After.SDA)
+
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+	scl, sda = stuckBus(3)
+	res, err = recoverLines(cancelled, scl, sda)
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Equal(t, 0, res.ClockPulses)
+}
@@ -19,12 +19,20 @@
 //	if err != nil { log.Fatal(err) }
 //
 //	_ = e.Halt() // optional on shutdown
+//
+// The driver also runs over any sensors.SPIBus, e.g. an MCP2210 USB bridge:
+//
+//	bridge := adapter.NewMCP2210(adapter.WithChipSelect(0))
+//	e := eeprom.NewWithBus(bridge)
+//	data, _ := e.Read(0x0000, 16)
 package eeprom
 
 import (
+	"context"
 	"fmt"
 	"time"
 
+	"github.com/mklimuk/sensors"
 	"gobot.io/x/gobot/v2/drivers/spi"
 )
 
@@ -49,6 +57,8 @@ const (
 // EEPROM25AA1024 implements gobot.Driver for the 25AA1024 device.
 type EEPROM25AA1024 struct {
 	*spi.Driver
+	// bus is used instead of the Gobot driver when set
+	bus sensors.SPIBus
 }
 
 // New returns a new driver bound to a Gobot SPI adaptor. bus and cs are the SPI bus
@@ -67,11 +77,27 @@ func New(adaptor spi.Connector, bus string, cs byte, opts ...func(spi.Config)) *
 	return &EEPROM25AA1024{Driver: d}
 }
 
+// NewWithBus returns a driver transferring over bus, which must select the
+// device and use SPI mode 0 or 3 at up to 20 MHz.
+func NewWithBus(bus sensors.SPIBus) *EEPROM25AA1024 {
+	return &EEPROM25AA1024{bus: bus}
+}
+
 // Start establishes the SPI bus. Required by Gobot.Driver interface.
-func (e *EEPROM25AA1024) Start() error { return e.Driver.Start() }
+func (e *EEPROM25AA1024) Start() error {
+	if e.Driver == nil {
+		return nil
+	}
+	return e.Driver.Start()
+}
 
 // Halt releases the bus. Optional.
-func (e *EEPROM25AA1024) Halt() error { return e.Driver.Halt() }
+func (e *EEPROM25AA1024) Halt() error {
+	if e.Driver == nil {
+		return nil
+	}
+	return e.Driver.Halt()
+}
 
 // Transfer performs a full‑duplex SPI transaction.
 //
@@ -82,10 +108,20 @@ func (e *EEPROM25AA1024) Halt() error { return e.Driver.Halt() }
 //   - If tx is nil and rx is non‑nil, zeros are clocked out while reading (if the
 //     underlying connection supports it).
 //
-// This simply delegates to the underlying Gobot SPI driver connection.
+// This simply delegates to the SPI bus or the underlying Gobot SPI driver
+// connection.
 func (e *EEPROM25AA1024) Transfer(tx []byte, rx []byte) error {
+	if e != nil && e.bus != nil {
+		if len(tx) == 0 {
+			return nil
+		}
+		if len(rx) == 0 {
+			rx = nil
+		}
+		return e.bus.Transfer(context.Background(), tx, rx)
+	}
 	if e == nil || e.Driver == nil {
-		return fmt.Errorf("spi driver not initialized")
+		return fmt.Errorf("spi driver not initialized: %w", sensors.ErrNotReady)
 	}
 	// Access the underlying SPI connection via Gobot driver.
 	conn := e.Driver.Connection()
@@ -96,7 +132,7 @@ func (e *EEPROM25AA1024) Transfer(tx []byte, rx []byte) error {
 	}
 	ops, ok := conn.(spiOps)
 	if !ok {
-		return fmt.Errorf("spi connection does not support required operations")
+		return fmt.Errorf("spi connection does not support required operations: %w", sensors.ErrUnsupported)
 	}
 
 	// Write-only transaction
@@ -109,7 +145,7 @@ func (e *EEPROM25AA1024) Transfer(tx []byte, rx []byte) error {
 
 	// Read transaction with command header and dummy bytes.
 	if len(tx) != len(rx) {
-		return fmt.Errorf("tx/rx length mismatch: %d != %d", len(tx), len(rx))
+		return fmt.Errorf("tx/rx length mismatch: %d != %d: %w", len(tx), len(rx), sensors.ErrInvalidArgument)
 	}
 
 	// Heuristics based on 25AA1024 protocol to split header and data lengths
@@ -144,7 +180,7 @@ func (e *EEPROM25AA1024) Transfer(tx []byte, rx []byte) error {
 // capacity return an error.
 func (e *EEPROM25AA1024) Read(address uint32, length int) ([]byte, error) {
 	if address+uint32(length) > capacity {
-		return nil, fmt.Errorf("read out of range")
+		return nil, fmt.Errorf("read of %d bytes at %#x out of range: %w", length, address, sensors.ErrInvalidArgument)
 	}
 	// Build command + 24‑bit address (only A16..A0 used, seven MSB are “don’t care”).
 	header := []byte{cmdRead, byte(address >> 16), byte(address >> 8), byte(address)}
@@ -164,7 +200,7 @@ func (e *EEPROM25AA1024) Read(address uint32, length int) ([]byte, error) {
 // until each internal write cycle completes.
 func (e *EEPROM25AA1024) Write(address uint32, data []byte) error {
 	if address+uint32(len(data)) > capacity {
-		return fmt.Errorf("write out of range")
+		return fmt.Errorf("write of %d bytes at %#x out of range: %w", len(data), address, sensors.ErrInvalidArgument)
 	}
 
 	offset := 0
@@ -210,12 +246,12 @@ func (e *EEPROM25AA1024) waitUntilReady(timeout time.Duration) error {
 		}
 		time.Sleep(500 * time.Microsecond)
 	}
-	return fmt.Errorf("timeout waiting for write completion")
+	return fmt.Errorf("waiting for write completion: %w", sensors.ErrTimeout)
 }
 
 func (e *EEPROM25AA1024) pageWrite(address uint32, data []byte) error {
 	if len(data) == 0 || len(data) > pageSize {
-		return fmt.Errorf("invalid page size")
+		return fmt.Errorf("invalid page size %d: %w", len(data), sensors.ErrInvalidArgument)
 	}
 	if err := e.writeEnable(); err != nil {
 		return err
@@ -0,0 +1,72 @@
+package eeprom
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mklimuk/sensors"
+)
+
+// chip emulates the 25AA1024 instructions used by the driver.
+type chip struct {
+	memory [capacity]byte
+	wel    bool
+	writes int
+}
+
+func (c *chip) Transfer(ctx context.Context, w, r []byte) error {
+	rx := make([]byte, len(w))
+	switch w[0] {
+	case cmdWREN:
+		c.wel = true
+	case cmdRDSR:
+		if c.wel {
+			rx[1] = 0x02
+		}
+	case cmdRead:
+		address := int(w[1])<<16 | int(w[2])<<8 | int(w[3])
+		for i := range len(w) - 4 {
+			rx[4+i] = c.memory[(address+i)%capacity]
+		}
+	case cmdWrite:
+		if !c.wel {
+			return nil
+		}
+		address := int(w[1])<<16 | int(w[2])<<8 | int(w[3])
+		page := address &^ (pageSize - 1)
+		for i, b := range w[4:] {
+			// writes wrap around within the page
+			c.memory[page+(address+i)%pageSize] = b
+		}
+		c.wel = false
+		c.writes++
+	}
+	if r != nil {
+		copy(r, rx)
+	}
+	return nil
+}
+
+func TestEEPROM_OverSPIBus(t *testing.T) {
+	c := &chip{}
+	e := NewWithBus(c)
+	require.NoError(t, e.Start())
+	defer e.Halt()
+
+	data := bytes.Repeat([]byte("25aa1024"), 64)
+	require.NoError(t, e.Write(0x00F0, data))
+	// 512 bytes starting 16 bytes before a page boundary span three pages
+	assert.Equal(t, 3, c.writes)
+
+	res, err := e.Read(0x00F0, len(data))
+	require.NoError(t, err)
+	assert.Equal(t, data, res)
+
+	_, err = e.Read(capacity-1, 2)
+	assert.ErrorIs(t, err, sensors.ErrInvalidArgument)
+	assert.ErrorIs(t, e.Write(capacity-1, data), sensors.ErrInvalidArgument)
+}
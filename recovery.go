@@ -0,0 +1,57 @@
+package sensors
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// ErrBusStuck is returned when the bus lines are still held low after a
+// recovery attempt.
+var ErrBusStuck = errors.New("i2c bus stuck")
+
+// ErrRecoveryUnsupported is returned by RecoverBus when the bus can only
+// cancel the pending transfer.
+var ErrRecoveryUnsupported = fmt.Errorf("bus recovery %w", ErrUnsupported)
+
+// BusLines holds the levels of the I2C lines, true meaning high.
+type BusLines struct {
+	SCL bool `json:"scl" yaml:"scl"`
+	SDA bool `json:"sda" yaml:"sda"`
+}
+
+// Idle tells whether both lines are released.
+func (l BusLines) Idle() bool {
+	return l.SCL && l.SDA
+}
+
+// BusRecovery describes what a bus recovery found and did.
+type BusRecovery struct {
+	Before       BusLines `json:"before" yaml:"before"`
+	After        BusLines `json:"after" yaml:"after"`
+	ClockPulses  int      `json:"clock_pulses" yaml:"clock_pulses"`
+	AdapterReset bool     `json:"adapter_reset" yaml:"adapter_reset"`
+}
+
+// BusRecoverer is implemented by buses able to free the bus from a slave
+// holding SDA low after an interrupted transfer. RecoverBus cancels the
+// pending transfer, clocks the slave out of its transfer when the adapter
+// allows it and returns ErrBusStuck unless the bus is idle afterwards.
+type BusRecoverer interface {
+	RecoverBus(ctx context.Context) (BusRecovery, error)
+}
+
+// RecoverBus runs the recovery procedure of bus. Buses which do not
+// implement BusRecoverer only get their pending transfer cancelled with
+// Release, and ErrRecoveryUnsupported is returned as the bus state is
+// unknown.
+func RecoverBus(ctx context.Context, bus I2CBus) (BusRecovery, error) {
+	if r, ok := bus.(BusRecoverer); ok {
+		return r.RecoverBus(ctx)
+	}
+	err := bus.Release(ctx)
+	if err != nil {
+		return BusRecovery{}, fmt.Errorf("could not release bus: %w", err)
+	}
+	return BusRecovery{}, fmt.Errorf("%w: pending transfer cancelled only", ErrRecoveryUnsupported)
+}
@@ -0,0 +1,30 @@
+package sensors
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type recoveringBus struct {
+	recordingBus
+}
+
+func (b *recoveringBus) RecoverBus(ctx context.Context) (BusRecovery, error) {
+	b.calls = append(b.calls, "recover")
+	return BusRecovery{After: BusLines{SCL: true, SDA: true}}, nil
+}
+
+func TestRecoverBus(t *testing.T) {
+	ctx := context.Background()
+
+	rb := &recoveringBus{}
+	res, err := RecoverBus(ctx, rb)
+	assert.NoError(t, err)
+	assert.True(t, res.After.Idle())
+	assert.Equal(t, []string{"recover"}, rb.calls)
+
+	_, err = RecoverBus(ctx, &recordingBus{})
+	assert.ErrorIs(t, err, ErrRecoveryUnsupported)
+}
@@ -0,0 +1,38 @@
+package remote
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/mklimuk/sensors"
+)
+
+func init() {
+	sensors.RegisterBus("tcp", openURL)
+}
+
+// openURL opens tcp://host:7777?token=X&timeout=2s.
+func openURL(ctx context.Context, u *url.URL) (sensors.I2CBus, error) {
+	err := sensors.CheckBusQuery(u, "token", "timeout")
+	if err != nil {
+		return nil, err
+	}
+	if u.Host == "" {
+		return nil, fmt.Errorf("missing server address")
+	}
+	q := u.Query()
+	var opts []Option
+	if token := q.Get("token"); token != "" {
+		opts = append(opts, WithToken(token))
+	}
+	if timeout := q.Get("timeout"); timeout != "" {
+		d, err := time.ParseDuration(timeout)
+		if err != nil {
+			return nil, fmt.Errorf("invalid timeout %q: %w", timeout, err)
+		}
+		opts = append(opts, WithTimeout(d))
+	}
+	return NewClient(u.Host, opts...), nil
+}
@@ -0,0 +1,241 @@
+package remote
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/mklimuk/sensors"
+)
+
+var _ sensors.I2CBus = &Client{}
+var _ sensors.I2CTransactor = &Client{}
+var _ sensors.AddressLocker = &Client{}
+
+// lockPollInterval is the delay between two attempts to take an address
+// lock held by another client.
+const lockPollInterval = 10 * time.Millisecond
+
+// Client is a sensors.I2CBus forwarding the transfers to a Server. It
+// connects on first use and reconnects after a connection error. Address
+// locks are lost on reconnection.
+type Client struct {
+	mx      sync.Mutex
+	address string
+	opts    options
+	conn    net.Conn
+	// addrLocks serialize the goroutines of this client before the lock is
+	// requested from the server
+	addrLocksMx sync.Mutex
+	addrLocks   map[byte]*sync.Mutex
+}
+
+// NewClient returns a client of the server listening on the TCP address.
+func NewClient(address string, opts ...Option) *Client {
+	return &Client{
+		address:   address,
+		opts:      newOptions(opts),
+		addrLocks: make(map[byte]*sync.Mutex),
+	}
+}
+
+// Close closes the connection to the server.
+func (c *Client) Close() error {
+	c.mx.Lock()
+	defer c.mx.Unlock()
+	if c.conn == nil {
+		return nil
+	}
+	err := c.conn.Close()
+	c.conn = nil
+	return err
+}
+
+func (c *Client) WriteToAddr(ctx context.Context, address byte, buffer []byte) error {
+	if len(buffer) > maxWriteSize {
+		return fmt.Errorf("write of %d bytes exceeds %d", len(buffer), maxWriteSize)
+	}
+	_, err := c.roundTrip(ctx, request{op: opWrite, address: address, data: buffer})
+	return err
+}
+
+func (c *Client) ReadFromAddr(ctx context.Context, address byte, buffer []byte) error {
+	if len(buffer) > maxReadSize {
+		return fmt.Errorf("read of %d bytes exceeds %d", len(buffer), maxReadSize)
+	}
+	data, err := c.roundTrip(ctx, request{op: opRead, address: address, readLen: len(buffer)})
+	if err != nil {
+		return err
+	}
+	copy(buffer, data)
+	return nil
+}
+
+// Tx writes w and reads r in one request, using a repeated start when the
+// bus of the server supports it.
+func (c *Client) Tx(ctx context.Context, address byte, w, r []byte) error {
+	if len(w) > maxWriteSize || len(r) > maxReadSize {
+		return fmt.Errorf("transfer of %d/%d bytes exceeds %d/%d", len(w), len(r), maxWriteSize, maxReadSize)
+	}
+	data, err := c.roundTrip(ctx, request{op: opTx, address: address, readLen: len(r), data: w})
+	if err != nil {
+		return err
+	}
+	copy(r, data)
+	return nil
+}
+
+func (c *Client) Release(ctx context.Context) error {
+	_, err := c.roundTrip(ctx, request{op: opRelease})
+	return err
+}
+
+func (c *Client) addrMutex(addr byte) *sync.Mutex {
+	c.addrLocksMx.Lock()
+	defer c.addrLocksMx.Unlock()
+	mu, ok := c.addrLocks[addr]
+	if !ok {
+		mu = &sync.Mutex{}
+		c.addrLocks[addr] = mu
+	}
+	return mu
+}
+
+// LockAddr acquires an exclusive per-address lock shared with the other
+// clients of the server. When the server cannot be reached the lock only
+// excludes the goroutines of this client.
+func (c *Client) LockAddr(addr byte) {
+	c.addrMutex(addr).Lock()
+	for {
+		_, err := c.roundTrip(context.Background(), request{op: opLock, address: addr})
+		if err == nil {
+			return
+		}
+		if !errors.Is(err, errLocked) {
+			slog.Warn("could not lock remote address", "addr", addr, "err", err)
+			return
+		}
+		time.Sleep(lockPollInterval)
+	}
+}
+
+// UnlockAddr releases the per-address lock acquired by LockAddr.
+func (c *Client) UnlockAddr(addr byte) {
+	_, err := c.roundTrip(context.Background(), request{op: opUnlock, address: addr})
+	if err != nil {
+		slog.Warn("could not unlock remote address", "addr", addr, "err", err)
+	}
+	c.addrMutex(addr).Unlock()
+}
+
+var errLocked = errors.New("address locked by another client")
+
+func (c *Client) roundTrip(ctx context.Context, req request) ([]byte, error) {
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		deadline = time.Now().Add(c.opts.timeout)
+	}
+	req.timeout = time.Until(deadline)
+	if req.timeout <= 0 {
+		return nil, context.DeadlineExceeded
+	}
+	c.mx.Lock()
+	defer c.mx.Unlock()
+	err := c.connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	conn := c.conn
+	// unblock the exchange when the context is canceled
+	stop := context.AfterFunc(ctx, func() {
+		_ = conn.SetDeadline(time.Now())
+	})
+	defer stop()
+	_ = conn.SetDeadline(deadline)
+	res, err := c.exchange(req.encode())
+	if err != nil {
+		c.invalidate()
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		if errors.Is(err, os.ErrDeadlineExceeded) {
+			return nil, fmt.Errorf("remote bus request: %w: %w", sensors.ErrTimeout, context.DeadlineExceeded)
+		}
+		return nil, fmt.Errorf("remote bus request: %w: %w", sensors.ErrDisconnected, err)
+	}
+	return decodeResponse(req.address, res)
+}
+
+func (c *Client) connect(ctx context.Context) error {
+	if c.conn != nil {
+		return nil
+	}
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "tcp", c.address)
+	if err != nil {
+		return fmt.Errorf("could not connect to %s: %w: %w", c.address, sensors.ErrDisconnected, err)
+	}
+	_ = conn.SetDeadline(time.Now().Add(c.opts.timeout))
+	c.conn = conn
+	res, err := c.exchange(request{op: opHello, data: []byte(c.opts.token)}.encode())
+	if err == nil {
+		_, err = decodeResponse(0, res)
+	}
+	if err != nil {
+		c.invalidate()
+		return fmt.Errorf("could not connect to %s: %w", c.address, err)
+	}
+	return nil
+}
+
+// exchange sends a request and returns its response.
+func (c *Client) exchange(payload []byte) ([]byte, error) {
+	err := writeFrame(c.conn, payload)
+	if err != nil {
+		return nil, err
+	}
+	return readFrame(c.conn)
+}
+
+// decodeResponse returns the data of a response to a request addressed to
+// address, turning the error statuses into errors.
+func decodeResponse(address byte, res []byte) ([]byte, error) {
+	if len(res) == 0 {
+		return nil, fmt.Errorf("%w: empty response", ErrRemote)
+	}
+	data := res[1:]
+	switch res[0] {
+	case statusOK:
+		return data, nil
+	case statusNACK:
+		return nil, &sensors.NACKError{Address: address, Err: fmt.Errorf("%w: %s", ErrRemote, data)}
+	case statusBusy:
+		return nil, fmt.Errorf("%w: %s", sensors.ErrBusBusy, data)
+	case statusTimeout:
+		return nil, fmt.Errorf("%w: %w: %s", sensors.ErrTimeout, context.DeadlineExceeded, data)
+	case statusUnsupported:
+		return nil, fmt.Errorf("%w: %s", sensors.ErrUnsupported, data)
+	case statusNotReady:
+		return nil, fmt.Errorf("%w: %s", sensors.ErrNotReady, data)
+	case statusDisconnected:
+		return nil, fmt.Errorf("%w: %s", sensors.ErrDisconnected, data)
+	case statusLocked:
+		return nil, errLocked
+	case statusUnauthorized:
+		return nil, ErrUnauthorized
+	}
+	return nil, fmt.Errorf("%w: %s", ErrRemote, data)
+}
+
+func (c *Client) invalidate() {
+	if c.conn == nil {
+		return
+	}
+	_ = c.conn.Close()
+	c.conn = nil
+}
@@ -0,0 +1,135 @@
+// Package remote exposes a sensors.I2CBus over TCP so that drivers can run on
+// a different machine than the one the bus adapter is attached to.
+//
+// The protocol is made of length-prefixed frames exchanged synchronously: the
+// client sends a request and waits for its response before sending the next
+// one. Each frame starts with its payload length as a big-endian uint32.
+//
+// Request payload:
+//
+//	op (1) | address (1) | timeout in ms (4) | read length (2) | write data
+//
+// Response payload:
+//
+//	status (1) | read data or error message
+//
+// The first request of a connection must be a hello carrying the shared
+// token, which is empty when the server does not require one.
+//
+// Example usage:
+//
+//	// on the machine with the adapter
+//	srv := remote.NewServer(adapter.NewMCP2221(), remote.WithToken("secret"))
+//	err := srv.ListenAndServe(ctx, ":7777")
+//
+//	// on the developer machine
+//	bus := remote.NewClient("lab:7777", remote.WithToken("secret"))
+//	defer bus.Close()
+//	t, h, err := environment.NewSHTC3(bus).GetTempAndHum(ctx)
+package remote
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+)
+
+const (
+	opHello   byte = 0x01
+	opWrite   byte = 0x02
+	opRead    byte = 0x03
+	opTx      byte = 0x04
+	opRelease byte = 0x05
+	opLock    byte = 0x06
+	opUnlock  byte = 0x07
+)
+
+const (
+	statusOK           byte = 0x00
+	statusError        byte = 0x01
+	statusNACK         byte = 0x02
+	statusBusy         byte = 0x03
+	statusTimeout      byte = 0x04
+	statusLocked       byte = 0x05
+	statusUnauthorized byte = 0x06
+	statusBadRequest   byte = 0x07
+	statusUnsupported  byte = 0x08
+	statusNotReady     byte = 0x09
+	statusDisconnected byte = 0x0A
+)
+
+const (
+	requestHeaderSize = 8
+	// maxFrameSize bounds the frames accepted by both ends
+	maxFrameSize = 1 << 16
+	maxReadSize  = maxFrameSize - 1
+	maxWriteSize = maxFrameSize - requestHeaderSize
+	// DefaultTimeout bounds the requests that do not carry a deadline.
+	DefaultTimeout = 5 * time.Second
+)
+
+var ErrUnauthorized = errors.New("remote bus: invalid token")
+var ErrRemote = errors.New("remote bus error")
+var errFrameTooLarge = errors.New("remote bus: frame too large")
+
+type request struct {
+	op      byte
+	address byte
+	timeout time.Duration
+	readLen int
+	data    []byte
+}
+
+func (r request) encode() []byte {
+	buf := make([]byte, requestHeaderSize+len(r.data))
+	buf[0] = r.op
+	buf[1] = r.address
+	binary.BigEndian.PutUint32(buf[2:], uint32(r.timeout/time.Millisecond))
+	binary.BigEndian.PutUint16(buf[6:], uint16(r.readLen))
+	copy(buf[requestHeaderSize:], r.data)
+	return buf
+}
+
+func decodeRequest(buf []byte) (request, error) {
+	if len(buf) < requestHeaderSize {
+		return request{}, fmt.Errorf("short request of %d bytes", len(buf))
+	}
+	return request{
+		op:      buf[0],
+		address: buf[1],
+		timeout: time.Duration(binary.BigEndian.Uint32(buf[2:])) * time.Millisecond,
+		readLen: int(binary.BigEndian.Uint16(buf[6:])),
+		data:    buf[requestHeaderSize:],
+	}, nil
+}
+
+func writeFrame(w io.Writer, payload []byte) error {
+	if len(payload) > maxFrameSize {
+		return errFrameTooLarge
+	}
+	buf := make([]byte, 4+len(payload))
+	binary.BigEndian.PutUint32(buf, uint32(len(payload)))
+	copy(buf[4:], payload)
+	_, err := w.Write(buf)
+	return err
+}
+
+func readFrame(r io.Reader) ([]byte, error) {
+	var header [4]byte
+	_, err := io.ReadFull(r, header[:])
+	if err != nil {
+		return nil, err
+	}
+	size := binary.BigEndian.Uint32(header[:])
+	if size > maxFrameSize {
+		return nil, errFrameTooLarge
+	}
+	buf := make([]byte, size)
+	_, err = io.ReadFull(r, buf)
+	if err != nil {
+		return nil, err
+	}
+	return buf, nil
+}
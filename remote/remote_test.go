@@ -0,0 +1,209 @@
+package remote
+
+import (
+	"context"
+	"errors"
+	"net"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/mklimuk/sensors"
+	"github.com/mklimuk/sensors/sim"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testAddress = 0x42
+
+// registers is a device with 8-bit registers addressed by the first byte of
+// each write.
+type registers struct {
+	values  [256]byte
+	pointer byte
+	// delay slows the writes down, in nanoseconds
+	delay atomic.Int64
+}
+
+func (r *registers) Write(w []byte) error {
+	time.Sleep(time.Duration(r.delay.Load()))
+	if len(w) == 0 {
+		return nil
+	}
+	r.pointer = w[0]
+	for _, b := range w[1:] {
+		r.values[r.pointer] = b
+		r.pointer++
+	}
+	return nil
+}
+
+func (r *registers) Read(buf []byte) error {
+	for i := range buf {
+		buf[i] = r.values[r.pointer]
+		r.pointer++
+	}
+	return nil
+}
+
+// serve starts a server of bus on a localhost port and returns its address.
+func serve(t *testing.T, bus *sim.Bus, opts ...Option) string {
+	t.Helper()
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		done <- NewServer(bus, opts...).Serve(ctx, l)
+	}()
+	t.Cleanup(func() {
+		cancel()
+		require.NoError(t, <-done)
+	})
+	return l.Addr().String()
+}
+
+func TestRemote_Transfers(t *testing.T) {
+	ctx := context.Background()
+	bus := sim.NewBus()
+	dev := &registers{}
+	bus.Attach(testAddress, dev)
+	c := NewClient(serve(t, bus))
+	defer c.Close()
+
+	require.NoError(t, c.WriteToAddr(ctx, testAddress, []byte{0x10, 0xCA, 0xFE}))
+	assert.Equal(t, byte(0xCA), dev.values[0x10])
+	r := make([]byte, 2)
+	require.NoError(t, c.Tx(ctx, testAddress, []byte{0x10}, r))
+	assert.Equal(t, []byte{0xCA, 0xFE}, r)
+	require.NoError(t, c.WriteToAddr(ctx, testAddress, []byte{0x11}))
+	require.NoError(t, c.ReadFromAddr(ctx, testAddress, r[:1]))
+	assert.Equal(t, byte(0xFE), r[0])
+	require.NoError(t, c.Release(ctx))
+
+	err := c.WriteToAddr(ctx, 0x10, []byte{0x00})
+	assert.ErrorIs(t, err, sensors.ErrNACK)
+	var nack *sensors.NACKError
+	require.ErrorAs(t, err, &nack)
+	assert.Equal(t, byte(0x10), nack.Address)
+	// the connection survives the transfer errors
+	require.NoError(t, c.ReadFromAddr(ctx, testAddress, r))
+}
+
+func TestRemote_Token(t *testing.T) {
+	ctx := context.Background()
+	bus := sim.NewBus()
+	bus.Attach(testAddress, &registers{})
+	address := serve(t, bus, WithToken("secret"))
+
+	c := NewClient(address, WithToken("guess"))
+	defer c.Close()
+	assert.ErrorIs(t, c.WriteToAddr(ctx, testAddress, []byte{0x00}), ErrUnauthorized)
+	c = NewClient(address)
+	defer c.Close()
+	assert.ErrorIs(t, c.WriteToAddr(ctx, testAddress, []byte{0x00}), ErrUnauthorized)
+	c = NewClient(address, WithToken("secret"))
+	defer c.Close()
+	assert.NoError(t, c.WriteToAddr(ctx, testAddress, []byte{0x00}))
+
+	opened, err := sensors.OpenBus(ctx, "tcp://"+address+"?token=secret&timeout=1s")
+	require.NoError(t, err)
+	defer opened.(*Client).Close()
+	assert.NoError(t, opened.WriteToAddr(ctx, testAddress, []byte{0x00}))
+}
+
+func TestRemote_Timeout(t *testing.T) {
+	bus := sim.NewBus()
+	dev := &registers{}
+	dev.delay.Store(int64(200 * time.Millisecond))
+	bus.Attach(testAddress, dev)
+	c := NewClient(serve(t, bus))
+	defer c.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, c.WriteToAddr(ctx, testAddress, []byte{0x00}), context.DeadlineExceeded)
+
+	// the client reconnects after the timeout
+	dev.delay.Store(0)
+	assert.NoError(t, c.WriteToAddr(context.Background(), testAddress, []byte{0x00}))
+}
+
+func TestRemote_Locks(t *testing.T) {
+	bus := sim.NewBus()
+	address := serve(t, bus)
+	a := NewClient(address)
+	defer a.Close()
+	b := NewClient(address)
+	defer b.Close()
+
+	a.LockAddr(testAddress)
+	// other addresses are not affected
+	b.LockAddr(testAddress + 1)
+	b.UnlockAddr(testAddress + 1)
+
+	locked := make(chan struct{})
+	go func() {
+		b.LockAddr(testAddress)
+		close(locked)
+	}()
+	select {
+	case <-locked:
+		t.Fatal("address locked by two clients")
+	case <-time.After(50 * time.Millisecond):
+	}
+	a.UnlockAddr(testAddress)
+	select {
+	case <-locked:
+	case <-time.After(time.Second):
+		t.Fatal("address not unlocked")
+	}
+
+	// the locks of a disconnected client are released
+	require.NoError(t, b.Close())
+	locked = make(chan struct{})
+	go func() {
+		a.LockAddr(testAddress)
+		close(locked)
+	}()
+	select {
+	case <-locked:
+	case <-time.After(time.Second):
+		t.Fatal("lock of a disconnected client not released")
+	}
+	a.UnlockAddr(testAddress)
+}
+
+// failingListener accepts a single connection and then fails.
+type failingListener struct {
+	net.Listener
+	accepted bool
+}
+
+func (l *failingListener) Accept() (net.Conn, error) {
+	if l.accepted {
+		return nil, errors.New("too many open files")
+	}
+	l.accepted = true
+	return l.Listener.Accept()
+}
+
+func TestRemote_AcceptError(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer l.Close()
+	done := make(chan error)
+	go func() {
+		done <- NewServer(sim.NewBus()).Serve(context.Background(), &failingListener{Listener: l})
+	}()
+	// the client stays connected while accepting fails
+	conn, err := net.Dial("tcp", l.Addr().String())
+	require.NoError(t, err)
+	defer conn.Close()
+	select {
+	case err := <-done:
+		assert.ErrorContains(t, err, "too many open files")
+	case <-time.After(time.Second):
+		t.Fatal("server waiting for the connected clients")
+	}
+}
@@ -0,0 +1,256 @@
+package remote
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/mklimuk/sensors"
+)
+
+type options struct {
+	token   string
+	timeout time.Duration
+}
+
+type Option func(*options)
+
+// WithToken sets the shared token. A server with a token rejects the clients
+// presenting a different one.
+func WithToken(token string) Option {
+	return func(o *options) {
+		o.token = token
+	}
+}
+
+// WithTimeout bounds every request. Clients use it for the requests without
+// a context deadline, servers as an upper bound of the client deadlines.
+func WithTimeout(timeout time.Duration) Option {
+	return func(o *options) {
+		o.timeout = timeout
+	}
+}
+
+func newOptions(opts []Option) options {
+	o := options{timeout: DefaultTimeout}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+// Server serves a bus to remote clients. Address locks taken by a client are
+// held on its behalf until it unlocks them or disconnects.
+type Server struct {
+	bus  sensors.I2CBus
+	opts options
+	// busMx serializes the transfers of all clients
+	busMx   sync.Mutex
+	locksMx sync.Mutex
+	locks   map[byte]*session
+}
+
+// session is the state of one client connection.
+type session struct {
+	conn net.Conn
+}
+
+func NewServer(bus sensors.I2CBus, opts ...Option) *Server {
+	return &Server{
+		bus:   bus,
+		opts:  newOptions(opts),
+		locks: make(map[byte]*session),
+	}
+}
+
+// ListenAndServe listens on the TCP address and serves clients until ctx is
+// done.
+func (s *Server) ListenAndServe(ctx context.Context, address string) error {
+	var lc net.ListenConfig
+	l, err := lc.Listen(ctx, "tcp", address)
+	if err != nil {
+		return fmt.Errorf("could not listen on %s: %w", address, err)
+	}
+	return s.Serve(ctx, l)
+}
+
+// Serve accepts clients on l until ctx is done, then closes l and the client
+// connections and waits for them to terminate.
+func (s *Server) Serve(ctx context.Context, l net.Listener) error {
+	ctx, cancel := context.WithCancel(ctx)
+	var wg sync.WaitGroup
+	// the cancel closes the client connections, so that the wait does not
+	// depend on the clients hanging up when accepting fails
+	defer func() {
+		cancel()
+		wg.Wait()
+	}()
+	stop := context.AfterFunc(ctx, func() {
+		_ = l.Close()
+	})
+	defer stop()
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return fmt.Errorf("accept error: %w", err)
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			s.serveConn(ctx, conn)
+		}()
+	}
+}
+
+func (s *Server) serveConn(ctx context.Context, conn net.Conn) {
+	sess := &session{conn: conn}
+	stop := context.AfterFunc(ctx, func() {
+		_ = conn.Close()
+	})
+	defer func() {
+		stop()
+		s.unlockAll(sess)
+		_ = conn.Close()
+		slog.Debug("remote bus client disconnected", "client", conn.RemoteAddr())
+	}()
+	if !s.authenticate(conn) {
+		slog.Warn("remote bus client rejected", "client", conn.RemoteAddr())
+		return
+	}
+	slog.Debug("remote bus client connected", "client", conn.RemoteAddr())
+	for {
+		frame, err := readFrame(conn)
+		if err != nil {
+			return
+		}
+		req, err := decodeRequest(frame)
+		var res []byte
+		if err != nil {
+			res = append([]byte{statusBadRequest}, err.Error()...)
+		} else {
+			res = s.handle(ctx, sess, req)
+		}
+		err = writeFrame(conn, res)
+		if err != nil {
+			return
+		}
+	}
+}
+
+func (s *Server) authenticate(conn net.Conn) bool {
+	_ = conn.SetReadDeadline(time.Now().Add(s.opts.timeout))
+	frame, err := readFrame(conn)
+	if err != nil {
+		return false
+	}
+	_ = conn.SetReadDeadline(time.Time{})
+	req, err := decodeRequest(frame)
+	if err != nil || req.op != opHello {
+		_ = writeFrame(conn, append([]byte{statusBadRequest}, "expected hello"...))
+		return false
+	}
+	if subtle.ConstantTimeCompare(req.data, []byte(s.opts.token)) != 1 {
+		_ = writeFrame(conn, []byte{statusUnauthorized})
+		return false
+	}
+	return writeFrame(conn, []byte{statusOK}) == nil
+}
+
+func (s *Server) handle(ctx context.Context, sess *session, req request) []byte {
+	timeout := s.opts.timeout
+	if req.timeout > 0 && req.timeout < timeout {
+		timeout = req.timeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	switch req.op {
+	case opLock:
+		if !s.tryLock(sess, req.address) {
+			return []byte{statusLocked}
+		}
+		return []byte{statusOK}
+	case opUnlock:
+		s.unlock(sess, req.address)
+		return []byte{statusOK}
+	}
+	r := make([]byte, req.readLen)
+	err := s.transfer(ctx, req, r)
+	if err != nil {
+		return append([]byte{errorStatus(ctx, err)}, err.Error()...)
+	}
+	return append([]byte{statusOK}, r...)
+}
+
+func (s *Server) transfer(ctx context.Context, req request, r []byte) error {
+	s.busMx.Lock()
+	defer s.busMx.Unlock()
+	switch req.op {
+	case opWrite:
+		return s.bus.WriteToAddr(ctx, req.address, req.data)
+	case opRead:
+		return s.bus.ReadFromAddr(ctx, req.address, r)
+	case opTx:
+		return sensors.WriteRead(ctx, s.bus, req.address, req.data, r)
+	case opRelease:
+		return s.bus.Release(ctx)
+	}
+	return fmt.Errorf("unknown operation %#x", req.op)
+}
+
+func errorStatus(ctx context.Context, err error) byte {
+	switch {
+	case errors.Is(err, sensors.ErrNACK):
+		return statusNACK
+	case errors.Is(err, sensors.ErrBusBusy):
+		return statusBusy
+	case errors.Is(err, sensors.ErrTimeout), errors.Is(err, context.DeadlineExceeded), ctx.Err() != nil:
+		return statusTimeout
+	case errors.Is(err, sensors.ErrUnsupported):
+		return statusUnsupported
+	case errors.Is(err, sensors.ErrNotReady):
+		return statusNotReady
+	case errors.Is(err, sensors.ErrDisconnected):
+		return statusDisconnected
+	}
+	return statusError
+}
+
+// tryLock takes the lock of address for sess unless another client holds
+// it. Clients wait for a lock by retrying, so that a connection is never
+// blocked by another one.
+func (s *Server) tryLock(sess *session, address byte) bool {
+	s.locksMx.Lock()
+	defer s.locksMx.Unlock()
+	owner, ok := s.locks[address]
+	if ok && owner != sess {
+		return false
+	}
+	s.locks[address] = sess
+	return true
+}
+
+func (s *Server) unlock(sess *session, address byte) {
+	s.locksMx.Lock()
+	defer s.locksMx.Unlock()
+	if s.locks[address] == sess {
+		delete(s.locks, address)
+	}
+}
+
+func (s *Server) unlockAll(sess *session) {
+	s.locksMx.Lock()
+	defer s.locksMx.Unlock()
+	for address, owner := range s.locks {
+		if owner == sess {
+			delete(s.locks, address)
+		}
+	}
+}
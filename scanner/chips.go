@@ -0,0 +1,106 @@
+package scanner
+
+import (
+	"context"
+
+	"github.com/mklimuk/sensors"
+	"github.com/mklimuk/sensors/accel"
+	"github.com/mklimuk/sensors/air"
+	"github.com/mklimuk/sensors/environment"
+	"github.com/mklimuk/sensors/gpio"
+)
+
+// Chip describes a device known to the scanner. Identify, when set, must only
+// perform reads (or state-preserving commands) and reports whether the device
+// at address is this chip.
+type Chip struct {
+	Name      string
+	Addresses []byte
+	Identify  func(ctx context.Context, bus sensors.I2CBus, address byte) (bool, error)
+}
+
+// KnownChips lists every chip supported by the drivers in this module.
+var KnownChips = []Chip{
+	{
+		Name:      "SHTC3",
+		Addresses: []byte{0x70},
+		Identify:  identifySHTC3,
+	},
+	{
+		Name:      "TC74",
+		Addresses: []byte{0x48, 0x49, 0x4A, 0x4B, 0x4C, 0x4D, 0x4E, 0x4F},
+		Identify:  identifyTC74,
+	},
+	{
+		Name:      "AGS02MA",
+		Addresses: []byte{0x1A},
+		Identify:  identifyAGS02MA,
+	},
+	{
+		Name:      "BMA220",
+		Addresses: []byte{0x0A},
+		Identify:  identifyBMA220,
+	},
+	{
+		Name:      "MCP23017",
+		Addresses: []byte{0x20, 0x21, 0x22, 0x23, 0x24, 0x25, 0x26, 0x27},
+		Identify:  identifyMCP23017,
+	},
+	{
+		Name:      "HIH6021",
+		Addresses: []byte{0x27},
+	},
+	{
+		Name:      "BH1750",
+		Addresses: []byte{environment.BH1750AddrLow, environment.BH1750AddrHigh},
+	},
+}
+
+func identifySHTC3(ctx context.Context, bus sensors.I2CBus, address byte) (bool, error) {
+	id, err := environment.NewSHTC3(bus).ReadID(ctx)
+	if err != nil {
+		return false, err
+	}
+	return id&environment.SHTC3IDMask == environment.SHTC3IDValue, nil
+}
+
+// identifyTC74 checks that the unused bits 5:0 of the config register read 0.
+func identifyTC74(ctx context.Context, bus sensors.I2CBus, address byte) (bool, error) {
+	config, err := environment.NewTC74(bus, environment.WithAddress(address)).GetConfig(ctx)
+	if err != nil {
+		return false, err
+	}
+	return config&0x3F == 0, nil
+}
+
+// identifyAGS02MA relies on the CRC protecting the version register content.
+func identifyAGS02MA(ctx context.Context, bus sensors.I2CBus, address byte) (bool, error) {
+	_, err := air.NewAGS02MA(bus, air.WithAddress(address)).ReadVersion(ctx)
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func identifyBMA220(ctx context.Context, bus sensors.I2CBus, address byte) (bool, error) {
+	id, err := accel.NewBMA220(bus).ReadChipID(ctx)
+	if err != nil {
+		return false, err
+	}
+	return id == accel.ChipID, nil
+}
+
+// identifyMCP23017 reads IOCON through both port addresses: the register is
+// shared so both reads must match, and its bit 0 is unimplemented.
+func identifyMCP23017(ctx context.Context, bus sensors.I2CBus, address byte) (bool, error) {
+	exp := gpio.NewMCP23017(bus, address)
+	a, err := exp.ReadSettingsA(ctx)
+	if err != nil {
+		return false, err
+	}
+	b, err := exp.ReadSettingsB(ctx)
+	if err != nil {
+		return false, err
+	}
+	return a == b && a&0x01 == 0, nil
+}
@@ -0,0 +1,157 @@
+// Package scanner discovers devices attached to an I2C bus. It probes every
+// address in the usable 7-bit range for an acknowledge and then runs safe,
+// read-only identification probes for the chips known to this module.
+//
+// Typical usage:
+//
+//	results, err := scanner.Scan(ctx, bus)
+//	for _, r := range results {
+//		fmt.Printf("%#02x %s\n", r.Address, r.Identified)
+//	}
+package scanner
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+
+	"github.com/mklimuk/sensors"
+)
+
+// Usable 7-bit address range; addresses outside it are reserved by the I2C
+// specification.
+const (
+	FirstAddress = 0x08
+	LastAddress  = 0x77
+)
+
+// ProbeMode selects how the presence of a device is detected.
+type ProbeMode int
+
+const (
+	// ProbeWrite issues a zero-length write (SMBus quick write). Buses
+	// which do not support zero-length writes, such as the CP2112, are
+	// probed with a read instead.
+	ProbeWrite ProbeMode = iota
+	// ProbeRead reads a single byte from the device.
+	ProbeRead
+)
+
+func (m ProbeMode) String() string {
+	switch m {
+	case ProbeRead:
+		return "read"
+	default:
+		return "write"
+	}
+}
+
+// Result describes a single address that acknowledged the probe.
+type Result struct {
+	Address byte `json:"address" yaml:"address"`
+	// Identified is the name of the chip confirmed by an identification
+	// probe; empty when no probe matched.
+	Identified string `json:"identified,omitempty" yaml:"identified,omitempty"`
+	// Candidates lists every known chip that may respond at this address.
+	Candidates []string `json:"candidates,omitempty" yaml:"candidates,omitempty"`
+}
+
+type Options struct {
+	First byte
+	Last  byte
+	Mode  ProbeMode
+	Chips []Chip
+}
+
+type Option func(*Options)
+
+// WithRange limits the scan to addresses between first and last inclusive.
+func WithRange(first, last byte) Option {
+	return func(o *Options) {
+		o.First = first
+		o.Last = last
+	}
+}
+
+// WithProbeMode selects the presence detection method.
+func WithProbeMode(mode ProbeMode) Option {
+	return func(o *Options) {
+		o.Mode = mode
+	}
+}
+
+// WithChips replaces the list of chips used for identification.
+func WithChips(chips ...Chip) Option {
+	return func(o *Options) {
+		o.Chips = chips
+	}
+}
+
+// Scan probes the bus and returns one result per acknowledging address in
+// ascending order. Errors returned by individual probes are treated as "no
+// device" or "not identified"; only context cancellation aborts the scan.
+func Scan(ctx context.Context, bus sensors.I2CBus, opts ...Option) ([]Result, error) {
+	options := Options{
+		First: FirstAddress,
+		Last:  LastAddress,
+		Mode:  ProbeWrite,
+		Chips: KnownChips,
+	}
+	for _, opt := range opts {
+		opt(&options)
+	}
+	var results []Result
+	for addr := int(options.First); addr <= int(options.Last); addr++ {
+		if err := ctx.Err(); err != nil {
+			return results, err
+		}
+		if !Ping(ctx, bus, byte(addr), options.Mode) {
+			continue
+		}
+		results = append(results, identify(ctx, bus, byte(addr), options.Chips))
+	}
+	return results, nil
+}
+
+// Ping reports whether a device acknowledges the given address.
+func Ping(ctx context.Context, bus sensors.I2CBus, address byte, mode ProbeMode) bool {
+	var err error
+	switch mode {
+	case ProbeRead:
+		err = bus.ReadFromAddr(ctx, address, make([]byte, 1))
+	default:
+		err = bus.WriteToAddr(ctx, address, []byte{})
+		if errors.Is(err, sensors.ErrUnsupported) {
+			err = bus.ReadFromAddr(ctx, address, make([]byte, 1))
+		}
+	}
+	if err != nil {
+		slog.Debug("no response", "address", fmt.Sprintf("%#02x", address), "err", err)
+		return false
+	}
+	return true
+}
+
+func identify(ctx context.Context, bus sensors.I2CBus, address byte, chips []Chip) Result {
+	res := Result{Address: address}
+	for _, chip := range chips {
+		if !slices.Contains(chip.Addresses, address) {
+			continue
+		}
+		res.Candidates = append(res.Candidates, chip.Name)
+		if res.Identified != "" || chip.Identify == nil {
+			continue
+		}
+		ok, err := chip.Identify(ctx, bus, address)
+		if err != nil {
+			slog.Debug("identification probe failed", "chip", chip.Name, "address", fmt.Sprintf("%#02x", address), "err", err)
+			continue
+		}
+		if ok {
+			res.Identified = chip.Name
+		}
+	}
+	return res
+}
@@ -0,0 +1,88 @@
+package scanner
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mklimuk/sensors"
+	"github.com/mklimuk/sensors/sim"
+)
+
+func TestScan_IdentifiesSimulatedChips(t *testing.T) {
+	bus := sim.NewBus()
+	bus.Attach(sim.SHTC3Address, sim.NewSHTC3())
+	bus.Attach(sim.TC74Address, sim.NewTC74())
+	bus.Attach(sim.BMA220Address, sim.NewBMA220())
+	bus.Attach(0x21, sim.NewMCP23017())
+	bus.Attach(sim.BH1750AddrLow, sim.NewBH1750())
+
+	results, err := Scan(context.Background(), bus)
+	require.NoError(t, err)
+	require.Len(t, results, 5)
+
+	byAddr := make(map[byte]Result)
+	for _, r := range results {
+		byAddr[r.Address] = r
+	}
+	assert.Equal(t, "BMA220", byAddr[sim.BMA220Address].Identified)
+	assert.Equal(t, "MCP23017", byAddr[0x21].Identified)
+	assert.Equal(t, "TC74", byAddr[sim.TC74Address].Identified)
+	assert.Equal(t, "SHTC3", byAddr[sim.SHTC3Address].Identified)
+	assert.Empty(t, byAddr[sim.BH1750AddrLow].Identified)
+	assert.Equal(t, []string{"MCP23017", "BH1750"}, byAddr[sim.BH1750AddrLow].Candidates)
+}
+
+func TestScan_Range(t *testing.T) {
+	bus := sim.NewBus()
+	bus.Attach(0x20, sim.NewMCP23017())
+	bus.Attach(0x27, sim.NewHIH6021())
+
+	results, err := Scan(context.Background(), bus, WithRange(0x20, 0x26))
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	assert.Equal(t, byte(0x20), results[0].Address)
+}
+
+func TestScan_UnidentifiedSharedAddress(t *testing.T) {
+	bus := sim.NewBus()
+	bus.Attach(0x27, sim.NewHIH6021())
+
+	results, err := Scan(context.Background(), bus)
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	assert.Empty(t, results[0].Identified)
+	assert.Equal(t, []string{"MCP23017", "HIH6021"}, results[0].Candidates)
+}
+
+// noQuickWrite rejects zero-length writes like the CP2112 does.
+type noQuickWrite struct {
+	*sim.Bus
+}
+
+func (b noQuickWrite) WriteToAddr(ctx context.Context, address byte, buffer []byte) error {
+	if len(buffer) == 0 {
+		return fmt.Errorf("zero-length writes %w", sensors.ErrUnsupported)
+	}
+	return b.Bus.WriteToAddr(ctx, address, buffer)
+}
+
+func TestScan_ReadWithoutQuickWrite(t *testing.T) {
+	bus := sim.NewBus()
+	bus.Attach(sim.TC74Address, sim.NewTC74())
+
+	results, err := Scan(context.Background(), noQuickWrite{bus})
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	assert.Equal(t, "TC74", results[0].Identified)
+}
+
+func TestScan_Cancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err := Scan(ctx, sim.NewBus())
+	assert.ErrorIs(t, err, context.Canceled)
+}
@@ -0,0 +1,120 @@
+package sim
+
+import (
+	"encoding/binary"
+	"fmt"
+	"sync"
+)
+
+// AGS02MAAddress is the default 7-bit address of the Aosong AGS02MA.
+const AGS02MAAddress = 0x1A
+
+// AGS02MA registers
+const (
+	ags02maRegTVOC       = 0x00
+	ags02maRegCalibrate  = 0x01
+	ags02maRegVersion    = 0x11
+	ags02maRegResistance = 0x20
+	ags02maStatusRDY     = 0x01
+)
+
+// AGS02MA models an Aosong AGS02MA TVOC sensor. A write selects the register
+// read next and every read returns four data bytes followed by the CRC. While
+// preheating the RDY status bit of the TVOC register is set.
+type AGS02MA struct {
+	mx         sync.Mutex
+	tvoc       uint32
+	resistance uint32
+	version    byte
+	preheating bool
+	calibrated int
+	configured int
+	register   byte
+}
+
+func NewAGS02MA() *AGS02MA {
+	return &AGS02MA{tvoc: 0, resistance: 1000, version: 118}
+}
+
+// SetTVOC sets the TVOC concentration in ppb.
+func (s *AGS02MA) SetTVOC(ppb uint32) {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+	s.tvoc = ppb & 0xFFFFFF
+}
+
+// SetResistance sets the raw sensing resistance value (in units of 100 Ohm).
+func (s *AGS02MA) SetResistance(value uint32) {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+	s.resistance = value
+}
+
+// SetVersion sets the firmware version reported by the version register.
+func (s *AGS02MA) SetVersion(version byte) {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+	s.version = version
+}
+
+// SetPreheating toggles the pre-heat stage during which the RDY bit is set.
+func (s *AGS02MA) SetPreheating(preheating bool) {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+	s.preheating = preheating
+}
+
+// Calibrations returns the number of zero-point calibration requests received.
+func (s *AGS02MA) Calibrations() int {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+	return s.calibrated
+}
+
+// Configurations returns the number of mode configuration writes received.
+func (s *AGS02MA) Configurations() int {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+	return s.configured
+}
+
+func (s *AGS02MA) Write(w []byte) error {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+	if len(w) == 0 {
+		return nil
+	}
+	switch w[0] {
+	case ags02maRegTVOC:
+		if len(w) > 1 {
+			s.configured++
+		}
+	case ags02maRegCalibrate:
+		s.calibrated++
+	case ags02maRegVersion, ags02maRegResistance:
+	default:
+		return fmt.Errorf("ags02ma: invalid register %#02x", w[0])
+	}
+	s.register = w[0]
+	return nil
+}
+
+func (s *AGS02MA) Read(r []byte) error {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+	var data [5]byte
+	switch s.register {
+	case ags02maRegTVOC, ags02maRegCalibrate:
+		binary.BigEndian.PutUint32(data[0:4], s.tvoc)
+		if s.preheating {
+			data[0] |= ags02maStatusRDY
+		}
+	case ags02maRegVersion:
+		data[3] = s.version
+	case ags02maRegResistance:
+		binary.BigEndian.PutUint32(data[0:4], s.resistance)
+	}
+	data[4] = sensirionCRC8(data[:4])
+	copy(r, data[:])
+	return nil
+}
@@ -0,0 +1,63 @@
+package sim_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mklimuk/sensors/air"
+	"github.com/mklimuk/sensors/sim"
+)
+
+func newTestAGS02MA(bus *sim.Bus, opts ...air.AGS02MAOpt) *air.AGS02MA {
+	opts = append([]air.AGS02MAOpt{
+		air.WithConfigureDelay(time.Millisecond),
+		air.WithReadDelay(time.Millisecond),
+		air.WithTxDelay(time.Millisecond),
+	}, opts...)
+	return air.NewAGS02MA(bus, opts...)
+}
+
+func TestAGS02MA_EndToEnd(t *testing.T) {
+	bus := sim.NewBus()
+	chip := sim.NewAGS02MA()
+	bus.Attach(sim.AGS02MAAddress, chip)
+	chip.SetTVOC(1234)
+	chip.SetVersion(118)
+	chip.SetResistance(56)
+
+	sensor := newTestAGS02MA(bus)
+	ctx := context.Background()
+	require.NoError(t, sensor.Configure(ctx))
+	assert.Equal(t, 1, chip.Configurations())
+
+	ppb, err := sensor.GetTVOC(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, uint32(1234), ppb)
+
+	ver, err := sensor.ReadVersion(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 118, ver)
+
+	res, err := sensor.ReadResistance(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 56, res)
+
+	require.NoError(t, sensor.Calibrate(ctx))
+	assert.Equal(t, 1, chip.Calibrations())
+	sensor.Close(ctx)
+}
+
+func TestAGS02MA_Preheat(t *testing.T) {
+	bus := sim.NewBus()
+	chip := sim.NewAGS02MA()
+	bus.Attach(sim.AGS02MAAddress, chip)
+	chip.SetPreheating(true)
+
+	sensor := newTestAGS02MA(bus, air.WithTVOCMode(air.TVOCModeDirectRead))
+	_, err := sensor.GetTVOC(context.Background())
+	assert.ErrorIs(t, err, air.ErrNotReady)
+}
@@ -0,0 +1,101 @@
+package sim
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// BH1750 addresses selected by the ADDR pin
+const (
+	BH1750AddrLow  = 0x23
+	BH1750AddrHigh = 0x5C
+)
+
+// BH1750 instructions
+const (
+	bh1750PowerDown        = 0x00
+	bh1750PowerOn          = 0x01
+	bh1750Reset            = 0x07
+	bh1750ContinuousHRes   = 0x10
+	bh1750ContinuousHRes2  = 0x11
+	bh1750ContinuousLRes   = 0x13
+	bh1750OneTimeHRes      = 0x20
+	bh1750OneTimeHRes2     = 0x21
+	bh1750OneTimeLRes      = 0x23
+	bh1750MeasurementRatio = 1.2
+)
+
+var ErrPoweredDown = errors.New("device is powered down")
+
+// BH1750 models a ROHM BH1750 ambient light sensor. One-time measurement
+// modes return to power down once the result has been read.
+type BH1750 struct {
+	mx       sync.Mutex
+	lux      float32
+	powered  bool
+	oneTime  bool
+	measured bool
+}
+
+func NewBH1750() *BH1750 {
+	return &BH1750{lux: 100}
+}
+
+// SetLux sets the illuminance reported by the sensor.
+func (s *BH1750) SetLux(lux float32) {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+	s.lux = lux
+}
+
+func (s *BH1750) Write(w []byte) error {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+	if len(w) == 0 {
+		return nil
+	}
+	if len(w) != 1 {
+		return fmt.Errorf("bh1750: invalid instruction length %d", len(w))
+	}
+	switch w[0] {
+	case bh1750PowerDown:
+		s.powered = false
+		s.measured = false
+	case bh1750PowerOn:
+		s.powered = true
+	case bh1750Reset:
+		if !s.powered {
+			return ErrPoweredDown
+		}
+		s.measured = false
+	case bh1750ContinuousHRes, bh1750ContinuousHRes2, bh1750ContinuousLRes:
+		s.powered = true
+		s.oneTime = false
+		s.measured = true
+	case bh1750OneTimeHRes, bh1750OneTimeHRes2, bh1750OneTimeLRes:
+		s.powered = true
+		s.oneTime = true
+		s.measured = true
+	default:
+		return fmt.Errorf("bh1750: unknown instruction %#02x", w[0])
+	}
+	return nil
+}
+
+func (s *BH1750) Read(r []byte) error {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+	if !s.measured {
+		return ErrNoData
+	}
+	var out [2]byte
+	binary.BigEndian.PutUint16(out[:], scaleRaw(s.lux*bh1750MeasurementRatio/65535, 65535))
+	copy(r, out[:])
+	// one-time modes drop to power down but keep the data register
+	if s.oneTime {
+		s.powered = false
+	}
+	return nil
+}
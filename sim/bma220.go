@@ -0,0 +1,119 @@
+package sim
+
+import (
+	"fmt"
+	"sync"
+)
+
+// BMA220Address is the default 7-bit address of the Bosch BMA220.
+const BMA220Address = 0x0A
+
+// BMA220 registers and bits used by the model
+const (
+	bma220RegChipID     = 0x00
+	bma220RegRevision   = 0x02
+	bma220RegAccX       = 0x04
+	bma220RegAccY       = 0x06
+	bma220RegAccZ       = 0x08
+	bma220RegSlopeSet   = 0x12
+	bma220RegIntStatus  = 0x18
+	bma220RegSlopeDet   = 0x1A
+	bma220RegLatch      = 0x1C
+	bma220RegSoftReset  = 0x32
+	bma220RegCount      = 0x40
+	bma220SlopeEnable   = 0b00111000
+	bma220SlopeInt      = 0x01
+	bma220ResetInt      = 0x80
+	bma220ChipIDValue   = 0xDD
+	bma220RevisionValue = 0x00
+)
+
+// BMA220 models a Bosch BMA220 accelerometer register file. A write sets the
+// register pointer and stores any following bytes at consecutive addresses;
+// reads return registers from the pointer with auto-increment.
+type BMA220 struct {
+	mx        sync.Mutex
+	registers [bma220RegCount]byte
+	pointer   byte
+}
+
+func NewBMA220() *BMA220 {
+	s := &BMA220{}
+	s.reset()
+	return s
+}
+
+func (s *BMA220) reset() {
+	s.registers = [bma220RegCount]byte{}
+	s.registers[bma220RegChipID] = bma220ChipIDValue
+	s.registers[bma220RegRevision] = bma220RevisionValue
+	s.registers[bma220RegSlopeSet] = 0x45
+	s.pointer = 0
+}
+
+// SetAcceleration sets the 6-bit two's complement acceleration samples for
+// each axis (-32..31 LSB).
+func (s *BMA220) SetAcceleration(x, y, z int8) {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+	s.registers[bma220RegAccX] = byte(x) << 2
+	s.registers[bma220RegAccY] = byte(y) << 2
+	s.registers[bma220RegAccZ] = byte(z) << 2
+}
+
+// TriggerMotion simulates a slope above the configured threshold. The slope
+// interrupt is latched only if slope detection is enabled on any axis.
+func (s *BMA220) TriggerMotion() {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+	if s.registers[bma220RegSlopeDet]&bma220SlopeEnable != 0 {
+		s.registers[bma220RegIntStatus] |= bma220SlopeInt
+	}
+}
+
+// Register returns the raw content of a register.
+func (s *BMA220) Register(reg byte) byte {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+	return s.registers[reg%bma220RegCount]
+}
+
+func (s *BMA220) Write(w []byte) error {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+	if len(w) == 0 {
+		return nil
+	}
+	if w[0] >= bma220RegCount {
+		return fmt.Errorf("bma220: invalid register %#02x", w[0])
+	}
+	s.pointer = w[0]
+	for i, value := range w[1:] {
+		reg := (s.pointer + byte(i)) % bma220RegCount
+		switch reg {
+		case bma220RegChipID, bma220RegRevision, bma220RegAccX, bma220RegAccY, bma220RegAccZ, bma220RegIntStatus:
+			// read-only
+		case bma220RegLatch:
+			if value&bma220ResetInt != 0 {
+				s.registers[bma220RegIntStatus] = 0
+			}
+			s.registers[reg] = value &^ bma220ResetInt
+		case bma220RegSoftReset:
+			s.reset()
+			return nil
+		default:
+			s.registers[reg] = value
+		}
+	}
+	return nil
+}
+
+func (s *BMA220) Read(r []byte) error {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+	for i := range r {
+		r[i] = s.registers[s.pointer]
+		s.pointer = (s.pointer + 1) % bma220RegCount
+	}
+	return nil
+}
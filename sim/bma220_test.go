@@ -0,0 +1,45 @@
+package sim_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mklimuk/sensors/accel"
+	"github.com/mklimuk/sensors/sim"
+)
+
+func TestBMA220_MotionInterrupt(t *testing.T) {
+	bus := sim.NewBus()
+	chip := sim.NewBMA220()
+	bus.Attach(sim.BMA220Address, chip)
+	sensor := accel.NewBMA220(bus)
+	ctx := context.Background()
+
+	// slope detection disabled: motion is ignored
+	chip.TriggerMotion()
+	motion, err := sensor.CheckMotionInterrupt(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 0, motion)
+
+	require.NoError(t, sensor.InitMotionDetection(ctx))
+	chip.TriggerMotion()
+	motion, err = sensor.CheckMotionInterrupt(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, motion)
+
+	require.NoError(t, sensor.ResetMotionInterrupt(ctx))
+	motion, err = sensor.CheckMotionInterrupt(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 0, motion)
+}
+
+func TestBMA220_ChipID(t *testing.T) {
+	bus := sim.NewBus()
+	bus.Attach(sim.BMA220Address, sim.NewBMA220())
+	buf := make([]byte, 1)
+	require.NoError(t, bus.Tx(context.Background(), sim.BMA220Address, []byte{0x00}, buf))
+	assert.Equal(t, byte(0xDD), buf[0])
+}
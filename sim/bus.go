@@ -0,0 +1,177 @@
+// Package sim provides an in-memory I2C bus onto which virtual chip models can
+// be attached, so the drivers in this module can be exercised end to end
+// without hardware.
+//
+// Typical usage:
+//
+//	bus := sim.NewBus()
+//	chip := sim.NewSHTC3()
+//	bus.Attach(sim.SHTC3Address, chip)
+//	chip.SetTemperature(21.5)
+//	t, h, err := environment.NewSHTC3(bus).GetTempAndHum(ctx)
+package sim
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/mklimuk/sensors"
+)
+
+var _ sensors.I2CBus = &Bus{}
+var _ sensors.I2CTransactor = &Bus{}
+var _ sensors.AddressLocker = &Bus{}
+
+// ErrNACK is matched by the *sensors.NACKError returned when no device is
+// attached at the requested address or the device refused the transfer.
+//
+// Deprecated: Use sensors.ErrNACK.
+var ErrNACK = sensors.ErrNACK
+
+// Device is a virtual I2C chip. Write receives the bytes of a write transfer
+// addressed to the device and Read fills the buffer of a read transfer.
+// Returning an error makes the bus report a NACK for the transfer.
+type Device interface {
+	Write(w []byte) error
+	Read(r []byte) error
+}
+
+// Bus is an in-memory sensors.I2CBus. It is safe for concurrent use; every
+// transfer is executed atomically with respect to the other ones.
+type Bus struct {
+	mx          sync.Mutex
+	devices     map[byte]Device
+	addrLocksMx sync.Mutex
+	addrLocks   map[byte]*sync.Mutex
+}
+
+func NewBus() *Bus {
+	return &Bus{
+		devices:   make(map[byte]Device),
+		addrLocks: make(map[byte]*sync.Mutex),
+	}
+}
+
+// Attach connects dev to the bus at the given 7-bit address replacing any
+// device previously attached there.
+func (b *Bus) Attach(address byte, dev Device) {
+	b.mx.Lock()
+	defer b.mx.Unlock()
+	b.devices[address] = dev
+}
+
+// Detach disconnects the device attached at address, if any.
+func (b *Bus) Detach(address byte) {
+	b.mx.Lock()
+	defer b.mx.Unlock()
+	delete(b.devices, address)
+}
+
+// Device returns the device attached at address or nil.
+func (b *Bus) Device(address byte) Device {
+	b.mx.Lock()
+	defer b.mx.Unlock()
+	return b.devices[address]
+}
+
+func (b *Bus) WriteToAddr(ctx context.Context, address byte, buffer []byte) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	b.mx.Lock()
+	defer b.mx.Unlock()
+	return b.write(address, buffer)
+}
+
+func (b *Bus) ReadFromAddr(ctx context.Context, address byte, buffer []byte) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	b.mx.Lock()
+	defer b.mx.Unlock()
+	return b.read(address, buffer)
+}
+
+// Tx performs a write followed by a read without letting any other transfer
+// in between, mirroring a repeated start on a real bus.
+func (b *Bus) Tx(ctx context.Context, address byte, w, r []byte) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	b.mx.Lock()
+	defer b.mx.Unlock()
+	if len(w) > 0 || len(r) == 0 {
+		if err := b.write(address, w); err != nil {
+			return err
+		}
+	}
+	if len(r) == 0 {
+		return nil
+	}
+	return b.read(address, r)
+}
+
+func (b *Bus) Release(ctx context.Context) error {
+	return nil
+}
+
+func (b *Bus) write(address byte, buffer []byte) error {
+	dev, ok := b.devices[address]
+	if !ok {
+		return fmt.Errorf("sim: no device: %w", &sensors.NACKError{Address: address})
+	}
+	if err := dev.Write(buffer); err != nil {
+		return fmt.Errorf("sim: write: %w", &sensors.NACKError{Address: address, Err: err})
+	}
+	return nil
+}
+
+func (b *Bus) read(address byte, buffer []byte) error {
+	dev, ok := b.devices[address]
+	if !ok {
+		return fmt.Errorf("sim: no device: %w", &sensors.NACKError{Address: address})
+	}
+	if err := dev.Read(buffer); err != nil {
+		return fmt.Errorf("sim: read: %w", &sensors.NACKError{Address: address, Err: err})
+	}
+	return nil
+}
+
+func (b *Bus) addrMutex(addr byte) *sync.Mutex {
+	b.addrLocksMx.Lock()
+	defer b.addrLocksMx.Unlock()
+	mu, ok := b.addrLocks[addr]
+	if !ok {
+		mu = &sync.Mutex{}
+		b.addrLocks[addr] = mu
+	}
+	return mu
+}
+
+// LockAddr acquires an exclusive per-address lock.
+func (b *Bus) LockAddr(addr byte) {
+	b.addrMutex(addr).Lock()
+}
+
+// UnlockAddr releases the per-address lock acquired by LockAddr.
+func (b *Bus) UnlockAddr(addr byte) {
+	b.addrMutex(addr).Unlock()
+}
+
+// sensirionCRC8 computes the CRC-8 used by Sensirion and Aosong sensors
+// (polynomial 0x31, init 0xFF).
+func sensirionCRC8(data []byte) byte {
+	crc := byte(0xFF)
+	for _, b := range data {
+		crc ^= b
+		for range 8 {
+			if crc&0x80 != 0 {
+				crc = (crc << 1) ^ 0x31
+			} else {
+				crc <<= 1
+			}
+		}
+	}
+	return crc
+}
@@ -0,0 +1,58 @@
+package sim
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type echoDevice struct {
+	last []byte
+}
+
+func (d *echoDevice) Write(w []byte) error {
+	d.last = append([]byte{}, w...)
+	return nil
+}
+
+func (d *echoDevice) Read(r []byte) error {
+	copy(r, d.last)
+	return nil
+}
+
+func TestBus_NACKWithoutDevice(t *testing.T) {
+	bus := NewBus()
+	ctx := context.Background()
+	assert.ErrorIs(t, bus.WriteToAddr(ctx, 0x10, []byte{0x01}), ErrNACK)
+	assert.ErrorIs(t, bus.ReadFromAddr(ctx, 0x10, make([]byte, 1)), ErrNACK)
+}
+
+func TestBus_AttachDetach(t *testing.T) {
+	bus := NewBus()
+	ctx := context.Background()
+	dev := &echoDevice{}
+	bus.Attach(0x10, dev)
+	assert.Same(t, dev, bus.Device(0x10))
+
+	buf := make([]byte, 2)
+	assert.NoError(t, bus.Tx(ctx, 0x10, []byte{0xAB, 0xCD}, buf))
+	assert.Equal(t, []byte{0xAB, 0xCD}, buf)
+
+	bus.Detach(0x10)
+	assert.Nil(t, bus.Device(0x10))
+	assert.ErrorIs(t, bus.WriteToAddr(ctx, 0x10, nil), ErrNACK)
+}
+
+func TestBus_CancelledContext(t *testing.T) {
+	bus := NewBus()
+	bus.Attach(0x10, &echoDevice{})
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	assert.ErrorIs(t, bus.WriteToAddr(ctx, 0x10, nil), context.Canceled)
+}
+
+func TestSensirionCRC8(t *testing.T) {
+	// example from the SHTC3 datasheet
+	assert.Equal(t, byte(0x92), sensirionCRC8([]byte{0xBE, 0xEF}))
+}
@@ -0,0 +1,137 @@
+package sim_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mklimuk/sensors"
+	"github.com/mklimuk/sensors/environment"
+	"github.com/mklimuk/sensors/sim"
+)
+
+func TestSHTC3_EndToEnd(t *testing.T) {
+	bus := sim.NewBus()
+	chip := sim.NewSHTC3()
+	bus.Attach(sim.SHTC3Address, chip)
+	chip.SetTemperature(21.5)
+	chip.SetHumidity(43.2)
+
+	temp, hum, err := environment.NewSHTC3(bus).GetTempAndHum(context.Background())
+	require.NoError(t, err)
+	assert.InDelta(t, 21.5, temp, 0.01)
+	assert.InDelta(t, 43.2, hum, 0.01)
+	assert.True(t, chip.Asleep(), "driver should put the sensor back to sleep")
+}
+
+// corrupted flips the bits of the first byte read from the wrapped device.
+type corrupted struct {
+	sim.Device
+}
+
+func (c corrupted) Read(r []byte) error {
+	err := c.Device.Read(r)
+	if err == nil && len(r) > 0 {
+		r[0] ^= 0xFF
+	}
+	return err
+}
+
+func TestSHTC3_CRCMismatch(t *testing.T) {
+	bus := sim.NewBus()
+	bus.Attach(sim.SHTC3Address, corrupted{sim.NewSHTC3()})
+
+	_, _, err := environment.NewSHTC3(bus).GetTempAndHum(context.Background())
+	assert.ErrorIs(t, err, sensors.ErrCRC)
+	var crc *sensors.CRCError
+	assert.ErrorAs(t, err, &crc)
+}
+
+func TestSHTC3_ReadIDSleepsOnError(t *testing.T) {
+	bus := sim.NewBus()
+	chip := sim.NewSHTC3()
+	bus.Attach(sim.SHTC3Address, corrupted{chip})
+
+	_, err := environment.NewSHTC3(bus).ReadID(context.Background())
+	assert.ErrorIs(t, err, sensors.ErrCRC)
+	assert.True(t, chip.Asleep(), "driver should put the sensor back to sleep")
+}
+
+func TestSHTC3_SleepingChipNACKs(t *testing.T) {
+	bus := sim.NewBus()
+	bus.Attach(sim.SHTC3Address, sim.NewSHTC3())
+	err := bus.WriteToAddr(context.Background(), sim.SHTC3Address, []byte{0x78, 0x66})
+	assert.ErrorIs(t, err, sim.ErrNACK)
+}
+
+func TestHIH6021_EndToEnd(t *testing.T) {
+	bus := sim.NewBus()
+	chip := sim.NewHIH6021()
+	bus.Attach(sim.HIH6021Address, chip)
+	chip.SetTemperature(-12.5)
+	chip.SetHumidity(80)
+
+	temp, hum, err := environment.NewHIH6021(bus).GetTempAndHum(context.Background())
+	require.NoError(t, err)
+	assert.InDelta(t, -12.5, temp, 0.02)
+	assert.InDelta(t, 80, hum, 0.01)
+}
+
+func TestHIH6021_StaleBit(t *testing.T) {
+	bus := sim.NewBus()
+	bus.Attach(sim.HIH6021Address, sim.NewHIH6021())
+	ctx := context.Background()
+	buf := make([]byte, 4)
+
+	require.NoError(t, bus.WriteToAddr(ctx, sim.HIH6021Address, nil))
+	require.NoError(t, bus.ReadFromAddr(ctx, sim.HIH6021Address, buf))
+	assert.Zero(t, buf[0]&0xC0)
+	require.NoError(t, bus.ReadFromAddr(ctx, sim.HIH6021Address, buf))
+	assert.Equal(t, byte(0x40), buf[0]&0xC0)
+}
+
+func TestTC74_EndToEnd(t *testing.T) {
+	bus := sim.NewBus()
+	chip := sim.NewTC74()
+	bus.Attach(sim.TC74Address, chip)
+	sensor := environment.NewTC74(bus)
+	ctx := context.Background()
+
+	chip.SetTemperature(-7)
+	temp, err := sensor.GetTemperature(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, float32(-7), temp)
+
+	// without DATA_RDY the driver keeps the previous reading
+	chip.SetDataReady(false)
+	chip.SetTemperature(30)
+	temp, err = sensor.GetTemperature(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, float32(-7), temp)
+
+	_, err = sensor.GetHumidity(ctx)
+	assert.ErrorIs(t, err, sensors.ErrUnsupported)
+}
+
+func TestTC74_NotReady(t *testing.T) {
+	bus := sim.NewBus()
+	chip := sim.NewTC74()
+	chip.SetDataReady(false)
+	bus.Attach(sim.TC74Address, chip)
+
+	_, err := environment.NewTC74(bus).GetTemperature(context.Background())
+	assert.ErrorIs(t, err, sensors.ErrNotReady)
+}
+
+func TestBH1750_EndToEnd(t *testing.T) {
+	bus := sim.NewBus()
+	chip := sim.NewBH1750()
+	bus.Attach(sim.BH1750AddrLow, chip)
+	chip.SetLux(350)
+
+	lux, err := environment.NewBH1750(bus, environment.BH1750AddrLow).GetLux(context.Background())
+	require.NoError(t, err)
+	assert.InDelta(t, 350, lux, 1)
+}
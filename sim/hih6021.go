@@ -0,0 +1,81 @@
+package sim
+
+import (
+	"encoding/binary"
+	"sync"
+)
+
+// HIH6021Address is the default 7-bit address of the Honeywell HIH6021.
+const HIH6021Address = 0x27
+
+const hih6021Divider = float32(1<<14 - 2)
+
+// HIH6021 status bits reported in the two most significant bits of the first
+// data byte.
+const (
+	hih6021StatusStale   = 0x40
+	hih6021StatusCommand = 0x80
+)
+
+// HIH6021 models a Honeywell HumidIcon HIH6021 sensor. An empty write
+// (measurement request) latches fresh data; reading the same data a second
+// time sets the stale bit.
+type HIH6021 struct {
+	mx          sync.Mutex
+	temperature float32
+	humidity    float32
+	commandMode bool
+	fresh       bool
+	latched     [4]byte
+}
+
+func NewHIH6021() *HIH6021 {
+	return &HIH6021{temperature: 25, humidity: 50}
+}
+
+// SetTemperature sets the temperature in Celsius latched by the next measurement.
+func (s *HIH6021) SetTemperature(t float32) {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+	s.temperature = t
+}
+
+// SetHumidity sets the relative humidity in %RH latched by the next measurement.
+func (s *HIH6021) SetHumidity(h float32) {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+	s.humidity = h
+}
+
+// SetCommandMode makes the model report the command mode status bit.
+func (s *HIH6021) SetCommandMode(enabled bool) {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+	s.commandMode = enabled
+}
+
+func (s *HIH6021) Write(w []byte) error {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+	hum := scaleRaw(s.humidity/100, hih6021Divider)
+	temp := scaleRaw((s.temperature+40)/165, hih6021Divider)
+	binary.BigEndian.PutUint16(s.latched[0:2], hum&0x3FFF)
+	binary.BigEndian.PutUint16(s.latched[2:4], temp<<2)
+	s.fresh = true
+	return nil
+}
+
+func (s *HIH6021) Read(r []byte) error {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+	out := s.latched
+	switch {
+	case s.commandMode:
+		out[0] |= hih6021StatusCommand
+	case !s.fresh:
+		out[0] |= hih6021StatusStale
+	}
+	s.fresh = false
+	copy(r, out[:])
+	return nil
+}
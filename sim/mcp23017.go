@@ -0,0 +1,204 @@
+package sim
+
+import (
+	"fmt"
+	"sync"
+)
+
+// MCP23017AddressBase is the address of an MCP23017 with A2..A0 tied low.
+const MCP23017AddressBase = 0x20
+
+// MCP23017 registers in BANK=0 order; the register index of port B is the
+// index of port A plus one.
+const (
+	mcp23017IODIRA = iota * 2
+	mcp23017IPOLA
+	mcp23017GPINTENA
+	mcp23017DEFVALA
+	mcp23017INTCONA
+	mcp23017IOCONA
+	mcp23017GPPUA
+	mcp23017INTFA
+	mcp23017INTCAPA
+	mcp23017GPIOA
+	mcp23017OLATA
+	mcp23017RegCount
+)
+
+const (
+	mcp23017IOCONBank   = 0x80
+	mcp23017IOCONSeqOp  = 0x20
+	mcp23017IOCONUnused = 0x01
+)
+
+// MCP23017 models a Microchip MCP23017 16-bit I/O expander, including both
+// IOCON.BANK register layouts, sequential addressing and interrupt capture on
+// pin change.
+type MCP23017 struct {
+	mx        sync.Mutex
+	registers [mcp23017RegCount]byte
+	pins      [2]byte
+	pointer   byte
+}
+
+func NewMCP23017() *MCP23017 {
+	s := &MCP23017{}
+	s.registers[mcp23017IODIRA] = 0xFF
+	s.registers[mcp23017IODIRA+1] = 0xFF
+	return s
+}
+
+// SetInputs sets the external levels applied to the pins of port A and B and
+// latches interrupts for pins with interrupt-on-change enabled.
+func (s *MCP23017) SetInputs(a, b byte) {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+	for port, levels := range [2]byte{a, b} {
+		prev := s.pins[port]
+		s.pins[port] = levels
+		enabled := s.registers[mcp23017GPINTENA+port] & s.registers[mcp23017IODIRA+port]
+		compare := s.registers[mcp23017INTCONA+port]
+		defval := s.registers[mcp23017DEFVALA+port]
+		// INTCON bit set: compare against DEFVAL, otherwise against previous value
+		changed := (compare & (levels ^ defval)) | (^compare & (levels ^ prev))
+		if flags := changed & enabled; flags != 0 && s.registers[mcp23017INTFA+port] == 0 {
+			s.registers[mcp23017INTFA+port] = flags
+			s.registers[mcp23017INTCAPA+port] = levels
+		}
+	}
+}
+
+// Outputs returns the levels driven on port A and B pins configured as outputs.
+func (s *MCP23017) Outputs() (byte, byte) {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+	a := s.registers[mcp23017OLATA] &^ s.registers[mcp23017IODIRA]
+	b := s.registers[mcp23017OLATA+1] &^ s.registers[mcp23017IODIRA+1]
+	return a, b
+}
+
+// Interrupt reports whether any interrupt flag is pending on port A or B.
+func (s *MCP23017) Interrupt() (bool, bool) {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+	return s.registers[mcp23017INTFA] != 0, s.registers[mcp23017INTFA+1] != 0
+}
+
+// Bank returns the current IOCON.BANK addressing mode (0 or 1).
+func (s *MCP23017) Bank() int {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+	if s.registers[mcp23017IOCONA]&mcp23017IOCONBank != 0 {
+		return 1
+	}
+	return 0
+}
+
+func (s *MCP23017) Write(w []byte) error {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+	if len(w) == 0 {
+		return nil
+	}
+	if _, ok := s.register(w[0]); !ok {
+		return fmt.Errorf("mcp23017: invalid register address %#02x", w[0])
+	}
+	s.pointer = w[0]
+	for _, value := range w[1:] {
+		reg, ok := s.register(s.pointer)
+		if !ok {
+			return fmt.Errorf("mcp23017: invalid register address %#02x", s.pointer)
+		}
+		s.store(reg, value)
+		s.advance()
+	}
+	return nil
+}
+
+func (s *MCP23017) Read(r []byte) error {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+	for i := range r {
+		reg, ok := s.register(s.pointer)
+		if !ok {
+			return fmt.Errorf("mcp23017: invalid register address %#02x", s.pointer)
+		}
+		r[i] = s.load(reg)
+		s.advance()
+	}
+	return nil
+}
+
+// register maps a bus address to the BANK=0 register index for the active layout.
+func (s *MCP23017) register(address byte) (int, bool) {
+	if s.registers[mcp23017IOCONA]&mcp23017IOCONBank == 0 {
+		if int(address) >= mcp23017RegCount {
+			return 0, false
+		}
+		return int(address), true
+	}
+	port := int(address >> 4)
+	index := int(address & 0x0F)
+	if port > 1 || index >= mcp23017RegCount/2 {
+		return 0, false
+	}
+	return index*2 + port, true
+}
+
+// address maps a BANK=0 register index back to its bus address.
+func (s *MCP23017) address(reg int) byte {
+	if s.registers[mcp23017IOCONA]&mcp23017IOCONBank == 0 {
+		return byte(reg)
+	}
+	return byte((reg%2)<<4 | reg/2)
+}
+
+// advance moves the address pointer unless sequential operation is disabled.
+// In BANK=1 mode the pointer wraps within the port's register block.
+func (s *MCP23017) advance() {
+	if s.registers[mcp23017IOCONA]&mcp23017IOCONSeqOp != 0 {
+		return
+	}
+	reg, _ := s.register(s.pointer)
+	if s.registers[mcp23017IOCONA]&mcp23017IOCONBank == 0 {
+		s.pointer = s.address((reg + 1) % mcp23017RegCount)
+		return
+	}
+	next := reg + 2
+	if next >= mcp23017RegCount {
+		next = reg % 2
+	}
+	s.pointer = s.address(next)
+}
+
+func (s *MCP23017) store(reg int, value byte) {
+	port := reg % 2
+	switch reg - port {
+	case mcp23017IOCONA:
+		// IOCON is shared between both ports
+		value &^= mcp23017IOCONUnused
+		s.registers[mcp23017IOCONA] = value
+		s.registers[mcp23017IOCONA+1] = value
+	case mcp23017INTFA, mcp23017INTCAPA:
+		// read-only
+	case mcp23017GPIOA, mcp23017OLATA:
+		s.registers[mcp23017OLATA+port] = value
+	default:
+		s.registers[reg] = value
+	}
+}
+
+func (s *MCP23017) load(reg int) byte {
+	port := reg % 2
+	switch reg - port {
+	case mcp23017GPIOA:
+		dir := s.registers[mcp23017IODIRA+port]
+		inputs := (s.pins[port] ^ s.registers[mcp23017IPOLA+port]) & dir
+		outputs := s.registers[mcp23017OLATA+port] &^ dir
+		s.registers[mcp23017INTFA+port] = 0
+		return inputs | outputs
+	case mcp23017INTCAPA:
+		s.registers[mcp23017INTFA+port] = 0
+	}
+	return s.registers[reg]
+}
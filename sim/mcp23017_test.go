@@ -0,0 +1,92 @@
+package sim_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mklimuk/sensors/gpio"
+	"github.com/mklimuk/sensors/sim"
+)
+
+func TestMCP23017_ReadInputs(t *testing.T) {
+	bus := sim.NewBus()
+	chip := sim.NewMCP23017()
+	bus.Attach(gpio.DefaultMCP23017Address, chip)
+	exp := gpio.NewMCP23017(bus, gpio.DefaultMCP23017Address)
+	ctx := context.Background()
+
+	require.NoError(t, exp.InitA(ctx, 0xFF))
+	require.NoError(t, exp.PullUpA(ctx, 0xFF))
+	chip.SetInputs(0xA5, 0x3C)
+
+	vals, err := exp.Read(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []byte{0xA5, 0x3C}, vals)
+
+	iocon, err := exp.ReadSettingsA(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, byte(0x00), iocon)
+}
+
+func TestMCP23017_Outputs(t *testing.T) {
+	bus := sim.NewBus()
+	chip := sim.NewMCP23017()
+	bus.Attach(sim.MCP23017AddressBase, chip)
+	ctx := context.Background()
+
+	// IODIRA = 0x0F (upper nibble outputs), GPIOA = 0xF0
+	require.NoError(t, bus.WriteToAddr(ctx, sim.MCP23017AddressBase, []byte{0x00, 0x0F}))
+	require.NoError(t, bus.WriteToAddr(ctx, sim.MCP23017AddressBase, []byte{0x12, 0xF0}))
+	a, b := chip.Outputs()
+	assert.Equal(t, byte(0xF0), a)
+	assert.Equal(t, byte(0x00), b)
+}
+
+func TestMCP23017_BankLayouts(t *testing.T) {
+	bus := sim.NewBus()
+	chip := sim.NewMCP23017()
+	bus.Attach(sim.MCP23017AddressBase, chip)
+	ctx := context.Background()
+	chip.SetInputs(0x11, 0x22)
+	buf := make([]byte, 2)
+
+	// BANK=0: GPIOA and GPIOB are adjacent at 0x12/0x13
+	require.NoError(t, bus.Tx(ctx, sim.MCP23017AddressBase, []byte{gpio.BankAddr[0][gpio.GPIOA]}, buf))
+	assert.Equal(t, []byte{0x11, 0x22}, buf)
+
+	// switch to BANK=1 through IOCON (0x0A in BANK=0 layout)
+	require.NoError(t, bus.WriteToAddr(ctx, sim.MCP23017AddressBase, []byte{0x0A, 0x80}))
+	assert.Equal(t, 1, chip.Bank())
+
+	require.NoError(t, bus.Tx(ctx, sim.MCP23017AddressBase, []byte{gpio.BankAddr[1][gpio.GPIOA]}, buf[:1]))
+	assert.Equal(t, byte(0x11), buf[0])
+	require.NoError(t, bus.Tx(ctx, sim.MCP23017AddressBase, []byte{gpio.BankAddr[1][gpio.GPIOB]}, buf[:1]))
+	assert.Equal(t, byte(0x22), buf[0])
+
+	// IOCON reads the same from both ports in BANK=1 layout
+	require.NoError(t, bus.Tx(ctx, sim.MCP23017AddressBase, []byte{gpio.BankAddr[1][gpio.IOCONB]}, buf[:1]))
+	assert.Equal(t, byte(0x80), buf[0])
+}
+
+func TestMCP23017_InterruptOnChange(t *testing.T) {
+	bus := sim.NewBus()
+	chip := sim.NewMCP23017()
+	bus.Attach(sim.MCP23017AddressBase, chip)
+	ctx := context.Background()
+
+	// GPINTENA = 0x01
+	require.NoError(t, bus.WriteToAddr(ctx, sim.MCP23017AddressBase, []byte{0x04, 0x01}))
+	chip.SetInputs(0x01, 0x00)
+	a, _ := chip.Interrupt()
+	assert.True(t, a)
+
+	// reading INTCAPA clears the flag
+	buf := make([]byte, 1)
+	require.NoError(t, bus.Tx(ctx, sim.MCP23017AddressBase, []byte{0x10}, buf))
+	assert.Equal(t, byte(0x01), buf[0])
+	a, _ = chip.Interrupt()
+	assert.False(t, a)
+}
@@ -0,0 +1,166 @@
+package sim
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+
+	"github.com/mklimuk/sensors"
+	"gopkg.in/yaml.v3"
+)
+
+func init() {
+	sensors.RegisterBus("sim", openURL)
+}
+
+// Scenario describes a bus populated with chip models, e.g.:
+//
+//	devices:
+//	  - chip: shtc3
+//	    temperature: 21.5
+//	    humidity: 40
+//	  - chip: tc74
+//	    address: 0x48
+//	    temperature: 23
+type Scenario struct {
+	Devices []ScenarioDevice `yaml:"devices"`
+}
+
+// ScenarioDevice is a chip model attached at Address, or at the default
+// address of the chip when Address is 0. The measurements that do not apply
+// to the chip are ignored.
+type ScenarioDevice struct {
+	Chip        string   `yaml:"chip"`
+	Address     byte     `yaml:"address"`
+	Temperature *float32 `yaml:"temperature"`
+	Humidity    *float32 `yaml:"humidity"`
+	Lux         *float32 `yaml:"lux"`
+	TVOC        *uint32  `yaml:"tvoc"`
+	Resistance  *uint32  `yaml:"resistance"`
+	// Acceleration holds the x, y and z readings
+	Acceleration []int8 `yaml:"acceleration"`
+	// Inputs holds the levels of ports A and B
+	Inputs []byte `yaml:"inputs"`
+}
+
+// LoadScenario returns a bus populated with the devices of the YAML scenario
+// read from r.
+func LoadScenario(r io.Reader) (*Bus, error) {
+	var s Scenario
+	err := yaml.NewDecoder(r).Decode(&s)
+	if err != nil && err != io.EOF {
+		return nil, fmt.Errorf("could not decode scenario: %w", err)
+	}
+	return s.Bus()
+}
+
+// OpenScenario loads the YAML scenario stored at path.
+func OpenScenario(path string) (*Bus, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return LoadScenario(f)
+}
+
+// Bus returns a new bus populated with the devices of the scenario.
+func (s Scenario) Bus() (*Bus, error) {
+	bus := NewBus()
+	for i, d := range s.Devices {
+		dev, address, err := d.device()
+		if err != nil {
+			return nil, fmt.Errorf("device %d: %w", i, err)
+		}
+		if d.Address != 0 {
+			address = d.Address
+		}
+		if bus.Device(address) != nil {
+			return nil, fmt.Errorf("device %d: address %#x already used", i, address)
+		}
+		bus.Attach(address, dev)
+	}
+	return bus, nil
+}
+
+func (d ScenarioDevice) device() (Device, byte, error) {
+	switch d.Chip {
+	case "shtc3":
+		dev := NewSHTC3()
+		if d.Temperature != nil {
+			dev.SetTemperature(*d.Temperature)
+		}
+		if d.Humidity != nil {
+			dev.SetHumidity(*d.Humidity)
+		}
+		return dev, SHTC3Address, nil
+	case "hih6021":
+		dev := NewHIH6021()
+		if d.Temperature != nil {
+			dev.SetTemperature(*d.Temperature)
+		}
+		if d.Humidity != nil {
+			dev.SetHumidity(*d.Humidity)
+		}
+		return dev, HIH6021Address, nil
+	case "tc74":
+		dev := NewTC74()
+		if d.Temperature != nil {
+			dev.SetTemperature(int8(*d.Temperature))
+		}
+		return dev, TC74Address, nil
+	case "bh1750":
+		dev := NewBH1750()
+		if d.Lux != nil {
+			dev.SetLux(*d.Lux)
+		}
+		return dev, BH1750AddrLow, nil
+	case "ags02ma":
+		dev := NewAGS02MA()
+		if d.TVOC != nil {
+			dev.SetTVOC(*d.TVOC)
+		}
+		if d.Resistance != nil {
+			dev.SetResistance(*d.Resistance)
+		}
+		return dev, AGS02MAAddress, nil
+	case "bma220":
+		dev := NewBMA220()
+		if len(d.Acceleration) > 0 {
+			if len(d.Acceleration) != 3 {
+				return nil, 0, fmt.Errorf("expected 3 acceleration values, got %d", len(d.Acceleration))
+			}
+			dev.SetAcceleration(d.Acceleration[0], d.Acceleration[1], d.Acceleration[2])
+		}
+		return dev, BMA220Address, nil
+	case "mcp23017":
+		dev := NewMCP23017()
+		if len(d.Inputs) > 0 {
+			if len(d.Inputs) != 2 {
+				return nil, 0, fmt.Errorf("expected 2 input values, got %d", len(d.Inputs))
+			}
+			dev.SetInputs(d.Inputs[0], d.Inputs[1])
+		}
+		return dev, MCP23017AddressBase, nil
+	}
+	return nil, 0, fmt.Errorf("unknown chip %q", d.Chip)
+}
+
+// openURL opens sim://scenario.yaml, sim:///abs/scenario.yaml or an empty
+// bus for sim://.
+func openURL(ctx context.Context, u *url.URL) (sensors.I2CBus, error) {
+	err := sensors.CheckBusQuery(u)
+	if err != nil {
+		return nil, err
+	}
+	path := u.Opaque
+	if path == "" {
+		path = u.Host + u.Path
+	}
+	if path == "" {
+		return NewBus(), nil
+	}
+	return OpenScenario(path)
+}
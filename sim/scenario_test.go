@@ -0,0 +1,58 @@
+package sim_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mklimuk/sensors"
+	"github.com/mklimuk/sensors/environment"
+	"github.com/mklimuk/sensors/sim"
+)
+
+const scenario = `
+devices:
+  - chip: shtc3
+    temperature: 21.5
+    humidity: 40
+  - chip: tc74
+    address: 0x48
+    temperature: 23
+  - chip: mcp23017
+    inputs: [0xF0, 0x0F]
+`
+
+func TestScenario_OpenBus(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "scenario.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(scenario), 0o644))
+
+	bus, err := sensors.OpenBus(ctx, "sim://"+path)
+	require.NoError(t, err)
+	temp, hum, err := environment.NewSHTC3(bus).GetTempAndHum(ctx)
+	require.NoError(t, err)
+	assert.InDelta(t, 21.5, temp, 0.01)
+	assert.InDelta(t, 40, hum, 0.01)
+	celsius, err := environment.NewTC74(bus, environment.WithAddress(0x48)).GetTemperature(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 23, int(celsius))
+	assert.NotNil(t, bus.(*sim.Bus).Device(sim.MCP23017AddressBase))
+
+	bus, err = sensors.OpenBus(ctx, "sim://")
+	require.NoError(t, err)
+	assert.ErrorIs(t, bus.WriteToAddr(ctx, sim.SHTC3Address, []byte{0x00}), sim.ErrNACK)
+}
+
+func TestScenario_Errors(t *testing.T) {
+	_, err := sim.LoadScenario(strings.NewReader("devices:\n  - chip: unknown\n"))
+	assert.ErrorContains(t, err, "unknown chip")
+	_, err = sim.LoadScenario(strings.NewReader("devices:\n  - chip: tc74\n  - chip: tc74\n"))
+	assert.ErrorContains(t, err, "already used")
+	_, err = sim.LoadScenario(strings.NewReader("devices:\n  - chip: bma220\n    acceleration: [1, 2]\n"))
+	assert.Error(t, err)
+}
@@ -0,0 +1,142 @@
+package sim
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// SHTC3Address is the fixed 7-bit address of the Sensirion SHTC3.
+const SHTC3Address = 0x70
+
+// SHTC3 commands (big endian on the wire)
+const (
+	shtc3CmdWake           uint16 = 0x3517
+	shtc3CmdSleep          uint16 = 0xB098
+	shtc3CmdSoftReset      uint16 = 0x805D
+	shtc3CmdReadID         uint16 = 0xEFC8
+	shtc3CmdMeasureTFirst  uint16 = 0x7866
+	shtc3CmdMeasureRHFirst uint16 = 0x58E0
+	shtc3CmdMeasureTLP     uint16 = 0x609C
+	shtc3CmdMeasureRHLP    uint16 = 0x401A
+)
+
+// SHTC3ID is the content of the ID register reported by the model. Bits 11
+// and 5:0 identify the SHTC3.
+const SHTC3ID uint16 = 0x0807
+
+var ErrSleeping = errors.New("device is sleeping")
+var ErrNoData = errors.New("no data available")
+
+// SHTC3 models a Sensirion SHTC3 temperature and humidity sensor. The chip
+// starts asleep and only acknowledges the wake-up command until woken.
+type SHTC3 struct {
+	mx          sync.Mutex
+	temperature float32
+	humidity    float32
+	asleep      bool
+	out         []byte
+}
+
+func NewSHTC3() *SHTC3 {
+	return &SHTC3{temperature: 25, humidity: 50, asleep: true}
+}
+
+// SetTemperature sets the temperature in Celsius returned by the next measurement.
+func (s *SHTC3) SetTemperature(t float32) {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+	s.temperature = t
+}
+
+// SetHumidity sets the relative humidity in %RH returned by the next measurement.
+func (s *SHTC3) SetHumidity(h float32) {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+	s.humidity = h
+}
+
+// Asleep reports whether the chip is in sleep mode.
+func (s *SHTC3) Asleep() bool {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+	return s.asleep
+}
+
+func (s *SHTC3) Write(w []byte) error {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+	// the address is acknowledged even in sleep mode
+	if len(w) == 0 {
+		return nil
+	}
+	if len(w) != 2 {
+		return fmt.Errorf("shtc3: invalid command length %d", len(w))
+	}
+	cmd := binary.BigEndian.Uint16(w)
+	if s.asleep {
+		if cmd != shtc3CmdWake {
+			return ErrSleeping
+		}
+		s.asleep = false
+		return nil
+	}
+	s.out = nil
+	switch cmd {
+	case shtc3CmdWake:
+	case shtc3CmdSleep:
+		s.asleep = true
+	case shtc3CmdSoftReset:
+	case shtc3CmdReadID:
+		s.out = sensirionWord(nil, SHTC3ID)
+	case shtc3CmdMeasureTFirst, shtc3CmdMeasureTLP:
+		s.out = sensirionWord(sensirionWord(nil, s.rawTemperature()), s.rawHumidity())
+	case shtc3CmdMeasureRHFirst, shtc3CmdMeasureRHLP:
+		s.out = sensirionWord(sensirionWord(nil, s.rawHumidity()), s.rawTemperature())
+	default:
+		return fmt.Errorf("shtc3: unknown command %#04x", cmd)
+	}
+	return nil
+}
+
+func (s *SHTC3) Read(r []byte) error {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+	if s.asleep {
+		return ErrSleeping
+	}
+	if s.out == nil {
+		return ErrNoData
+	}
+	copy(r, s.out)
+	s.out = nil
+	return nil
+}
+
+func (s *SHTC3) rawTemperature() uint16 {
+	return scaleRaw((s.temperature+45)/175, 65535)
+}
+
+func (s *SHTC3) rawHumidity() uint16 {
+	return scaleRaw(s.humidity/100, 65535)
+}
+
+// sensirionWord appends a big endian word followed by its CRC to buf.
+func sensirionWord(buf []byte, word uint16) []byte {
+	var w [2]byte
+	binary.BigEndian.PutUint16(w[:], word)
+	return append(buf, w[0], w[1], sensirionCRC8(w[:]))
+}
+
+// scaleRaw converts a 0..1 ratio to a raw value in 0..full, clamping out of
+// range inputs.
+func scaleRaw(ratio float32, full float32) uint16 {
+	if ratio < 0 {
+		ratio = 0
+	}
+	if ratio > 1 {
+		ratio = 1
+	}
+	return uint16(ratio*full + 0.5)
+}
@@ -0,0 +1,91 @@
+package sim
+
+import (
+	"fmt"
+	"sync"
+)
+
+// TC74Address is the default 7-bit address of the Microchip TC74 (A5 variant).
+const TC74Address = 0x4D
+
+// TC74 registers and config bits
+const (
+	tc74RegTemp     = 0x00
+	tc74RegConfig   = 0x01
+	tc74ConfigSHDN  = 0x80
+	tc74ConfigReady = 0x40
+)
+
+// TC74 models a Microchip TC74 digital temperature sensor. A one byte write
+// selects the register pointer, a two byte write stores the value and reads
+// return the selected register.
+type TC74 struct {
+	mx          sync.Mutex
+	temperature int8
+	ready       bool
+	shutdown    bool
+	pointer     byte
+}
+
+func NewTC74() *TC74 {
+	return &TC74{temperature: 25, ready: true}
+}
+
+// SetTemperature sets the temperature in Celsius held by the TEMP register.
+func (s *TC74) SetTemperature(t int8) {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+	s.temperature = t
+}
+
+// SetDataReady sets the DATA_RDY bit of the CONFIG register.
+func (s *TC74) SetDataReady(ready bool) {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+	s.ready = ready
+}
+
+// Shutdown reports whether the SHDN bit has been set by the host.
+func (s *TC74) Shutdown() bool {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+	return s.shutdown
+}
+
+func (s *TC74) Write(w []byte) error {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+	if len(w) == 0 {
+		return nil
+	}
+	if w[0] > tc74RegConfig {
+		return fmt.Errorf("tc74: invalid register %#02x", w[0])
+	}
+	s.pointer = w[0]
+	if len(w) > 1 && s.pointer == tc74RegConfig {
+		// only SHDN is writable, DATA_RDY is read-only
+		s.shutdown = w[1]&tc74ConfigSHDN != 0
+	}
+	return nil
+}
+
+func (s *TC74) Read(r []byte) error {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+	var value byte
+	switch s.pointer {
+	case tc74RegTemp:
+		value = byte(s.temperature)
+	case tc74RegConfig:
+		if s.shutdown {
+			value |= tc74ConfigSHDN
+		}
+		if s.ready {
+			value |= tc74ConfigReady
+		}
+	}
+	for i := range r {
+		r[i] = value
+	}
+	return nil
+}
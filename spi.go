@@ -0,0 +1,14 @@
+package sensors
+
+import (
+	"context"
+)
+
+// SPIBus is a full-duplex SPI bus with the chip select of the target device
+// handled by the bus. Transfer asserts the chip select, clocks out w while
+// reading the same number of bytes into r and releases the chip select. r
+// may be nil when the received bytes are not needed, otherwise it must be as
+// long as w.
+type SPIBus interface {
+	Transfer(ctx context.Context, w, r []byte) error
+}